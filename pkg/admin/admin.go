@@ -0,0 +1,242 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admin creates instances and databases through the Spanner admin APIs
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	instance "cloud.google.com/go/spanner/admin/instance/apiv1"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	databasepb "google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+	instancepb "google.golang.org/genproto/googleapis/spanner/admin/instance/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Lookups are retried while the service is unavailable. Give up eventually, e.g. when no
+// emulator listens on the configured address.
+const lookupTimeout = 30 * time.Second
+
+// Interval between polls of a schema change. Each poll logs the statements completed since the
+// last one.
+var pollInterval = 5 * time.Second
+
+// CreateDatabase creates the configured instance and database if they do not exist. A new
+// database is created with the statements of create.ddl_file. Existing databases are left
+// untouched.
+func CreateDatabase(ctx context.Context, cfg *config.Config) error {
+	var statements []string
+	if cfg.Create.DDLFile != "" {
+		var err error
+		statements, err = ReadDDLFile(cfg.Create.DDLFile)
+		if err != nil {
+			return fmt.Errorf("unable to read ddl: %s", err.Error())
+		}
+	}
+
+	err := createInstance(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	created, err := createDatabase(ctx, cfg, statements)
+	if err != nil {
+		return err
+	}
+
+	if !created && len(statements) > 0 {
+		log.Printf("Database %s exists, not applying %s", cfg.Database, cfg.Create.DDLFile)
+	}
+
+	return nil
+}
+
+// ApplyDDL applies statements to the configured database and logs the progress of the schema
+// change. If create.database is set, a missing instance and database are created with the
+// statements instead.
+func ApplyDDL(ctx context.Context, cfg *config.Config, statements []string) error {
+	if cfg.Create.Database {
+		err := createInstance(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		created, err := createDatabase(ctx, cfg, statements)
+		if err != nil || created {
+			return err
+		}
+	}
+
+	client, err := database.NewDatabaseAdminClient(ctx, cfg.ClientOptions()...)
+	if err != nil {
+		return fmt.Errorf("unable to create database admin client: %s", err.Error())
+	}
+	defer client.Close()
+
+	op, err := client.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   cfg.DB(),
+		Statements: statements,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to update ddl: %s", err.Error())
+	}
+
+	log.Printf("Applying %d statements to database %s", len(statements), cfg.Database)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	applied := 0
+	throttled := false
+	for {
+		err := op.Poll(ctx)
+
+		// Statements are committed in order, each one gets a commit timestamp
+		md, merr := op.Metadata()
+		if merr == nil && md != nil {
+			for ; applied < len(md.CommitTimestamps) && applied < len(statements); applied++ {
+				log.Printf("[%d/%d] %s", applied+1, len(statements), summarizeStatement(statements[applied]))
+			}
+
+			if md.Throttled && !throttled {
+				log.Println("The schema change is throttled")
+			}
+			throttled = md.Throttled
+		}
+
+		if err != nil {
+			// Statements committed before the failure remain applied
+			return fmt.Errorf("statement %d of %d failed: %s", applied+1, len(statements), err.Error())
+		}
+
+		if op.Done() {
+			log.Printf("Applied %d statements", len(statements))
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for schema change %s: %s", op.Name(), ctx.Err().Error())
+		case <-ticker.C:
+		}
+	}
+}
+
+// summarizeStatement returns the statement on one line, shortened to the first words
+func summarizeStatement(stmt string) string {
+	s := strings.Join(strings.Fields(stmt), " ")
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+
+	return s
+}
+
+func createInstance(ctx context.Context, cfg *config.Config) error {
+	client, err := instance.NewInstanceAdminClient(ctx, cfg.ClientOptions()...)
+	if err != nil {
+		return fmt.Errorf("unable to create instance admin client: %s", err.Error())
+	}
+	defer client.Close()
+
+	lctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	_, err = client.GetInstance(lctx, &instancepb.GetInstanceRequest{Name: cfg.InstancePath()})
+	if err == nil {
+		return nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("unable to get instance: %s", err.Error())
+	}
+
+	instanceConfig := cfg.Create.InstanceConfig
+	if instanceConfig == "" {
+		if cfg.EmulatorHost == "" {
+			return fmt.Errorf("instance %s does not exist and create.instance_config is empty", cfg.Instance)
+		}
+		instanceConfig = config.EmulatorInstanceConfig
+	}
+	if !strings.Contains(instanceConfig, "/") {
+		instanceConfig = fmt.Sprintf("projects/%s/instanceConfigs/%s", cfg.Project, instanceConfig)
+	}
+
+	log.Printf("Creating instance %s (%s, %d processing units)", cfg.Instance, instanceConfig, cfg.Create.ProcessingUnits)
+	op, err := client.CreateInstance(ctx, &instancepb.CreateInstanceRequest{
+		Parent:     fmt.Sprintf("projects/%s", cfg.Project),
+		InstanceId: cfg.Instance,
+		Instance: &instancepb.Instance{
+			Config:          instanceConfig,
+			DisplayName:     cfg.Instance,
+			ProcessingUnits: int32(cfg.Create.ProcessingUnits),
+		},
+	})
+	if err == nil {
+		_, err = op.Wait(ctx)
+	}
+	// Another process may have created it in the meantime
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("unable to create instance: %s", err.Error())
+	}
+
+	return nil
+}
+
+// createDatabase creates the database with statements if it does not exist. It returns whether
+// the database was created.
+func createDatabase(ctx context.Context, cfg *config.Config, statements []string) (bool, error) {
+	client, err := database.NewDatabaseAdminClient(ctx, cfg.ClientOptions()...)
+	if err != nil {
+		return false, fmt.Errorf("unable to create database admin client: %s", err.Error())
+	}
+	defer client.Close()
+
+	lctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	_, err = client.GetDatabase(lctx, &databasepb.GetDatabaseRequest{Name: cfg.DB()})
+	if err == nil {
+		return false, nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return false, fmt.Errorf("unable to get database: %s", err.Error())
+	}
+
+	log.Printf("Creating database %s with %d statements", cfg.Database, len(statements))
+	op, err := client.CreateDatabase(ctx, &databasepb.CreateDatabaseRequest{
+		Parent:          cfg.InstancePath(),
+		CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`", cfg.Database),
+		ExtraStatements: statements,
+	})
+	if err == nil {
+		_, err = op.Wait(ctx)
+	}
+	if err != nil {
+		// Another process may have created it in the meantime
+		if status.Code(err) == codes.AlreadyExists {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("unable to create database: %s", err.Error())
+	}
+
+	return true, nil
+}
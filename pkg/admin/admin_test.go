@@ -0,0 +1,60 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner/spannertest"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+)
+
+func TestApplyDDL(t *testing.T) {
+	srv, err := spannertest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+	srv.SetLogger(func(string, ...interface{}) {})
+
+	defer func(d time.Duration) { pollInterval = d }(pollInterval)
+	pollInterval = 10 * time.Millisecond
+
+	cfg := &config.Config{Project: "p", Instance: "i", Database: "d", EmulatorHost: srv.Addr}
+	ctx := context.Background()
+
+	create := []string{
+		"CREATE TABLE Singers (SingerId INT64 NOT NULL, Name STRING(MAX)) PRIMARY KEY (SingerId)",
+		"CREATE INDEX SingersByName ON Singers(Name)",
+	}
+	if err := ApplyDDL(ctx, cfg, create); err != nil {
+		t.Fatalf("ApplyDDL: %v", err)
+	}
+
+	// The table exists now
+	if err := ApplyDDL(ctx, cfg, create[:1]); err == nil {
+		t.Error("creating an existing table should fail")
+	}
+
+	drop := []string{"DROP INDEX SingersByName", "DROP TABLE Singers"}
+	if err := ApplyDDL(ctx, cfg, drop); err != nil {
+		t.Fatalf("ApplyDDL: %v", err)
+	}
+	if err := ApplyDDL(ctx, cfg, drop[1:]); err == nil {
+		t.Error("dropping a missing table should fail")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ReadDDLFile reads the statements of a DDL file
+func ReadDDLFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadDDL(f)
+}
+
+// ReadDDL splits DDL into statements separated by semicolons, like the files in schemas/.
+// Comments are removed, since the admin API does not accept them.
+func ReadDDL(r io.Reader) ([]string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	ddl := string(b)
+
+	statements := make([]string, 0)
+	var stmt strings.Builder
+	add := func() {
+		if s := strings.TrimSpace(stmt.String()); s != "" {
+			statements = append(statements, s)
+		}
+		stmt.Reset()
+	}
+
+	for i := 0; i < len(ddl); i++ {
+		c := ddl[i]
+		switch {
+		case c == ';':
+			add()
+		case c == '-' && strings.HasPrefix(ddl[i:], "--"), c == '#':
+			// Line comment
+			end := strings.IndexByte(ddl[i:], '\n')
+			if end < 0 {
+				i = len(ddl)
+			} else {
+				i += end - 1
+			}
+		case c == '/' && strings.HasPrefix(ddl[i:], "/*"):
+			end := strings.Index(ddl[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			i += end + 3
+			stmt.WriteByte(' ')
+		case c == '\'' || c == '"' || c == '`':
+			// Quoted strings and identifiers may contain any of the above
+			end := i + 1
+			for ; end < len(ddl) && ddl[end] != c; end++ {
+				if ddl[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(ddl) {
+				return nil, fmt.Errorf("unterminated quote at offset %d", i)
+			}
+			stmt.WriteString(ddl[i : end+1])
+			i = end
+		default:
+			stmt.WriteByte(c)
+		}
+	}
+	add()
+
+	return statements, nil
+}
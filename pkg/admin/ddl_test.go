@@ -0,0 +1,65 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDDL(t *testing.T) {
+	ddl := `/* Header; with a semicolon */
+CREATE TABLE Singers (
+  SingerId INT64 NOT NULL, -- the key; not null
+  Name     STRING(MAX) DEFAULT ("a;b"),
+) PRIMARY KEY (SingerId);
+
+# Index
+CREATE INDEX SingersByName ON Singers(Name);
+`
+
+	got, err := ReadDDL(strings.NewReader(ddl))
+	if err != nil {
+		t.Fatalf("ReadDDL: %v", err)
+	}
+
+	want := []string{
+		"CREATE TABLE Singers (\n  SingerId INT64 NOT NULL, \n  Name     STRING(MAX) DEFAULT (\"a;b\"),\n) PRIMARY KEY (SingerId)",
+		"CREATE INDEX SingersByName ON Singers(Name)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadDDL() = %q, want %q", got, want)
+	}
+
+	if _, err := ReadDDL(strings.NewReader("CREATE TABLE T (/* open")); err == nil {
+		t.Error("expected an error for an unterminated comment")
+	}
+	if _, err := ReadDDL(strings.NewReader("CREATE TABLE `T")); err == nil {
+		t.Error("expected an error for an unterminated quote")
+	}
+}
+
+func TestReadDDLFile(t *testing.T) {
+	got, err := ReadDDLFile("../../schemas/multi_table.sql")
+	if err != nil {
+		t.Fatalf("ReadDDLFile: %v", err)
+	}
+
+	// Five tables and four indexes
+	if len(got) != 9 || !strings.HasPrefix(got[0], "CREATE TABLE Singers") || got[8] != "CREATE INDEX ConcertsBySingerId ON Concerts(SingerId)" {
+		t.Errorf("unexpected statements %q", got)
+	}
+}
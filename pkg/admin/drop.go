@@ -0,0 +1,85 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+)
+
+// DropStatements returns the statements dropping tables and views from s. Interleaved children
+// are dropped before their parents and indexes before their tables. Named foreign keys of the
+// dropped tables are dropped first. Tables with interleaved children that are not dropped
+// as well are an error.
+func DropStatements(s schema.Schema, tables []string) ([]string, error) {
+	drop := make(map[string]bool, len(tables))
+	targets := make([]schema.Table, 0, len(tables))
+	for _, name := range tables {
+		if drop[name] {
+			continue
+		}
+
+		t := s.GetTable(name)
+		if t == nil {
+			return nil, fmt.Errorf("unknown table '%s'", name)
+		}
+
+		drop[name] = true
+		targets = append(targets, t)
+	}
+
+	for _, t := range s.Tables().Tables() {
+		if drop[t.ParentName()] && !drop[t.Name()] {
+			return nil, fmt.Errorf("table '%s' has interleaved child '%s', drop it as well", t.ParentName(), t.Name())
+		}
+
+		for _, fk := range t.ForeignKeys() {
+			if drop[fk.ReferencedTable()] && !drop[t.Name()] {
+				return nil, fmt.Errorf("table '%s' has a foreign key referencing '%s', drop it as well", t.Name(), fk.ReferencedTable())
+			}
+		}
+	}
+
+	// Drop the deepest tables of each hierarchy first
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Depth() > targets[j].Depth()
+	})
+
+	statements := make([]string, 0)
+	for _, t := range targets {
+		for _, fk := range t.ForeignKeys() {
+			if fk.Name() != "" {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", t.Name(), fk.Name()))
+			}
+		}
+	}
+
+	for _, t := range targets {
+		if t.IsView() {
+			statements = append(statements, fmt.Sprintf("DROP VIEW %s", t.Name()))
+			continue
+		}
+
+		for _, idx := range t.Indexes().Indexes() {
+			statements = append(statements, fmt.Sprintf("DROP INDEX %s", idx.IndexName()))
+		}
+
+		statements = append(statements, fmt.Sprintf("DROP TABLE %s", t.Name()))
+	}
+
+	return statements, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+)
+
+func TestDropStatements(t *testing.T) {
+	s, err := schema.LoadSchemaFile("../../schemas/multi_table.sql")
+	if err != nil {
+		t.Fatalf("LoadSchemaFile: %v", err)
+	}
+
+	got, err := DropStatements(s, []string{"Singers", "Albums", "Songs", "Venues", "Concerts", "Songs"})
+	if err != nil {
+		t.Fatalf("DropStatements: %v", err)
+	}
+
+	want := []string{
+		"ALTER TABLE Concerts DROP CONSTRAINT FKConcertsSingerId",
+		"DROP INDEX SongsBySingerAlbumSongNameDesc",
+		"DROP TABLE Songs",
+		"DROP INDEX AlbumsByAlbumTitle",
+		"DROP TABLE Albums",
+		"DROP INDEX ConcertsBySingerId",
+		"DROP TABLE Concerts",
+		"DROP INDEX SingersByFirstLastName",
+		"DROP TABLE Singers",
+		"DROP TABLE Venues",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DropStatements() = %q, want %q", got, want)
+	}
+
+	for _, tables := range [][]string{
+		{"Missing"},
+		{"Singers"},                    // Interleaved children are not dropped
+		{"Singers", "Albums", "Songs"}, // Concerts references Singers
+	} {
+		if _, err := DropStatements(s, tables); err == nil {
+			t.Errorf("DropStatements(%q) should fail", tables)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// package assertion parses and evaluates pass/fail conditions on the metrics of a run, such as
+//
+//	operations.read.time p99 < 50ms
+//	error_rate < 0.1%
+//	throughput > 5000/s
+//	operations.write.rate >= 1000/s
+package assertion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	// Metrics computed from the whole run rather than read from the registry
+	MetricErrorRate  = "error_rate" // Failed operations / attempted operations
+	MetricErrors     = "errors"     // Number of failed operations
+	MetricThroughput = "throughput" // Successful operations per second
+
+	StatCount = "count"
+)
+
+type (
+	// Assertion is a single parsed condition
+	Assertion struct {
+		Expr      string  // The expression as written in the configuration
+		Metric    string  // Metric name
+		Stat      string  // Timer statistic. Empty for other metrics
+		Op        string  // One of <, <=, >, >=
+		Threshold float64 // Right hand side in the unit of the metric (nanoseconds for durations)
+		format    func(float64) string
+	}
+
+	// Snapshot is the state of a finished run that assertions are evaluated against
+	Snapshot struct {
+		Registry   metrics.Registry // Registry holding timers and meters
+		Elapsed    time.Duration    // Duration of the phase, used to compute rates
+		Operations int64            // Attempted operations
+		Errors     int64            // Failed operations
+	}
+
+	// Result is the outcome of evaluating an Assertion
+	Result struct {
+		Assertion *Assertion
+		Actual    string // Formatted value of the metric. Empty if Err is set
+		Passed    bool
+		Err       error // Set if the assertion could not be evaluated, which counts as a failure
+	}
+)
+
+// ParseAll parses every expression and returns all syntax errors at once
+func ParseAll(exprs []string) ([]*Assertion, error) {
+	var result *multierror.Error
+
+	ret := make([]*Assertion, 0, len(exprs))
+	for _, e := range exprs {
+		a, err := Parse(e)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+
+		ret = append(ret, a)
+	}
+
+	return ret, result.ErrorOrNil()
+}
+
+// Parse parses an expression of the form '<metric> [<stat>] <op> <value>'. Timers require a
+// statistic (min, max, mean, stddev, median, count or a percentile such as p99 or p99.9).
+func Parse(expr string) (*Assertion, error) {
+	fields := strings.Fields(expr)
+
+	a := &Assertion{Expr: strings.Join(fields, " ")}
+
+	var value string
+	switch len(fields) {
+	case 3:
+		a.Metric, a.Op, value = fields[0], fields[1], fields[2]
+	case 4:
+		a.Metric, a.Stat, a.Op, value = fields[0], fields[1], fields[2], fields[3]
+		if _, err := statValue(metrics.NewTimer(), a.Stat); err != nil {
+			return nil, fmt.Errorf("assertion '%s': %s", expr, err.Error())
+		}
+	default:
+		return nil, fmt.Errorf("assertion '%s': expected '<metric> [<stat>] <op> <value>'", expr)
+	}
+
+	switch a.Op {
+	case "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("assertion '%s': unknown operator '%s'", expr, a.Op)
+	}
+
+	var err error
+	switch {
+	case a.Stat == "" && a.Metric == MetricErrorRate:
+		a.Threshold, err = parseRatio(value)
+		a.format = formatPercent
+	case a.Stat == "" && a.Metric == MetricErrors:
+		a.Threshold, err = parseCount(value)
+		a.format = formatCount
+	case a.Stat == "": // throughput or a meter
+		a.Threshold, err = parseRate(value)
+		a.format = formatRate
+	case a.Stat == StatCount:
+		a.Threshold, err = parseCount(value)
+		a.format = formatCount
+	default:
+		var d time.Duration
+		d, err = time.ParseDuration(value)
+		a.Threshold = float64(d)
+		a.format = formatDuration
+	}
+	if err != nil {
+		return nil, fmt.Errorf("assertion '%s': invalid value '%s': %s", expr, value, err.Error())
+	}
+
+	return a, nil
+}
+
+// Evaluate evaluates the assertion against a finished run
+func (a *Assertion) Evaluate(s Snapshot) Result {
+	actual, err := a.value(s)
+	if err != nil {
+		return Result{Assertion: a, Err: err}
+	}
+
+	var passed bool
+	switch a.Op {
+	case "<":
+		passed = actual < a.Threshold
+	case "<=":
+		passed = actual <= a.Threshold
+	case ">":
+		passed = actual > a.Threshold
+	case ">=":
+		passed = actual >= a.Threshold
+	}
+
+	return Result{Assertion: a, Actual: a.format(actual), Passed: passed}
+}
+
+// Evaluate evaluates all assertions and returns their results in order
+func Evaluate(assertions []*Assertion, s Snapshot) []Result {
+	ret := make([]Result, 0, len(assertions))
+	for _, a := range assertions {
+		ret = append(ret, a.Evaluate(s))
+	}
+
+	return ret
+}
+
+func (a *Assertion) value(s Snapshot) (float64, error) {
+	switch {
+	case a.Stat == "" && a.Metric == MetricErrorRate:
+		if s.Operations == 0 {
+			return 0, nil
+		}
+		return float64(s.Errors) / float64(s.Operations), nil
+	case a.Stat == "" && a.Metric == MetricErrors:
+		return float64(s.Errors), nil
+	case a.Stat == "" && a.Metric == MetricThroughput:
+		return rate(s.Operations-s.Errors, s.Elapsed), nil
+	}
+
+	m := s.Registry.Get(a.Metric)
+	if m == nil {
+		return 0, fmt.Errorf("metric '%s' not found", a.Metric)
+	}
+
+	if a.Stat == "" {
+		meter, ok := m.(metrics.Meter)
+		if !ok {
+			return 0, fmt.Errorf("metric '%s' is not a meter, add a statistic such as p99", a.Metric)
+		}
+		return rate(meter.Count(), s.Elapsed), nil
+	}
+
+	t, ok := m.(metrics.Timer)
+	if !ok {
+		return 0, fmt.Errorf("metric '%s' is not a timer", a.Metric)
+	}
+
+	return statValue(t, a.Stat)
+}
+
+// statValue returns a statistic of a timer
+func statValue(t metrics.Timer, stat string) (float64, error) {
+	switch stat {
+	case "min":
+		return float64(t.Min()), nil
+	case "max":
+		return float64(t.Max()), nil
+	case "mean":
+		return t.Mean(), nil
+	case "stddev":
+		return t.StdDev(), nil
+	case "median":
+		return t.Percentile(0.5), nil
+	case StatCount:
+		return float64(t.Count()), nil
+	}
+
+	if strings.HasPrefix(stat, "p") {
+		p, err := strconv.ParseFloat(stat[1:], 64)
+		if err == nil && p > 0 && p <= 100 {
+			return t.Percentile(p / 100), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown statistic '%s'", stat)
+}
+
+func rate(n int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(n) / elapsed.Seconds()
+}
+
+// parseRatio parses a percentage such as 0.1% or a fraction such as 0.001
+func parseRatio(s string) (float64, error) {
+	if strings.HasSuffix(s, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		return f / 100, err
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseRate parses a per second rate such as 5000/s or 5000
+func parseRate(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSuffix(s, "/s"), 64)
+}
+
+func parseCount(s string) (float64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	return float64(n), err
+}
+
+func formatPercent(f float64) string {
+	return strconv.FormatFloat(f*100, 'g', 4, 64) + "%"
+}
+
+func formatCount(f float64) string {
+	return strconv.FormatInt(int64(f), 10)
+}
+
+func formatRate(f float64) string {
+	return strconv.FormatFloat(f, 'f', 1, 64) + "/s"
+}
+
+func formatDuration(f float64) string {
+	return time.Duration(f).String()
+}
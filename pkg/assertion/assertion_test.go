@@ -0,0 +1,132 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParse(t *testing.T) {
+	Convey("Parse", t, func() {
+		Convey("Timer statistics", func() {
+			a, err := Parse("operations.read.time  p99 <  50ms")
+			So(err, ShouldBeNil)
+			So(a.Expr, ShouldEqual, "operations.read.time p99 < 50ms")
+			So(a.Metric, ShouldEqual, "operations.read.time")
+			So(a.Stat, ShouldEqual, "p99")
+			So(a.Threshold, ShouldEqual, float64(50*time.Millisecond))
+
+			a, err = Parse("operations.read.time count >= 100")
+			So(err, ShouldBeNil)
+			So(a.Threshold, ShouldEqual, 100)
+		})
+
+		Convey("Error rate", func() {
+			a, err := Parse("error_rate < 0.1%")
+			So(err, ShouldBeNil)
+			So(a.Threshold, ShouldAlmostEqual, 0.001)
+
+			a, err = Parse("error_rate <= 0.01")
+			So(err, ShouldBeNil)
+			So(a.Threshold, ShouldAlmostEqual, 0.01)
+		})
+
+		Convey("Throughput", func() {
+			a, err := Parse("throughput > 5000/s")
+			So(err, ShouldBeNil)
+			So(a.Threshold, ShouldEqual, 5000)
+		})
+
+		Convey("Invalid expressions", func() {
+			for _, e := range []string{
+				"",
+				"throughput",
+				"throughput = 5000/s",
+				"operations.read.time p101 < 50ms",
+				"operations.read.time p99 < fast",
+				"operations.read.time avg < 50ms",
+				"errors < 1.5",
+			} {
+				_, err := Parse(e)
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("ParseAll reports every error", func() {
+			_, err := ParseAll([]string{"a < b", "throughput > 1/s", "c"})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "'a < b'")
+			So(err.Error(), ShouldContainSubstring, "'c'")
+		})
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	Convey("Evaluate", t, func() {
+		registry := metrics.NewRegistry()
+		timer := metrics.GetOrRegisterTimer("operations.read.time", registry)
+		for i := 1; i <= 100; i++ {
+			timer.Update(time.Duration(i) * time.Millisecond)
+		}
+		meter := metrics.GetOrRegisterMeter("operations.read.rate", registry)
+		meter.Mark(1000)
+
+		s := Snapshot{
+			Registry:   registry,
+			Elapsed:    10 * time.Second,
+			Operations: 1000,
+			Errors:     5,
+		}
+
+		eval := func(expr string) Result {
+			a, err := Parse(expr)
+			So(err, ShouldBeNil)
+			return a.Evaluate(s)
+		}
+
+		Convey("Timer statistics", func() {
+			r := eval("operations.read.time p50 < 60ms")
+			So(r.Err, ShouldBeNil)
+			So(r.Passed, ShouldBeTrue)
+
+			r = eval("operations.read.time max < 50ms")
+			So(r.Passed, ShouldBeFalse)
+			So(r.Actual, ShouldEqual, "100ms")
+		})
+
+		Convey("Run metrics", func() {
+			r := eval("error_rate < 0.1%")
+			So(r.Passed, ShouldBeFalse)
+			So(r.Actual, ShouldEqual, "0.5%")
+
+			So(eval("errors <= 5").Passed, ShouldBeTrue)
+			So(eval("throughput >= 99.5/s").Passed, ShouldBeTrue)
+			So(eval("operations.read.rate > 100/s").Passed, ShouldBeFalse)
+		})
+
+		Convey("Missing metrics fail", func() {
+			r := eval("operations.write.time p99 < 1s")
+			So(r.Passed, ShouldBeFalse)
+			So(r.Err, ShouldNotBeNil)
+
+			r = eval("operations.read.rate p99 < 1s")
+			So(r.Err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backend provides the database gcsb executes workloads against
+package backend
+
+import (
+	"context"
+	"io/ioutil"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spansql"
+)
+
+const (
+	Spanner = "spanner" // Cloud Spanner or the emulator
+	Memory  = "memory"  // In process fake of Cloud Spanner, see NewMemory
+)
+
+var (
+	// Assert that *spanner.Client implements Client
+	_ Client = (*spanner.Client)(nil)
+)
+
+type (
+	// Client is the part of *spanner.Client used by gcsb
+	Client interface {
+		Single() *spanner.ReadOnlyTransaction
+		ReadOnlyTransaction() *spanner.ReadOnlyTransaction
+		BatchReadOnlyTransaction(ctx context.Context, tb spanner.TimestampBound) (*spanner.BatchReadOnlyTransaction, error)
+		ReadWriteTransaction(ctx context.Context, f func(context.Context, *spanner.ReadWriteTransaction) error) (time.Time, error)
+		Apply(ctx context.Context, ms []*spanner.Mutation, opts ...spanner.ApplyOption) (time.Time, error)
+		PartitionedUpdate(ctx context.Context, statement spanner.Statement) (int64, error)
+		Close()
+	}
+)
+
+// ParseDDLFile parses the statements of a DDL file
+func ParseDDLFile(path string) (*spansql.DDL, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return spansql.ParseDDL(path, string(b))
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spannertest"
+	"cloud.google.com/go/spanner/spansql"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+const (
+	// Database name of in memory backends. The fake serves a single database under any name.
+	MemoryDatabase = "projects/gcsb/instances/memory/databases/memory"
+)
+
+type (
+	// memory is a client of an in process spannertest server
+	memory struct {
+		*spanner.Client
+		server *spannertest.Server
+	}
+)
+
+// NewMemory starts an in memory fake of Cloud Spanner with the tables of ddl and returns a
+// client of it. The fake is meant for checking configurations and testing gcsb, not for
+// measuring performance. It does not support partitioned queries or table sampling (samples
+// return every row), and stores NUMERIC and JSON columns as strings. Closing the client stops
+// the fake.
+func NewMemory(ctx context.Context, ddl *spansql.DDL, cfg spanner.ClientConfig) (Client, error) {
+	srv, err := spannertest.NewServer("localhost:0")
+	if err != nil {
+		return nil, fmt.Errorf("starting in memory backend: %s", err.Error())
+	}
+	srv.SetLogger(func(string, ...interface{}) {})
+
+	err = srv.UpdateDDL(memoryDDL(ddl))
+	if err != nil {
+		srv.Close()
+		return nil, fmt.Errorf("applying ddl to in memory backend: %s", err.Error())
+	}
+
+	client, err := spanner.NewClientWithConfig(ctx, MemoryDatabase, cfg,
+		option.WithEndpoint(srv.Addr),
+		option.WithGRPCDialOption(grpc.WithInsecure()),
+		option.WithoutAuthentication(),
+	)
+	if err != nil {
+		srv.Close()
+		return nil, err
+	}
+
+	return &memory{Client: client, server: srv}, nil
+}
+
+// Close closes the client and stops the fake
+func (m *memory) Close() {
+	m.Client.Close()
+	m.server.Close()
+}
+
+// memoryDDL returns a copy of ddl with the column types the fake does not support replaced by
+// STRING(MAX). Values of these types are sent as strings, so the fake accepts them as such.
+func memoryDDL(ddl *spansql.DDL) *spansql.DDL {
+	ret := &spansql.DDL{Filename: ddl.Filename, List: make([]spansql.DDLStmt, 0, len(ddl.List))}
+
+	for _, stmt := range ddl.List {
+		if ct, ok := stmt.(*spansql.CreateTable); ok {
+			c := *ct
+			c.Columns = make([]spansql.ColumnDef, len(ct.Columns))
+			for i, cd := range ct.Columns {
+				if cd.Type.Base == spansql.Numeric || cd.Type.Base == spansql.JSON {
+					cd.Type.Base, cd.Type.Len = spansql.String, spansql.MaxLen
+				}
+				c.Columns[i] = cd
+			}
+			stmt = &c
+		}
+
+		ret.List = append(ret.List, stmt)
+	}
+
+	return ret
+}
@@ -0,0 +1,201 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/distributed"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	flags := agentCmd.Flags()
+	flags.StringVar(&agentCoordinator, "coordinator", "localhost:7070", "Address of the coordinator")
+	flags.StringVar(&agentName, "name", "", "Name of the agent in the coordinators logs (default is the host name)")
+	flags.StringVar(&agentReportFile, "report-file", "", "Also write the report of this agent to this file as JSON")
+	flags.StringVar(&agentMetricsAddr, "metrics-addr", "", "Serve prometheus metrics on this address (e.g. :9090) while running")
+
+	rootCmd.AddCommand(agentCmd)
+}
+
+var (
+	// Flags
+	agentCoordinator string
+	agentName        string
+	agentReportFile  string
+	agentMetricsAddr string
+
+	// Command
+	agentCmd = &cobra.Command{
+		Use:   "agent",
+		Short: "Execute a partition of a workload for a coordinator",
+		Long: `Registers with a coordinator started with 'gcsb coordinator', executes the share of the workload it is assigned
+and sends the results back. The configuration is taken from the coordinator.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if agentName == "" {
+				agentName, _ = os.Hostname()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Listen for os signals and cancel the context if we receive them
+			graceful(cancel)
+
+			a := distributed.NewAgent(agentCoordinator, agentName)
+			err := a.Run(ctx, func(ctx context.Context, as *distributed.Assignment, ready func() (time.Time, error)) (*report.Report, error) {
+				return executeAssignment(ctx, a, as, ready)
+			})
+			if err != nil {
+				log.Fatalf("unable to execute assignment: %s", err.Error())
+			}
+		},
+	}
+)
+
+// agentConfig reads the configuration handed out by the coordinator. Settings that only make
+// sense on the coordinator are replaced by the agents flags.
+func agentConfig(as *distributed.Assignment) (*config.Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err := v.ReadConfig(strings.NewReader(as.Settings))
+	if err != nil {
+		return nil, err
+	}
+
+	v.Set("partition.index", as.Partition.Index)
+	v.Set("partition.count", as.Partition.Count)
+	v.Set("metrics_addr", agentMetricsAddr)
+	v.Set("report.interval_file", "")
+	v.Set("report.file", agentReportFile)
+	v.Set("report.format", config.ReportFormatTable)
+	if agentReportFile != "" {
+		v.Set("report.format", config.ReportFormatJSON)
+	}
+
+	cfg, err := config.NewConfig(v)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// executeAssignment prepares the workload, waits for the common start and executes it
+func executeAssignment(ctx context.Context, a *distributed.Agent, as *distributed.Assignment, ready func() (time.Time, error)) (*report.Report, error) {
+	cfg, err := agentConfig(as)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load configuration: %s", err.Error())
+	}
+	logConfig(cfg)
+
+	if as.Phase == distributed.PhaseLoad {
+		// See the load command
+		cfg.Pool.WriteSessions = 1
+	}
+
+	// Get metric registry
+	registry := metrics.NewRegistry()
+
+	// Cancel the workload along with the agent
+	wctx, cancel := cfg.Context()
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+
+	// Infer the table schema from the database
+	log.Printf("Infering schema from %s", schemaSource(cfg))
+	s, err := schema.LoadSchema(wctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to infer schema: %s", err.Error())
+	}
+
+	// Create a workload. The observer is attached once the phase starts
+	log.Println("Creating workload")
+	wl, err := workload.NewCoreWorkload(workload.WorkloadConfig{
+		Context:        wctx,
+		Config:         cfg,
+		Schema:         s,
+		MetricRegistry: registry,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create workload: %s", err.Error())
+	}
+	defer wl.Stop()
+	cw := wl.(*workload.CoreWorkload)
+
+	// Plan and sample before the start, so that all agents start executing operations together
+	jobType := workload.JobRun
+	if as.Phase == distributed.PhaseLoad {
+		jobType = workload.JobLoad
+	}
+	log.Printf("Preparing %s phase", as.Phase)
+	err = cw.Prepare(jobType, as.Tables)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare %s phase: %s", as.Phase, err.Error())
+	}
+
+	// Wait for the other agents. Intervals start with the workload, so that the intervals of
+	// all agents cover the same time.
+	log.Println("Waiting for the other agents")
+	_, err = ready()
+	if err != nil {
+		return nil, fmt.Errorf("waiting for the start: %s", err.Error())
+	}
+
+	// Serve live metrics and stream interval statistics to the coordinator
+	obs, stop := startObserver(wctx, cfg, a.SendInterval)
+	var once sync.Once
+	stopObserver := func() { once.Do(stop) }
+	defer stopObserver()
+	cw.Observer = obs
+
+	// measure the phase
+	runTimer := metrics.GetOrRegisterTimer("run", registry)
+
+	log.Printf("Executing %s phase", as.Phase)
+	start := time.Now()
+	runTimer.Time(func() {
+		err = cw.Execute()
+	})
+	// Summarize what completed even if the phase was aborted
+	rep := newReport(as.Phase, start, time.Now(), cfg, wl, registry)
+	summarizeReport(rep)
+
+	// Flush the final interval before the result is sent
+	stopObserver()
+	writeReport(cfg, rep)
+
+	return rep, err
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/assertion"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/distributed"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	flags := coordinatorCmd.Flags()
+	flags.StringVar(&coordinatorListen, "listen", ":7070", "Address agents connect to")
+	flags.IntVar(&coordinatorAgents, "agents", 1, "Number of agents to wait for")
+	flags.StringVar(&coordinatorPhase, "phase", "run", "Phase executed by the agents (load, run)")
+	flags.StringSliceVarP(&coordinatorTables, "table", "t", []string{}, "Table name to load or run against")
+	flags.DurationVar(&coordinatorStartDelay, "start-delay", distributed.DefaultStartDelay, "Delay between the last agent becoming ready and the start")
+
+	flags.IntP("operations", "o", 1000, "Number of operations to perform across all agents")
+	flags.Int("threads", 10, "Number of threads per agent")
+	flags.Int64("seed", 0, "Seed for all random generators (0 picks a random seed)")
+	flags.IntP("reads", "r", 50, "Read weight")
+	flags.IntP("writes", "w", 50, "Write weight")
+	flags.String("workload", "", "Apply a preset such as ycsb-a on top of the configuration (see 'gcsb init')")
+	flags.String("report-format", "table", "Report format (table, json, csv)")
+	flags.String("report-file", "", "Write the merged report to this file (json and csv reports go to stdout by default)")
+	flags.Duration("report-interval", 0, "Stream and log throughput, latency and errors of every interval (e.g. 10s, 0 disables)")
+	flags.StringSlice("percentiles", []string{"50", "75", "90", "95", "99", "99.9", "99.99"}, "Latency percentiles to report")
+	flags.BoolVar(&coordinatorDry, "dry", false, "Dry run. Print config and exit.")
+
+	rootCmd.AddCommand(coordinatorCmd)
+}
+
+var (
+	// Flags
+	coordinatorListen     string
+	coordinatorAgents     int
+	coordinatorPhase      string
+	coordinatorTables     []string
+	coordinatorStartDelay time.Duration
+	coordinatorDry        bool
+
+	// Command
+	coordinatorCmd = &cobra.Command{
+		Use:   "coordinator",
+		Short: "Coordinate a load or run phase across several agents",
+		Long: `Waits for --agents agents started with 'gcsb agent' to connect, hands each of them the configuration and a partition of the workload,
+starts them at the same moment and merges their results into one report.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			flags := cmd.Flags()
+			viper.BindPFlag("operations.total", flags.Lookup("operations"))
+			viper.BindPFlag("threads", flags.Lookup("threads"))
+			viper.BindPFlag("seed", flags.Lookup("seed"))
+			viper.BindPFlag("operations.read", flags.Lookup("reads"))
+			viper.BindPFlag("operations.write", flags.Lookup("writes"))
+			viper.BindPFlag("workload", flags.Lookup("workload"))
+			viper.BindPFlag("report.format", flags.Lookup("report-format"))
+			viper.BindPFlag("report.file", flags.Lookup("report-file"))
+			viper.BindPFlag("report.interval", flags.Lookup("report-interval"))
+			viper.BindPFlag("report.percentiles", flags.Lookup("percentiles"))
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			// Presets know the tables of each phase. The agents receive the merged configuration.
+			if p := applyWorkload(); p != nil && len(coordinatorTables) <= 0 {
+				coordinatorTables = p.Run
+				if coordinatorPhase == "load" {
+					coordinatorTables = p.Load
+				}
+			}
+
+			if len(coordinatorTables) <= 0 {
+				log.Fatal("missing table name (-t)")
+			}
+
+			// Load configuration
+			log.Println("Loading configuration")
+			cfg, err := config.NewConfig(viper.GetViper())
+			if err != nil {
+				log.Fatalf("unable to parse configuration: %s", err.Error())
+			}
+
+			// Validate the configuration
+			log.Println("Validating configuration")
+			err = cfg.Validate()
+			if err != nil {
+				log.Fatalf("unable to validate configuration %s", err.Error())
+			}
+
+			// Log the configuration
+			logConfig(cfg)
+			if coordinatorDry {
+				log.Println("Exiting (--dry)")
+				os.Exit(0)
+			}
+
+			// Agents share the seed so that only their partitions tell their workers apart
+			viper.Set("seed", cfg.Seed)
+			settings, err := yaml.Marshal(viper.AllSettings())
+			if err != nil {
+				log.Fatalf("unable to marshal configuration: %s", err.Error())
+			}
+
+			c, err := distributed.NewCoordinator(distributed.CoordinatorConfig{
+				Agents:     coordinatorAgents,
+				Phase:      coordinatorPhase,
+				Tables:     coordinatorTables,
+				Settings:   settings,
+				StartDelay: coordinatorStartDelay,
+			})
+			if err != nil {
+				log.Fatalf("unable to create coordinator: %s", err.Error())
+			}
+
+			// Generate a context with cancelation
+			ctx, cancel := cfg.Context()
+
+			// Listen for os signals and cancel the context if we receive them
+			graceful(cancel)
+
+			err = c.Serve(coordinatorListen)
+			if err != nil {
+				log.Fatalf("unable to start coordinator: %s", err.Error())
+			}
+
+			reports, err := c.Wait(ctx)
+			c.Shutdown(5 * time.Second)
+			if len(reports) == 0 {
+				log.Fatalf("no agent reported results: %s", err)
+			}
+
+			// Summarize what completed even if agents failed
+			rep := mergeReports(cfg, reports)
+			summarizeReport(rep)
+			writeReport(cfg, rep)
+			if err != nil {
+				log.Fatalf("unable to execute %s operation: %s", coordinatorPhase, err.Error())
+			}
+
+			// Fail the process if the results do not meet the configured assertions
+			checkAssertions(rep)
+		},
+	}
+)
+
+// mergeReports merges the reports of all agents and evaluates the configured assertions
+// against the merged report
+func mergeReports(cfg *config.Config, reports []*report.Report) *report.Report {
+	log.Printf("Merging the reports of %d agents", len(reports))
+
+	rep, err := report.Merge(reports...)
+	if err != nil {
+		log.Fatalf("unable to merge reports: %s", err.Error())
+	}
+
+	if len(cfg.Assertions) > 0 {
+		registry, err := rep.Registry()
+		if err != nil {
+			log.Fatalf("unable to restore metrics from reports: %s", err.Error())
+		}
+
+		as, _ := assertion.ParseAll(cfg.Assertions)
+		rep.AddAssertions(assertion.Evaluate(as, rep.Snapshot(registry)))
+	}
+
+	return rep
+}
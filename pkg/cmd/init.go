@@ -0,0 +1,170 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/admin"
+	"github.com/cloudspannerecosystem/gcsb/pkg/preset"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	flags := initCmd.Flags()
+	flags.StringVar(&initPreset, "preset", "", "Preset to initialize (omit to list the presets)")
+	flags.StringVarP(&initOutput, "output", "o", "gcsb.yaml", "Configuration file to write. The schema is written next to it.")
+	flags.BoolVar(&initForce, "force", false, "Overwrite existing files")
+	flags.BoolVar(&initApply, "apply", false, "Apply the schema to the database")
+	flags.Bool("create-database", false, "Create the instance and database with the schema if they do not exist (with --apply)")
+
+	rootCmd.AddCommand(initCmd)
+}
+
+var (
+	// Flags
+	initPreset string
+	initOutput string
+	initForce  bool
+	initApply  bool
+
+	// Commands
+	initCmd = &cobra.Command{
+		Use:   "init",
+		Short: "Write the configuration and schema of a built-in benchmark",
+		Long: `Writes a ready to run gcsb.yaml and the DDL of a preset benchmark. The presets are shipped with gcsb,
+so no copy of the repository is needed. With --apply the schema is applied to the database as well.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlag("create.database", cmd.Flags().Lookup("create-database"))
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if initPreset == "" {
+				listPresets()
+				return
+			}
+
+			p, err := preset.Get(initPreset)
+			if err != nil {
+				log.Fatal(err.Error())
+			}
+
+			ddl, err := p.DDL()
+			if err != nil {
+				log.Fatalf("unable to read schema of preset %s: %s", p.Name, err.Error())
+			}
+
+			ddlFile := filepath.Join(filepath.Dir(initOutput), p.Name+".sql")
+			cfgFile, err := p.Config(presetHeader(p, ddlFile))
+			if err != nil {
+				log.Fatalf("unable to read configuration of preset %s: %s", p.Name, err.Error())
+			}
+
+			writeInitFile(ddlFile, ddl)
+			writeInitFile(initOutput, cfgFile)
+
+			if initApply {
+				cfg := schemaConfig()
+
+				statements, err := admin.ReadDDL(bytes.NewReader(ddl))
+				if err != nil {
+					log.Fatalf("unable to read ddl: %s", err.Error())
+				}
+
+				ctx, cancel := cfg.Context()
+				graceful(cancel)
+
+				err = admin.ApplyDDL(ctx, cfg, statements)
+				if err != nil {
+					log.Fatalf("unable to apply ddl: %s", err.Error())
+				}
+			}
+
+			var flags string
+			if initOutput != "gcsb.yaml" {
+				flags = fmt.Sprintf(" --config %s", initOutput)
+			}
+			tables := func(names []string) string {
+				return "-t " + strings.Join(names, " -t ")
+			}
+
+			log.Println("Next steps:")
+			if !initApply {
+				log.Printf("\tgcsb schema apply%s", flags)
+			}
+			log.Printf("\tgcsb load%s %s", flags, tables(p.Load))
+			log.Printf("\tgcsb run%s %s", flags, tables(p.Run))
+		},
+	}
+)
+
+// listPresets prints the name and description of every preset
+func listPresets() {
+	str := &strings.Builder{}
+	t := tablewriter.NewWriter(str)
+	t.SetHeader([]string{"Preset", "Load", "Run", "Description"})
+	for _, p := range preset.List() {
+		t.Append([]string{p.Name, strings.Join(p.Load, ", "), strings.Join(p.Run, ", "), p.Description})
+	}
+	t.Render()
+
+	fmt.Print(str.String())
+	fmt.Println("Initialize one with 'gcsb init --preset NAME'")
+}
+
+// presetHeader returns the connection settings written before the generator config of a preset
+func presetHeader(p *preset.Preset, ddlFile string) string {
+	value := func(key, placeholder string) string {
+		if v := viper.GetString(key); v != "" {
+			return v
+		}
+
+		return placeholder
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Written by 'gcsb init --preset %s': %s\n\n", p.Name, p.Description)
+	fmt.Fprintf(&b, "project: %s\n", value("project", "YOUR_PROJECT_ID"))
+	fmt.Fprintf(&b, "instance: %s\n", value("instance", "YOUR_SPANNER_INSTANCE_ID"))
+	fmt.Fprintf(&b, "database: %s\n\n", value("database", "YOUR_SPANNER_DATABASE"))
+	fmt.Fprintf(&b, "# Reports are labelled with the preset. Settings of this file take precedence over it.\n")
+	fmt.Fprintf(&b, "workload: %s\n\n", p.Name)
+	fmt.Fprintf(&b, "# The schema is read from this file instead of the database. 'gcsb schema apply' applies it.\n")
+	fmt.Fprintf(&b, "schema_file: %s\n", ddlFile)
+	fmt.Fprintf(&b, "create:\n  ddl_file: %s\n\n", ddlFile)
+
+	return b.String()
+}
+
+// writeInitFile writes a file of gcsb init, refusing to overwrite existing files without --force
+func writeInitFile(path string, data []byte) {
+	if _, err := os.Stat(path); err == nil && !initForce {
+		log.Fatalf("%s exists (use --force to overwrite it)", path)
+	}
+
+	err := ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		log.Fatalf("unable to write %s: %s", path, err.Error())
+	}
+
+	log.Printf("Wrote %s", path)
+}
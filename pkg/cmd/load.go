@@ -17,11 +17,12 @@ package cmd
 import (
 	"log"
 	"os"
+	"time"
 
-	"github.com/rcrowley/go-metrics"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
 	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -33,6 +34,26 @@ func init() {
 	flags.StringSliceVarP(&loadTables, "table", "t", []string{}, "Table name to load")
 	flags.IntP("operations", "o", 1000, "Number of records to load")
 	flags.Int("threads", 10, "Number of threads")
+	flags.Int64("seed", 0, "Seed for all random generators (0 picks a random seed)")
+	flags.Int("max-errors", 0, "Abort after this many failed operations (0 disables)")
+	flags.Float64("max-error-rate", 0, "Abort when this fraction of operations fail (0 disables)")
+	flags.String("metrics-addr", "", "Serve prometheus metrics on this address (e.g. :9090) while running")
+	flags.String("otel-endpoint", "", "Export metrics and traces to this OTLP collector (host:port)")
+	flags.String("otel-protocol", "grpc", "OTLP protocol (grpc, http)")
+	flags.Bool("otel-tracing", false, "Export a span per sampled operation")
+	flags.Float64("otel-sample-rate", 0.01, "Fraction of operations traced when --otel-tracing is set")
+	flags.String("report-format", "table", "Report format (table, json, csv)")
+	flags.String("report-file", "", "Write the report to this file (json and csv reports go to stdout by default)")
+	flags.Duration("report-interval", 0, "Log throughput, latency and errors of every interval (e.g. 10s, 0 disables)")
+	flags.String("report-interval-file", "", "Append interval statistics to this file")
+	flags.String("report-interval-format", "jsonl", "Interval file format (jsonl, csv)")
+	flags.StringSlice("percentiles", []string{"50", "75", "90", "95", "99", "99.9", "99.99"}, "Latency percentiles to report")
+	flags.Duration("timeout", 0, "Deadline for each operation including retries (0 disables)")
+	flags.Int("max-attempts", 1, "Attempts per operation for retryable errors (1 disables retries)")
+	flags.String("backend", "spanner", "Execute against Cloud Spanner or an in memory fake seeded from --ddl-file (spanner, memory)")
+	flags.Bool("create-database", false, "Create the instance and database if they do not exist")
+	flags.String("ddl-file", "", "Apply the statements in this file to a newly created database")
+	flags.String("workload", "", "Apply a preset such as ycsb-a on top of the configuration (see 'gcsb init')")
 	flags.BoolVar(&loadDry, "dry", false, "Dry run. Print config and exit.")
 
 	rootCmd.AddCommand(loadCmd)
@@ -52,8 +73,34 @@ var (
 			flags := cmd.Flags()
 			viper.BindPFlag("operations.total", flags.Lookup("operations"))
 			viper.BindPFlag("threads", flags.Lookup("threads"))
+			viper.BindPFlag("seed", flags.Lookup("seed"))
+			viper.BindPFlag("operations.max_errors", flags.Lookup("max-errors"))
+			viper.BindPFlag("operations.max_error_rate", flags.Lookup("max-error-rate"))
+			viper.BindPFlag("operations.timeout", flags.Lookup("timeout"))
+			viper.BindPFlag("report.format", flags.Lookup("report-format"))
+			viper.BindPFlag("report.file", flags.Lookup("report-file"))
+			viper.BindPFlag("report.percentiles", flags.Lookup("percentiles"))
+			viper.BindPFlag("report.interval", flags.Lookup("report-interval"))
+			viper.BindPFlag("report.interval_file", flags.Lookup("report-interval-file"))
+			viper.BindPFlag("report.interval_format", flags.Lookup("report-interval-format"))
+			viper.BindPFlag("metrics_addr", flags.Lookup("metrics-addr"))
+			viper.BindPFlag("otel.endpoint", flags.Lookup("otel-endpoint"))
+			viper.BindPFlag("otel.protocol", flags.Lookup("otel-protocol"))
+			viper.BindPFlag("otel.tracing.enabled", flags.Lookup("otel-tracing"))
+			viper.BindPFlag("otel.tracing.sample_rate", flags.Lookup("otel-sample-rate"))
+			viper.BindPFlag("operations.retry.read.max_attempts", flags.Lookup("max-attempts"))
+			viper.BindPFlag("operations.retry.write.max_attempts", flags.Lookup("max-attempts"))
+			viper.BindPFlag("backend", flags.Lookup("backend"))
+			viper.BindPFlag("create.database", flags.Lookup("create-database"))
+			viper.BindPFlag("create.ddl_file", flags.Lookup("ddl-file"))
+			viper.BindPFlag("workload", flags.Lookup("workload"))
 		},
 		Run: func(cmd *cobra.Command, args []string) {
+			// Presets know the tables they load
+			if p := applyWorkload(); p != nil && len(loadTables) <= 0 {
+				loadTables = p.Load
+			}
+
 			if len(loadTables) <= 0 {
 				log.Fatal("missing table name (-t)")
 			}
@@ -94,11 +141,14 @@ var (
 			log.Println("Listening for OS signals")
 			graceful(cancel)
 
+			// Create the instance and database when asked to
+			createDatabase(ctx, cfg)
+
 			// Measure how long schema inference takes to run
 			schemaTimer := metrics.GetOrRegisterTimer("schema.inference", registry)
 
 			// Infer the table schema from the database
-			log.Println("Infering schema from database")
+			log.Printf("Infering schema from %s", schemaSource(cfg))
 			var s schema.Schema
 			schemaTimer.Time(func() {
 				s, err = schema.LoadSchema(ctx, cfg)
@@ -107,6 +157,9 @@ var (
 				log.Fatalf("unable to infer schema: %s", err.Error())
 			}
 
+			// Serve live metrics while the workload runs
+			obs, stopObserver := startObserver(ctx, cfg, nil)
+
 			// Get a constructor for a workload
 			constructor, err := workload.GetWorkloadConstructor("NOTYETSUPPORTED")
 			if err != nil {
@@ -120,6 +173,7 @@ var (
 				Config:         cfg,
 				Schema:         s,
 				MetricRegistry: registry,
+				Observer:       obs,
 			})
 			if err != nil {
 				log.Fatalf("unable to create workload: %s", err.Error())
@@ -130,14 +184,23 @@ var (
 
 			// Execute the load phase
 			log.Println("Executing load phase")
+			start := time.Now()
 			runTimer.Time(func() {
 				err = wl.Load(loadTables)
 			})
+			// Summarize what completed even if the phase was aborted
+			rep := newReport("load", start, time.Now(), cfg, wl, registry)
+			summarizeReport(rep)
+
+			// Flush telemetry before we may exit with an error
+			stopObserver()
+			writeReport(cfg, rep)
 			if err != nil {
 				log.Fatalf("unable to execute load operation: %s", err.Error())
 			}
 
-			summarizeMetricsAsciiTable(registry)
+			// Fail the process if the results do not meet the configured assertions
+			checkAssertions(rep)
 		},
 	}
 )
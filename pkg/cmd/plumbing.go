@@ -20,10 +20,11 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/seed"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/davecgh/go-spew/spew"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -161,7 +162,7 @@ var (
 				log.Fatalf("error sampling table: %s", err.Error())
 			}
 
-			gen, err := generator.GetReadGeneratorMap(samples, table.PrimaryKeyNames())
+			gen, err := generator.GetReadGeneratorMap(seed.Source(cfg.Seed, "table", table.Name(), "reads"), samples, table.PrimaryKeyNames())
 			if err != nil {
 				log.Fatalf("error getting read generator: %s", err.Error())
 			}
@@ -0,0 +1,175 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	flags := reportCompareCmd.Flags()
+	flags.Float64Var(&compareLatency, "max-latency-increase", 10, "Maximum increase of a latency percentile in percent")
+	flags.Float64Var(&compareThroughput, "max-throughput-decrease", 10, "Maximum decrease of a throughput in percent")
+	flags.Float64Var(&compareErrorRate, "max-error-rate-increase", 0.1, "Maximum increase of the error rate in percentage points")
+	flags.StringVar(&compareFormat, "format", config.ReportFormatTable, "Output format (table, json)")
+	flags.BoolVar(&compareForce, "force", false, "Compare runs that are configured or planned differently")
+
+	flags = reportMergeCmd.Flags()
+	flags.StringVar(&mergeFormat, "report-format", config.ReportFormatJSON, "Format of the merged report (table, json, csv)")
+	flags.StringVar(&mergeFile, "report-file", "", "Write the merged report to this file (default is stdout)")
+	flags.BoolVar(&mergeForce, "force", false, "Merge reports of runs that are configured or planned differently")
+
+	reportCmd.AddCommand(reportCompareCmd)
+	reportCmd.AddCommand(reportMergeCmd)
+	rootCmd.AddCommand(reportCmd)
+}
+
+var (
+	// Flags
+	compareLatency    float64
+	compareThroughput float64
+	compareErrorRate  float64
+	compareFormat     string
+	compareForce      bool
+	mergeFormat       string
+	mergeFile         string
+	mergeForce        bool
+
+	// Commands
+	reportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Work with JSON reports written by --report-format json",
+		Long:  ``,
+	}
+
+	reportCompareCmd = &cobra.Command{
+		Use:   "compare BASELINE CANDIDATE...",
+		Short: "Compare runs to a baseline and fail on regressions",
+		Long: `Compares the throughput, latency percentiles and error rate of every candidate report to the baseline report.
+Exits with a non-zero code if any change exceeds its threshold. Runs that are configured or planned differently are
+not compared unless --force is set.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if compareFormat != config.ReportFormatTable && compareFormat != config.ReportFormatJSON {
+				log.Fatalf("unknown format '%s'", compareFormat)
+			}
+
+			th := report.Thresholds{
+				Latency:    compareLatency / 100,
+				Throughput: compareThroughput / 100,
+				ErrorRate:  compareErrorRate / 100,
+			}
+
+			baseline := readReport(args[0])
+
+			regressions := make([]string, 0)
+			for _, path := range args[1:] {
+				c, err := report.Compare(baseline, readReport(path), th)
+				if err != nil {
+					log.Fatalf("unable to compare %s to %s: %s", path, args[0], err.Error())
+				}
+				c.Baseline, c.Candidate = args[0], path
+
+				if len(c.Differences) > 0 && !compareForce {
+					log.Fatalf("refusing to compare %s to %s, they are configured differently (use --force to compare anyway):\n\t%s",
+						path, args[0], strings.Join(c.Differences, "\n\t"))
+				}
+
+				if compareFormat == config.ReportFormatJSON {
+					err = c.WriteJSON(os.Stdout)
+				} else {
+					str := &strings.Builder{}
+					err = c.WriteTable(str)
+					fmt.Print(str.String())
+				}
+				if err != nil {
+					log.Fatalf("unable to write comparison: %s", err.Error())
+				}
+
+				for _, d := range c.Regressions() {
+					regressions = append(regressions, fmt.Sprintf("%s: %s %s", path, d.Metric, d.Stat))
+				}
+			}
+
+			if len(regressions) > 0 {
+				log.Fatalf("%d regressions:\n\t%s", len(regressions), strings.Join(regressions, "\n\t"))
+			}
+
+			log.Printf("No regressions in %d runs", len(args)-1)
+		},
+	}
+
+	reportMergeCmd = &cobra.Command{
+		Use:   "merge REPORT...",
+		Short: "Merge the reports of several processes into one",
+		Long: `Merges JSON reports of the same phase, e.g. written by independently running pods, into one report. Counts and
+errors are summed, latency histograms are merged exactly and throughput is computed over the union of the time windows.
+Reports of runs that are configured or planned differently are refused unless --force is given.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			switch mergeFormat {
+			case config.ReportFormatTable, config.ReportFormatJSON, config.ReportFormatCSV:
+			default:
+				log.Fatalf("unknown report format '%s'", mergeFormat)
+			}
+
+			reports := make([]*report.Report, 0, len(args))
+			for _, path := range args {
+				reports = append(reports, readReport(path))
+			}
+
+			if diffs := report.MergeDifferences(reports...); len(diffs) > 0 {
+				if !mergeForce {
+					log.Fatalf("refusing to merge reports, they are configured differently (use --force to merge anyway):\n\t%s",
+						strings.Join(diffs, "\n\t"))
+				}
+
+				log.Printf("Merging reports that are configured differently:\n\t%s", strings.Join(diffs, "\n\t"))
+			}
+
+			log.Printf("Merging %d reports", len(reports))
+			rep, err := report.Merge(reports...)
+			if err != nil {
+				log.Fatalf("unable to merge reports: %s", err.Error())
+			}
+
+			summarizeReport(rep)
+			writeReport(&config.Config{Report: config.Report{Format: mergeFormat, File: mergeFile}}, rep)
+		},
+	}
+)
+
+// readReport reads a JSON report from a file
+func readReport(path string) *report.Report {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("unable to open report: %s", err.Error())
+	}
+	defer f.Close()
+
+	rep, err := report.Read(f)
+	if err != nil {
+		log.Fatalf("unable to read report %s: %s", path, err.Error())
+	}
+
+	return rep
+}
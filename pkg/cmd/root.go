@@ -62,6 +62,12 @@ func init() {
 
 	flags.StringVarP(&database, "database", "d", "", "Spanner Database")
 	viper.BindPFlag("database", flags.Lookup("database"))
+
+	flags.String("emulator-host", "", "Connect to the Spanner emulator on this address (default is $SPANNER_EMULATOR_HOST)")
+	viper.BindPFlag("emulator_host", flags.Lookup("emulator-host"))
+
+	flags.String("schema-file", "", "Read the schema from this DDL file instead of querying INFORMATION_SCHEMA")
+	viper.BindPFlag("schema_file", flags.Lookup("schema-file"))
 }
 
 // initConfig reads in config file and ENV variables if set.
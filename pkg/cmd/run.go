@@ -21,6 +21,7 @@ import (
 
 	"github.com/rcrowley/go-metrics"
 
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
 	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
@@ -34,12 +35,41 @@ func init() {
 
 	flags.IntP("operations", "o", 1000, "Number of operations to perform")
 	flags.Int("threads", 10, "Number of threads")
+	flags.Int64("seed", 0, "Seed for all random generators (0 picks a random seed)")
+	flags.Int("max-errors", 0, "Abort after this many failed operations (0 disables)")
+	flags.Float64("max-error-rate", 0, "Abort when this fraction of operations fail (0 disables)")
+	flags.String("metrics-addr", "", "Serve prometheus metrics on this address (e.g. :9090) while running")
+	flags.String("otel-endpoint", "", "Export metrics and traces to this OTLP collector (host:port)")
+	flags.String("otel-protocol", "grpc", "OTLP protocol (grpc, http)")
+	flags.Bool("otel-tracing", false, "Export a span per sampled operation")
+	flags.Float64("otel-sample-rate", 0.01, "Fraction of operations traced when --otel-tracing is set")
+	flags.String("report-format", "table", "Report format (table, json, csv)")
+	flags.String("report-file", "", "Write the report to this file (json and csv reports go to stdout by default)")
+	flags.Duration("report-interval", 0, "Log throughput, latency and errors of every interval (e.g. 10s, 0 disables)")
+	flags.String("report-interval-file", "", "Append interval statistics to this file")
+	flags.String("report-interval-format", "jsonl", "Interval file format (jsonl, csv)")
+	flags.StringSlice("percentiles", []string{"50", "75", "90", "95", "99", "99.9", "99.99"}, "Latency percentiles to report")
+	flags.Duration("timeout", 0, "Deadline for each operation including retries (0 disables)")
+	flags.Int("max-attempts", 1, "Attempts per operation for retryable errors (1 disables retries)")
 	flags.Int("num-conns", 10, "Number of spanner connections")
 	flags.IntP("reads", "r", 50, "Read weight")
 	flags.IntP("writes", "w", 50, "Write weight")
+	flags.Int("updates", 0, "Update weight")
+	flags.Int("scans", 0, "Scan weight")
+	flags.Int("read-modify-writes", 0, "Read-modify-write weight")
+	flags.Int("max-scan-length", 100, "Scans read between 1 and this many rows")
+	flags.String("distribution", "uniform", "Distribution of the keys chosen from the table sample (uniform, zipfian)")
+	flags.String("workload", "", "Apply a preset such as ycsb-a on top of the configuration (see 'gcsb init')")
 	flags.Float64P("sample-size", "s", 10, "Percentage of table to sample")
+	flags.String("sample-strategy", "bernoulli", "Table sampling strategy (bernoulli, reservoir, partitioned, stratified)")
+	flags.Int("sample-max-keys", 1000000, "Maximum number of sampled keys held in memory")
+	flags.String("key-file", "", "Load read keys from a key file written by 'gcsb sample export'")
+	flags.Float64("latest", 0, "Fraction of reads that target keys inserted during this run")
 	flags.Bool("read-stale", false, "Perform stale reads")
 	flags.Duration("staleness", time.Duration(15*time.Second), "Exact staleness timestamp bound")
+	flags.String("backend", "spanner", "Execute against Cloud Spanner or an in memory fake seeded from --ddl-file (spanner, memory)")
+	flags.Bool("create-database", false, "Create the instance and database if they do not exist")
+	flags.String("ddl-file", "", "Apply the statements in this file to a newly created database")
 	flags.BoolVar(&runDry, "dry", false, "Dry run. Print config and exit.")
 
 	rootCmd.AddCommand(runCmd)
@@ -59,15 +89,50 @@ var (
 			flags := cmd.Flags()
 			viper.BindPFlag("operations.total", flags.Lookup("operations"))
 			viper.BindPFlag("threads", flags.Lookup("threads"))
+			viper.BindPFlag("seed", flags.Lookup("seed"))
+			viper.BindPFlag("operations.max_errors", flags.Lookup("max-errors"))
+			viper.BindPFlag("operations.max_error_rate", flags.Lookup("max-error-rate"))
+			viper.BindPFlag("operations.timeout", flags.Lookup("timeout"))
+			viper.BindPFlag("report.format", flags.Lookup("report-format"))
+			viper.BindPFlag("report.file", flags.Lookup("report-file"))
+			viper.BindPFlag("report.percentiles", flags.Lookup("percentiles"))
+			viper.BindPFlag("report.interval", flags.Lookup("report-interval"))
+			viper.BindPFlag("report.interval_file", flags.Lookup("report-interval-file"))
+			viper.BindPFlag("report.interval_format", flags.Lookup("report-interval-format"))
+			viper.BindPFlag("metrics_addr", flags.Lookup("metrics-addr"))
+			viper.BindPFlag("otel.endpoint", flags.Lookup("otel-endpoint"))
+			viper.BindPFlag("otel.protocol", flags.Lookup("otel-protocol"))
+			viper.BindPFlag("otel.tracing.enabled", flags.Lookup("otel-tracing"))
+			viper.BindPFlag("otel.tracing.sample_rate", flags.Lookup("otel-sample-rate"))
+			viper.BindPFlag("operations.retry.read.max_attempts", flags.Lookup("max-attempts"))
+			viper.BindPFlag("operations.retry.write.max_attempts", flags.Lookup("max-attempts"))
+			viper.BindPFlag("backend", flags.Lookup("backend"))
+			viper.BindPFlag("create.database", flags.Lookup("create-database"))
+			viper.BindPFlag("create.ddl_file", flags.Lookup("ddl-file"))
 			viper.BindPFlag("num_conns", flags.Lookup("num-conns"))
 			viper.BindPFlag("operations.read", flags.Lookup("reads"))
 			viper.BindPFlag("operations.write", flags.Lookup("writes"))
+			viper.BindPFlag("operations.update", flags.Lookup("updates"))
+			viper.BindPFlag("operations.scan", flags.Lookup("scans"))
+			viper.BindPFlag("operations.read_modify_write", flags.Lookup("read-modify-writes"))
+			viper.BindPFlag("operations.max_scan_length", flags.Lookup("max-scan-length"))
+			viper.BindPFlag("operations.distribution", flags.Lookup("distribution"))
+			viper.BindPFlag("workload", flags.Lookup("workload"))
 			viper.BindPFlag("operations.sample_size", flags.Lookup("sample-size"))
+			viper.BindPFlag("operations.sampling.strategy", flags.Lookup("sample-strategy"))
+			viper.BindPFlag("operations.sampling.max_keys", flags.Lookup("sample-max-keys"))
+			viper.BindPFlag("operations.key_file", flags.Lookup("key-file"))
+			viper.BindPFlag("operations.latest.fraction", flags.Lookup("latest"))
 			viper.BindPFlag("operations.read_stale", flags.Lookup("read-stale"))
 			viper.BindPFlag("operations.staleness", flags.Lookup("staleness"))
 
 		},
 		Run: func(cmd *cobra.Command, args []string) {
+			// Presets know the table they run against
+			if p := applyWorkload(); p != nil && runTable == "" {
+				runTable = p.Run[0]
+			}
+
 			if runTable == "" {
 				log.Fatal("missing table name (-t)")
 			}
@@ -104,11 +169,14 @@ var (
 			log.Println("Listening for OS signals")
 			graceful(cancel)
 
+			// Create the instance and database when asked to
+			createDatabase(ctx, cfg)
+
 			// Measure how long schema inference takes to run
 			schemaTimer := metrics.GetOrRegisterTimer("schema.inference", registry)
 
 			// Infer the table schema from the database
-			log.Println("Infering schema from database")
+			log.Printf("Infering schema from %s", schemaSource(cfg))
 			var s schema.Schema
 			schemaTimer.Time(func() {
 				s, err = schema.LoadSchema(ctx, cfg)
@@ -117,6 +185,14 @@ var (
 				log.Fatalf("unable to infer schema: %s", err.Error())
 			}
 
+			// The in memory backend starts empty, give the reads rows to find
+			if cfg.Backend == backend.Memory {
+				seedMemory(ctx, cfg, s, runTable)
+			}
+
+			// Serve live metrics while the workload runs
+			obs, stopObserver := startObserver(ctx, cfg, nil)
+
 			// Get a constructor for a workload
 			constructor, err := workload.GetWorkloadConstructor("NOTYETSUPPORTED")
 			if err != nil {
@@ -130,6 +206,7 @@ var (
 				Config:         cfg,
 				Schema:         s,
 				MetricRegistry: registry,
+				Observer:       obs,
 			})
 			if err != nil {
 				log.Fatalf("unable to create workload: %s", err.Error())
@@ -140,14 +217,23 @@ var (
 
 			// Execute the run phase
 			log.Println("Executing run phase")
+			start := time.Now()
 			runTimer.Time(func() {
 				err = wl.Run(runTable)
 			})
+			// Summarize what completed even if the phase was aborted
+			rep := newReport("run", start, time.Now(), cfg, wl, registry)
+			summarizeReport(rep)
+
+			// Flush telemetry before we may exit with an error
+			stopObserver()
+			writeReport(cfg, rep)
 			if err != nil {
 				log.Fatalf("unable to execute run operation: %s", err.Error())
 			}
 
-			summarizeMetricsAsciiTable(registry)
+			// Fail the process if the results do not meet the configured assertions
+			checkAssertions(rep)
 		},
 	}
 )
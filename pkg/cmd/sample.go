@@ -0,0 +1,127 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	flags := sampleExportCmd.Flags()
+	flags.StringVarP(&sampleTable, "table", "t", "", "Table name to sample")
+	flags.StringVarP(&sampleOutput, "output", "o", "", "Key file to write")
+	flags.Float64P("sample-size", "s", 10, "Percentage of table to sample")
+	flags.String("sample-strategy", "bernoulli", "Table sampling strategy (bernoulli, reservoir, partitioned, stratified)")
+	flags.Int("sample-max-keys", 1000000, "Maximum number of sampled keys held in memory")
+
+	sampleCmd.AddCommand(sampleExportCmd)
+	rootCmd.AddCommand(sampleCmd)
+}
+
+var (
+	// Flags
+	sampleTable  string
+	sampleOutput string
+
+	// Commands
+	sampleCmd = &cobra.Command{
+		Use:   "sample",
+		Short: "Work with sampled primary keys",
+		Long:  ``,
+	}
+
+	sampleExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Sample a table and write its primary keys to a key file",
+		Long:  `Sample a table and write its primary keys to a key file. Set operations.key_file to the file to reuse the keys in later runs.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			flags := cmd.Flags()
+			viper.BindPFlag("operations.sample_size", flags.Lookup("sample-size"))
+			viper.BindPFlag("operations.sampling.strategy", flags.Lookup("sample-strategy"))
+			viper.BindPFlag("operations.sampling.max_keys", flags.Lookup("sample-max-keys"))
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if sampleTable == "" {
+				log.Fatal("missing table name (-t)")
+			}
+
+			if sampleOutput == "" {
+				log.Fatal("missing output file (-o)")
+			}
+
+			// Load configuration
+			log.Println("Loading configuration")
+			cfg, err := config.NewConfig(viper.GetViper())
+			if err != nil {
+				log.Fatalf("unable to parse configuration: %s", err.Error())
+			}
+
+			// Validate the configuration
+			log.Println("Validating configuration")
+			err = cfg.Validate()
+			if err != nil {
+				log.Fatalf("unable to validate configuration %s", err.Error())
+			}
+
+			// Generate a context with cancelation
+			ctx, cancel := cfg.Context()
+			graceful(cancel)
+
+			// Infer the table schema from the database
+			log.Printf("Infering schema from %s", schemaSource(cfg))
+			s, err := schema.LoadSchema(ctx, cfg)
+			if err != nil {
+				log.Fatalf("unable to infer schema: %s", err.Error())
+			}
+
+			t := s.GetTable(sampleTable)
+			if t == nil {
+				log.Fatalf("could not find table '%s'", sampleTable)
+			}
+
+			client, err := cfg.Client(ctx)
+			if err != nil {
+				log.Fatalf("unable to create spanner client: %s", err.Error())
+			}
+			defer client.Close()
+
+			log.Printf("Sampling table '%s'", sampleTable)
+			samples, err := generator.SampleTable(cfg, ctx, client, t)
+			if err != nil {
+				log.Fatalf("unable to sample table: %s", err.Error())
+			}
+
+			f, err := os.Create(sampleOutput)
+			if err != nil {
+				log.Fatalf("unable to create key file: %s", err.Error())
+			}
+			defer f.Close()
+
+			n, err := generator.WriteKeyFile(f, t, samples)
+			if err != nil {
+				log.Fatalf("unable to write key file: %s", err.Error())
+			}
+
+			log.Printf("Wrote %d keys to '%s'", n, sampleOutput)
+		},
+	}
+)
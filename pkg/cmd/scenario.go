@@ -0,0 +1,155 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/cloudspannerecosystem/gcsb/pkg/scenario"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	flags := scenarioCmd.Flags()
+	flags.StringVarP(&scenarioFile, "file", "f", "", "Scenario file listing the phases to execute")
+	flags.StringVar(&scenarioReportFormat, "report-format", "table", "Report format (table, json, csv)")
+	flags.StringVar(&scenarioReportFile, "report-file", "", "Write the report to this file (json and csv reports go to stdout by default)")
+	flags.BoolVar(&scenarioDry, "dry", false, "Dry run. Print the phases and their configuration and exit.")
+
+	rootCmd.AddCommand(scenarioCmd)
+}
+
+var (
+	// Flags
+	scenarioFile         string
+	scenarioReportFormat string
+	scenarioReportFile   string
+	scenarioDry          bool
+
+	// Command
+	scenarioCmd = &cobra.Command{
+		Use:   "scenario",
+		Short: "Execute the phases of a scenario file",
+		Long: `Executes the phases of a scenario file in order against one database: loading tables, running operation
+mixes, verifying row counts and deleting data. Every phase overrides the configuration file with its own settings
+and reports on its own. A failed phase skips the phases after it, except those marked always.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if scenarioFile == "" {
+				log.Fatal("missing scenario file (-f)")
+			}
+
+			switch scenarioReportFormat {
+			case config.ReportFormatTable, config.ReportFormatJSON, config.ReportFormatCSV:
+			default:
+				log.Fatalf("unknown report format '%s' (table, json, csv)", scenarioReportFormat)
+			}
+
+			s, err := scenario.Read(scenarioFile)
+			if err != nil {
+				log.Fatalf("unable to read scenario: %s", err.Error())
+			}
+
+			// Generate a context with cancelation
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Listen for os signals and cancel the context if we receive them
+			graceful(cancel)
+
+			runner := &scenario.Runner{
+				Context: ctx,
+				Base:    viper.GetViper(),
+				Observe: func(cfg *config.Config) (observer.Observer, func()) {
+					return startObserver(ctx, cfg, nil)
+				},
+			}
+
+			// Resolve the configuration of every phase before executing any of them
+			log.Println("Validating scenario")
+			steps, err := runner.Resolve(s)
+			if err != nil {
+				log.Fatalf("unable to validate scenario: %s", err.Error())
+			}
+
+			for i, st := range steps {
+				log.Printf("Phase %d: %s (%s %s)", i+1, st.Phase.Name, st.Phase.Type, strings.Join(st.Phase.Tables, ", "))
+				if scenarioDry {
+					logConfig(st.Config)
+				}
+			}
+			if scenarioDry {
+				log.Println("Exiting (--dry)")
+				os.Exit(0)
+			}
+
+			rep, err := runner.Run(s)
+			if err != nil {
+				log.Fatalf("unable to execute scenario: %s", err.Error())
+			}
+
+			tableString := &strings.Builder{}
+			rep.WriteTable(tableString)
+			logTable(tableString)
+
+			writeScenarioReport(rep)
+
+			failed := make([]string, 0)
+			for _, p := range rep.Phases {
+				if p.Failed() {
+					failed = append(failed, fmt.Sprintf("%s (%s)", p.Name, strings.ToLower(p.Result())))
+				}
+			}
+			if len(failed) > 0 {
+				log.Fatalf("%d of %d phases did not pass:\n\t%s", len(failed), len(rep.Phases), strings.Join(failed, "\n\t"))
+			}
+
+			log.Printf("All %d phases passed", len(rep.Phases))
+		},
+	}
+)
+
+// writeScenarioReport writes the report of a scenario like writeReport writes the report of a phase
+func writeScenarioReport(rep *scenario.Report) {
+	if scenarioReportFile == "" && scenarioReportFormat == config.ReportFormatTable {
+		return
+	}
+
+	w := os.Stdout
+	if scenarioReportFile != "" {
+		f, err := os.Create(scenarioReportFile)
+		if err != nil {
+			log.Fatalf("unable to create report file: %s", err.Error())
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	err := rep.Write(w, scenarioReportFormat)
+	if err != nil {
+		log.Fatalf("unable to write report: %s", err.Error())
+	}
+
+	if scenarioReportFile != "" {
+		log.Printf("Wrote %s report to %s", scenarioReportFormat, scenarioReportFile)
+	}
+}
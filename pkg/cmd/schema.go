@@ -0,0 +1,191 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/admin"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	flags := schemaApplyCmd.Flags()
+	flags.String("ddl-file", "", "Apply the statements in this file (default is the table definitions of the config file)")
+	flags.Bool("create-database", false, "Create the instance and database with the statements if they do not exist")
+	flags.BoolVar(&schemaDry, "dry", false, "Dry run. Print the statements and exit.")
+
+	flags = schemaDropCmd.Flags()
+	flags.StringSliceVarP(&schemaDropTables, "table", "t", []string{}, "Table or view to drop, together with its indexes")
+	flags.BoolVar(&schemaDry, "dry", false, "Dry run. Print the statements and exit.")
+
+	schemaCmd.AddCommand(schemaApplyCmd)
+	schemaCmd.AddCommand(schemaDropCmd)
+	rootCmd.AddCommand(schemaCmd)
+}
+
+var (
+	// Flags
+	schemaDry        bool
+	schemaDropTables []string
+
+	// Commands
+	schemaCmd = &cobra.Command{
+		Use:   "schema",
+		Short: "Create and drop the tables of a benchmark",
+		Long:  ``,
+	}
+
+	schemaApplyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Apply DDL to the database",
+		Long: `Applies the statements of --ddl-file to the database and waits for the schema change to complete.
+Without --ddl-file, tables with a primary_key and typed columns in the config file are created.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			flags := cmd.Flags()
+			viper.BindPFlag("create.database", flags.Lookup("create-database"))
+			viper.BindPFlag("create.ddl_file", flags.Lookup("ddl-file"))
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := schemaConfig()
+
+			var statements []string
+			if cfg.Create.DDLFile != "" {
+				var err error
+				statements, err = admin.ReadDDLFile(cfg.Create.DDLFile)
+				if err != nil {
+					log.Fatalf("unable to read ddl: %s", err.Error())
+				}
+			} else {
+				statements = configStatements()
+			}
+
+			if len(statements) <= 0 {
+				log.Fatal("no statements to apply")
+			}
+
+			logStatements(statements)
+			if schemaDry {
+				log.Println("Exiting (--dry)")
+				os.Exit(0)
+			}
+
+			ctx, cancel := cfg.Context()
+			graceful(cancel)
+
+			err := admin.ApplyDDL(ctx, cfg, statements)
+			if err != nil {
+				log.Fatalf("unable to apply ddl: %s", err.Error())
+			}
+		},
+	}
+
+	schemaDropCmd = &cobra.Command{
+		Use:   "drop",
+		Short: "Drop tables and their indexes",
+		Long: `Drops tables and views along with their indexes. Interleaved children must be dropped together with
+their parents, they are dropped first.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(schemaDropTables) <= 0 {
+				log.Fatal("missing table name (-t)")
+			}
+
+			cfg := schemaConfig()
+
+			ctx, cancel := cfg.Context()
+			graceful(cancel)
+
+			log.Printf("Infering schema from %s", schemaSource(cfg))
+			s, err := schema.LoadSchema(ctx, cfg)
+			if err != nil {
+				log.Fatalf("unable to infer schema: %s", err.Error())
+			}
+
+			statements, err := admin.DropStatements(s, schemaDropTables)
+			if err != nil {
+				log.Fatalf("unable to drop tables: %s", err.Error())
+			}
+
+			logStatements(statements)
+			if schemaDry {
+				log.Println("Exiting (--dry)")
+				os.Exit(0)
+			}
+
+			// The statements must not be applied to a newly created database
+			cfg.Create.Database = false
+
+			err = admin.ApplyDDL(ctx, cfg, statements)
+			if err != nil {
+				log.Fatalf("unable to drop tables: %s", err.Error())
+			}
+		},
+	}
+)
+
+// schemaConfig loads and validates the configuration of the schema commands
+func schemaConfig() *config.Config {
+	cfg, err := config.NewConfig(viper.GetViper())
+	if err != nil {
+		log.Fatalf("unable to parse configuration: %s", err.Error())
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		log.Fatalf("unable to validate configuration %s", err.Error())
+	}
+
+	if cfg.Backend != backend.Spanner {
+		log.Fatalf("the schema commands need the spanner backend")
+	}
+
+	return cfg
+}
+
+// configStatements returns the CREATE TABLE statements of the tables defined in the config file
+func configStatements() []string {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		log.Fatal("missing ddl file (--ddl-file)")
+	}
+
+	gc, err := config.NewGCSBConfigFromPath(path)
+	if err != nil {
+		log.Fatalf("unable to read table definitions from %s: %s", path, err.Error())
+	}
+
+	// Tables without a primary key only configure generators
+	defined := gc.Tables[:0]
+	for _, t := range gc.Tables {
+		if t.PrimaryKey != "" && len(t.Columns) > 0 {
+			defined = append(defined, t)
+		}
+	}
+	gc.Tables = defined
+
+	return gc.GetCreateStatements()
+}
+
+func logStatements(statements []string) {
+	log.Printf("%d statements:", len(statements))
+	for _, stmt := range statements {
+		log.Printf("\t%s", stmt)
+	}
+}
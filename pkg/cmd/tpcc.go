@@ -0,0 +1,293 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/admin"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/cloudspannerecosystem/gcsb/pkg/tpcc"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	flags := tpccSchemaCmd.Flags()
+	flags.Bool("create-database", false, "Create the instance and database with the statements if they do not exist")
+	flags.BoolVar(&tpccDry, "dry", false, "Dry run. Print the statements and exit.")
+
+	tpccPhaseFlags(tpccLoadCmd)
+	tpccPhaseFlags(tpccRunCmd)
+
+	flags = tpccRunCmd.Flags()
+	flags.IntP("operations", "o", 1000, "Number of transactions to run when --duration is not set")
+	flags.Duration("duration", 0, "Run the transaction mix for this long (e.g. 10m)")
+	flags.Bool("keying", false, "Wait the keying and think times of the specification around every transaction")
+	flags.Duration("report-interval", 0, "Log throughput, latency and errors of every interval (e.g. 10s, 0 disables)")
+
+	tpccCmd.AddCommand(tpccSchemaCmd)
+	tpccCmd.AddCommand(tpccLoadCmd)
+	tpccCmd.AddCommand(tpccRunCmd)
+	rootCmd.AddCommand(tpccCmd)
+}
+
+// tpccPhaseFlags adds the flags shared by tpcc load and run to cmd
+func tpccPhaseFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.Int("warehouses", 1, "Number of warehouses")
+	flags.Int("items", config.TPCCItems, "Items in the catalog. Lower values scale the database down for testing")
+	flags.Int("customers", config.TPCCCustomers, "Customers per district. Lower values scale the database down for testing")
+	flags.Int("threads", 10, "Number of threads. Every thread of a run is a terminal")
+	flags.Int64("seed", 0, "Seed for all random generators (0 picks a random seed)")
+	flags.Int("max-errors", 0, "Abort after this many failed transactions (0 disables)")
+	flags.Float64("max-error-rate", 0, "Abort when this fraction of transactions fail (0 disables)")
+	flags.String("metrics-addr", "", "Serve prometheus metrics on this address (e.g. :9090) while running")
+	flags.String("otel-endpoint", "", "Export metrics and traces to this OTLP collector (host:port)")
+	flags.String("report-format", "table", "Report format (table, json, csv)")
+	flags.String("report-file", "", "Write the report to this file (json and csv reports go to stdout by default)")
+	flags.StringSlice("percentiles", []string{"50", "75", "90", "95", "99", "99.9", "99.99"}, "Latency percentiles to report")
+	flags.String("backend", "spanner", "Execute against Cloud Spanner or an in memory fake (spanner, memory)")
+	flags.Bool("create-database", false, "Create the instance and database with the TPC-C schema if they do not exist")
+	flags.BoolVar(&tpccDry, "dry", false, "Dry run. Print config and exit.")
+}
+
+// bindTPCCFlags binds the flags added by tpccPhaseFlags
+func bindTPCCFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	viper.BindPFlag("tpcc.warehouses", flags.Lookup("warehouses"))
+	viper.BindPFlag("tpcc.items", flags.Lookup("items"))
+	viper.BindPFlag("tpcc.customers", flags.Lookup("customers"))
+	viper.BindPFlag("threads", flags.Lookup("threads"))
+	viper.BindPFlag("seed", flags.Lookup("seed"))
+	viper.BindPFlag("operations.max_errors", flags.Lookup("max-errors"))
+	viper.BindPFlag("operations.max_error_rate", flags.Lookup("max-error-rate"))
+	viper.BindPFlag("metrics_addr", flags.Lookup("metrics-addr"))
+	viper.BindPFlag("otel.endpoint", flags.Lookup("otel-endpoint"))
+	viper.BindPFlag("report.format", flags.Lookup("report-format"))
+	viper.BindPFlag("report.file", flags.Lookup("report-file"))
+	viper.BindPFlag("report.percentiles", flags.Lookup("percentiles"))
+	viper.BindPFlag("backend", flags.Lookup("backend"))
+	viper.BindPFlag("create.database", flags.Lookup("create-database"))
+}
+
+var (
+	// Flags
+	tpccDry bool
+
+	// Commands
+	tpccCmd = &cobra.Command{
+		Use:   "tpcc",
+		Short: "Run the TPC-C benchmark",
+		Long: `Creates the TPC-C schema, loads warehouses and runs the five TPC-C transaction types in the standard mix.
+Runs report tpmC, the New-Order transactions completed per minute, and the latency of every transaction type.`,
+	}
+
+	tpccSchemaCmd = &cobra.Command{
+		Use:   "schema",
+		Short: "Create the TPC-C tables",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlag("create.database", cmd.Flags().Lookup("create-database"))
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			b, err := tpcc.Schema()
+			if err != nil {
+				log.Fatalf("unable to read schema: %s", err.Error())
+			}
+
+			statements, err := admin.ReadDDL(bytes.NewReader(b))
+			if err != nil {
+				log.Fatalf("unable to read schema: %s", err.Error())
+			}
+
+			logStatements(statements)
+			if tpccDry {
+				log.Println("Exiting (--dry)")
+				os.Exit(0)
+			}
+
+			cfg := schemaConfig()
+
+			ctx, cancel := cfg.Context()
+			graceful(cancel)
+
+			err = admin.ApplyDDL(ctx, cfg, statements)
+			if err != nil {
+				log.Fatalf("unable to apply ddl: %s", err.Error())
+			}
+		},
+	}
+
+	tpccLoadCmd = &cobra.Command{
+		Use:   "load",
+		Short: "Load the TPC-C tables",
+		Long:  `Loads the item catalog and --warehouses warehouses into empty TPC-C tables, using a thread per warehouse.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindTPCCFlags(cmd)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, cleanup := tpccConfig()
+			defer cleanup()
+
+			ctx, cancel := cfg.Context()
+			graceful(cancel)
+
+			createDatabase(ctx, cfg)
+
+			registry := metrics.NewRegistry()
+			obs, stopObserver := startObserver(ctx, cfg, nil)
+
+			log.Printf("Loading %d warehouses", cfg.TPCC.Warehouses)
+			var err error
+			start := time.Now()
+			metrics.GetOrRegisterTimer("load", registry).Time(func() {
+				err = tpccExecute(ctx, cfg, registry, obs, func(wl workload.Workload) error { return wl.Load(nil) })
+			})
+			rep := newReport("load", start, time.Now(), cfg, nil, registry)
+			summarizeReport(rep)
+
+			stopObserver()
+			writeReport(cfg, rep)
+			if err != nil {
+				log.Fatalf("unable to execute load operation: %s", err.Error())
+			}
+
+			checkAssertions(rep)
+		},
+	}
+
+	tpccRunCmd = &cobra.Command{
+		Use:   "run",
+		Short: "Run the TPC-C transaction mix",
+		Long: `Runs the TPC-C transaction mix on a terminal per thread, for --duration or --operations transactions.
+The in memory backend is loaded before the run.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindTPCCFlags(cmd)
+			flags := cmd.Flags()
+			viper.BindPFlag("operations.total", flags.Lookup("operations"))
+			viper.BindPFlag("tpcc.duration", flags.Lookup("duration"))
+			viper.BindPFlag("tpcc.keying", flags.Lookup("keying"))
+			viper.BindPFlag("report.interval", flags.Lookup("report-interval"))
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, cleanup := tpccConfig()
+			defer cleanup()
+
+			ctx, cancel := cfg.Context()
+			graceful(cancel)
+
+			createDatabase(ctx, cfg)
+
+			// The in memory backend starts empty
+			if cfg.Backend == backend.Memory {
+				log.Printf("Loading %d warehouses into the in memory backend", cfg.TPCC.Warehouses)
+				err := tpccExecute(ctx, cfg, metrics.NewRegistry(), nil, func(wl workload.Workload) error { return wl.Load(nil) })
+				if err != nil {
+					log.Fatalf("unable to load the in memory backend: %s", err.Error())
+				}
+			}
+
+			registry := metrics.NewRegistry()
+			obs, stopObserver := startObserver(ctx, cfg, nil)
+
+			log.Println("Executing run phase")
+			var err error
+			start := time.Now()
+			metrics.GetOrRegisterTimer("run", registry).Time(func() {
+				err = tpccExecute(ctx, cfg, registry, obs, func(wl workload.Workload) error { return wl.Run("") })
+			})
+			rep := newReport("run", start, time.Now(), cfg, nil, registry)
+			summarizeReport(rep)
+
+			stopObserver()
+			writeReport(cfg, rep)
+			if err != nil {
+				log.Fatalf("unable to execute run operation: %s", err.Error())
+			}
+
+			checkAssertions(rep)
+		},
+	}
+)
+
+// tpccConfig loads and validates the configuration of tpcc load and run. Without
+// create.ddl_file, the in memory backend and newly created databases get the TPC-C schema
+// from a temporary file. The returned function removes it.
+func tpccConfig() (*config.Config, func()) {
+	cfg, err := config.NewConfig(viper.GetViper())
+	if err != nil {
+		log.Fatalf("unable to parse configuration: %s", err.Error())
+	}
+
+	cfg.Workload = "tpcc"
+	cleanup := func() {}
+	if cfg.Create.DDLFile == "" {
+		cfg.Create.DDLFile, err = tpcc.WriteSchema()
+		if err != nil {
+			log.Fatalf("unable to write schema: %s", err.Error())
+		}
+
+		path := cfg.Create.DDLFile
+		cleanup = func() { os.Remove(path) }
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		cleanup()
+		log.Fatalf("unable to validate configuration %s", err.Error())
+	}
+
+	logConfig(cfg)
+	log.Printf("\tTPC-C:")
+	log.Printf("\t\tWarehouses: %d", cfg.TPCC.Warehouses)
+	log.Printf("\t\tItems: %d", cfg.TPCC.Items)
+	log.Printf("\t\tCustomers: %d", cfg.TPCC.Customers)
+	if cfg.TPCC.Duration > 0 {
+		log.Printf("\t\tDuration: %s", cfg.TPCC.Duration)
+	}
+	log.Printf("\t\tKeying: %t", cfg.TPCC.Keying)
+
+	if tpccDry {
+		log.Println("Exiting (--dry)")
+		cleanup()
+		os.Exit(0)
+	}
+
+	return cfg, cleanup
+}
+
+// tpccExecute runs f with a TPC-C workload
+func tpccExecute(ctx context.Context, cfg *config.Config, registry metrics.Registry, obs observer.Observer, f func(workload.Workload) error) error {
+	wl, err := tpcc.NewWorkload(workload.WorkloadConfig{
+		Context:        ctx,
+		Config:         cfg,
+		MetricRegistry: registry,
+		Observer:       obs,
+	})
+	if err != nil {
+		log.Fatalf("unable to create workload: %s", err.Error())
+	}
+	defer wl.Stop()
+
+	return f(wl)
+}
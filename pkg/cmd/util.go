@@ -18,15 +18,24 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"strings"
 	"time"
 
+	"github.com/cloudspannerecosystem/gcsb/pkg/admin"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/cloudspannerecosystem/gcsb/pkg/preset"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
 	"github.com/olekukonko/tablewriter"
 	"github.com/rcrowley/go-metrics"
-	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/spf13/viper"
 )
 
 // graceful wraps a context cancel func with a listener for OS interrupt signals
@@ -41,6 +50,153 @@ func graceful(cancelFn context.CancelFunc) {
 	}()
 }
 
+// startObserver starts serving live prometheus metrics if metrics_addr is set, exporting to an
+// OpenTelemetry collector if otel.endpoint is set and logging interval statistics if
+// report.interval is set. onInterval, if not nil, is called with the statistics of every
+// interval. It returns the observer to hand to the workload, which may be nil, and a function
+// that stops the server and flushes pending telemetry.
+func startObserver(ctx context.Context, cfg *config.Config, onInterval func(observer.IntervalStats)) (observer.Observer, func()) {
+	obs := make(observer.Multi, 0, 3)
+	stops := make([]func(), 0, 4)
+
+	if cfg.MetricsAddr != "" {
+		p := observer.NewPrometheus()
+		err := p.Serve(cfg.MetricsAddr)
+		if err != nil {
+			log.Fatalf("unable to serve metrics: %s", err.Error())
+		}
+
+		obs = append(obs, p)
+		stops = append(stops, func() {
+			err := p.Shutdown(5 * time.Second)
+			if err != nil {
+				log.Printf("stopping metrics server: %s", err.Error())
+			}
+		})
+	}
+
+	if cfg.OTel.Endpoint != "" {
+		log.Printf("Exporting OpenTelemetry data to %s (%s)", cfg.OTel.Endpoint, cfg.OTel.Protocol)
+		o, err := observer.NewOpenTelemetry(ctx, cfg.OTel)
+		if err != nil {
+			log.Fatalf("unable to start OpenTelemetry exporter: %s", err.Error())
+		}
+
+		obs = append(obs, o)
+		stops = append(stops, func() {
+			// The run context may already be canceled, flush with a fresh one
+			sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			err := o.Shutdown(sctx)
+			if err != nil {
+				log.Printf("stopping OpenTelemetry exporter: %s", err.Error())
+			}
+		})
+	}
+
+	if cfg.Report.Interval > 0 {
+		var w io.Writer
+		var header bool
+		if cfg.Report.IntervalFile != "" {
+			f, err := os.OpenFile(cfg.Report.IntervalFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				log.Fatalf("unable to open interval file: %s", err.Error())
+			}
+
+			// Only write a csv header into a new file, so that runs can append to the same one
+			if info, err := f.Stat(); err == nil && info.Size() == 0 {
+				header = true
+			}
+
+			w = f
+			stops = append(stops, func() { f.Close() })
+		}
+
+		i := observer.NewInterval(cfg.Histogram, w, cfg.Report.IntervalFormat, header)
+		if onInterval != nil {
+			i.Notify(onInterval)
+		}
+		i.Run(cfg.Report.Interval)
+
+		obs = append(obs, i)
+		// Flush the final interval before the file is closed
+		stops = append([]func(){i.Stop}, stops...)
+	}
+
+	stop := func() {
+		for _, s := range stops {
+			s()
+		}
+	}
+
+	switch len(obs) {
+	case 0:
+		return nil, stop
+	case 1:
+		return obs[0], stop
+	}
+
+	return obs, stop
+}
+
+// applyWorkload merges the preset named by workload (--workload) into the configuration and
+// returns it. It returns nil if no workload is set.
+func applyWorkload() *preset.Preset {
+	name := viper.GetString("workload")
+	if name == "" {
+		return nil
+	}
+
+	p, err := preset.Get(name)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	log.Printf("Applying workload %s (%s)", p.Name, p.Description)
+	err = p.Apply(viper.GetViper())
+	if err != nil {
+		log.Fatalf("unable to apply workload %s: %s", p.Name, err.Error())
+	}
+
+	return p
+}
+
+// createDatabase creates the instance and database if create.database is set and they do
+// not exist. The in memory backend is always created from create.ddl_file.
+func createDatabase(ctx context.Context, cfg *config.Config) {
+	if !cfg.Create.Database || cfg.Backend == backend.Memory {
+		return
+	}
+
+	log.Println("Creating instance and database if missing")
+	err := admin.CreateDatabase(ctx, cfg)
+	if err != nil {
+		log.Fatalf("unable to create database: %s", err.Error())
+	}
+}
+
+// seedMemory loads table into the in memory backend before a run. The load is not part of the
+// report.
+func seedMemory(ctx context.Context, cfg *config.Config, s schema.Schema, table string) {
+	log.Printf("Loading table '%s' into the in memory backend", table)
+	wl, err := workload.NewCoreWorkload(workload.WorkloadConfig{
+		Context:        ctx,
+		Config:         cfg,
+		Schema:         s,
+		MetricRegistry: metrics.NewRegistry(),
+	})
+	if err != nil {
+		log.Fatalf("unable to create workload: %s", err.Error())
+	}
+	defer wl.Stop()
+
+	err = wl.Load([]string{table})
+	if err != nil {
+		log.Fatalf("unable to load the in memory backend: %s", err.Error())
+	}
+}
+
 func logTable(str *strings.Builder) {
 	scanner := bufio.NewScanner(strings.NewReader(str.String()))
 	for scanner.Scan() {
@@ -48,66 +204,97 @@ func logTable(str *strings.Builder) {
 	}
 }
 
-func summarizeMetricsAsciiTable(registry metrics.Registry) {
-	// tableString := &strings.Builder{}
-	// t := tablewriter.NewWriter(tableString)
+// newReport collects the report of a finished phase and evaluates the configured assertions
+// against it
+func newReport(command string, start time.Time, end time.Time, cfg *config.Config, wl workload.Workload, registry metrics.Registry) *report.Report {
+	rep, err := report.ForWorkload(command, start, end, cfg, wl, registry)
+	if err != nil {
+		log.Fatalf("unable to create report: %s", err.Error())
+	}
 
-	summarizeTimings(registry)
+	return rep
 }
 
-func summarizeTimings(registry metrics.Registry) {
+// summarizeReport logs the report as ASCII tables
+func summarizeReport(rep *report.Report) {
 	tableString := &strings.Builder{}
-	t := tablewriter.NewWriter(tableString)
-
-	t.SetHeader([]string{
-		"metric",
-		"count",
-		"min",
-		"max",
-		"mean",
-		"stddev",
-		"median",
-		"95%",
-		"99%",
-	})
+	rep.WriteTable(tableString)
+	logTable(tableString)
+}
 
-	mtrcs := []string{
-		"schema.inference",
-		"run",
-		"operations.read.data",
-		"operations.read.time",
-		"operations.write.data",
-		"operations.write.time",
+// writeReport writes the report in report.format to report.file. Without a file, json and csv
+// reports are written to stdout, which keeps them apart from the logs on stderr.
+func writeReport(cfg *config.Config, rep *report.Report) {
+	if cfg.Report.File == "" && cfg.Report.Format == config.ReportFormatTable {
+		return
 	}
 
-	for _, mtrc := range mtrcs {
-		mr := registry.Get(mtrc)
-		if mr == nil {
-			log.Println("Encountered missing metric:", mtrc)
+	w := os.Stdout
+	if cfg.Report.File != "" {
+		f, err := os.Create(cfg.Report.File)
+		if err != nil {
+			log.Fatalf("unable to create report file: %s", err.Error())
 		}
+		defer f.Close()
+
+		w = f
+	}
+
+	err := rep.Write(w, cfg.Report.Format)
+	if err != nil {
+		log.Fatalf("unable to write report: %s", err.Error())
+	}
 
-		tmr, ok := mr.(metrics.Timer)
-		if !ok {
-			log.Println("Encountered non-timer metric: ", mtrc)
+	if cfg.Report.File != "" {
+		log.Printf("Wrote %s report to %s", cfg.Report.Format, cfg.Report.File)
+	}
+}
+
+// checkAssertions logs a table of assertion results and exits with a non-zero code if any of
+// them failed
+func checkAssertions(rep *report.Report) {
+	if len(rep.Assertions) == 0 {
+		return
+	}
+
+	tableString := &strings.Builder{}
+	t := tablewriter.NewWriter(tableString)
+	t.SetHeader([]string{"assertion", "actual", "result"})
+
+	failed := make([]string, 0)
+	for _, a := range rep.Assertions {
+		actual, status := a.Actual, "PASS"
+		if a.Error != "" {
+			actual = a.Error
+		}
+		if !a.Passed {
+			status = "FAIL"
+			failed = append(failed, fmt.Sprintf("%s (actual: %s)", a.Expr, actual))
 		}
 
-		ps := tmr.Percentiles([]float64{0.5, 0.95, 0.99})
-
-		t.Append([]string{
-			mtrc,
-			fmt.Sprintf("%d", tmr.Count()),
-			time.Duration(tmr.Min()).String(),
-			time.Duration(tmr.Max()).String(),
-			time.Duration(tmr.Mean()).String(),
-			time.Duration(tmr.StdDev()).String(),
-			time.Duration(ps[0]).String(),
-			time.Duration(ps[1]).String(),
-			time.Duration(ps[2]).String(),
-		})
+		t.Append([]string{a.Expr, actual, status})
 	}
 
 	t.Render()
 	logTable(tableString)
+
+	if len(failed) > 0 {
+		log.Fatalf("%d of %d assertions failed:\n\t%s", len(failed), len(rep.Assertions), strings.Join(failed, "\n\t"))
+	}
+
+	log.Printf("All %d assertions passed", len(rep.Assertions))
+}
+
+// schemaSource describes where schema.LoadSchema reads the schema from
+func schemaSource(cfg *config.Config) string {
+	switch {
+	case cfg.SchemaFile != "":
+		return cfg.SchemaFile
+	case cfg.Backend == backend.Memory:
+		return cfg.Create.DDLFile
+	default:
+		return "database"
+	}
 }
 
 func logConfig(cfg *config.Config) {
@@ -115,10 +302,38 @@ func logConfig(cfg *config.Config) {
 	log.Printf("\tProject: %s", cfg.Project)
 	log.Printf("\tInstance: %s", cfg.Instance)
 	log.Printf("\tDatabase: %s", cfg.Database)
+	if cfg.EmulatorHost != "" {
+		log.Printf("\tEmulator: %s", cfg.EmulatorHost)
+	}
+	if cfg.Backend != backend.Spanner {
+		log.Printf("\tBackend: %s (%s)", cfg.Backend, cfg.Create.DDLFile)
+	}
+	if cfg.SchemaFile != "" {
+		log.Printf("\tSchema: %s", cfg.SchemaFile)
+	}
 	log.Printf("\tThreads: %d", cfg.Threads)
 	log.Printf("\tNumConns: %d", cfg.NumConns)
+	log.Printf("\tSeed: %d", cfg.Seed)
+	if cfg.Partition.Count > 1 {
+		log.Printf("\tPartition: %d of %d", cfg.Partition.Index, cfg.Partition.Count)
+	}
+	if cfg.MetricsAddr != "" {
+		log.Printf("\tMetrics: %s", cfg.MetricsAddr)
+	}
+	if cfg.OTel.Endpoint != "" {
+		log.Printf("\tOTel: %s (tracing: %t, sample rate: %g)", cfg.OTel.Endpoint, cfg.OTel.Tracing.Enabled, cfg.OTel.Tracing.SampleRate)
+	}
+	if cfg.Report.File != "" {
+		log.Printf("\tReport: %s (%s)", cfg.Report.File, cfg.Report.Format)
+	}
+	if cfg.Report.Interval > 0 {
+		log.Printf("\tReport Interval: %s", cfg.Report.Interval)
+	}
 	log.Printf("\tOperations:")
 	log.Printf("\t\tTotal: %d", cfg.Operations.Total)
 	log.Printf("\t\tRead: %d", cfg.Operations.Read)
 	log.Printf("\t\tWrite: %d", cfg.Operations.Write)
+	log.Printf("\t\tTimeout: %s", cfg.Operations.Timeout)
+	log.Printf("\t\tRead Attempts: %d", cfg.Operations.Retry.Read.MaxAttempts)
+	log.Printf("\t\tWrite Attempts: %d", cfg.Operations.Retry.Write.MaxAttempts)
 }
@@ -18,4 +18,5 @@ import "github.com/spf13/viper"
 
 func Bind(v *viper.Viper) {
 	v.BindEnv("connection.project", "PROJECT")
+	v.BindEnv("emulator_host", "GCSB_EMULATOR_HOST", "SPANNER_EMULATOR_HOST")
 }
@@ -21,9 +21,9 @@ var _ Validate = (*Column)(nil)
 
 type (
 	Column struct {
-		Name      string     `mapstructure:"name"`
-		Type      *string    `mapstructure:"type"`
-		Generator *Generator `mapstructure:"generator"`
+		Name      string     `mapstructure:"name" json:"name"`
+		Type      *string    `mapstructure:"type" json:"type"`
+		Generator *Generator `mapstructure:"generator" json:"generator"`
 	}
 )
 
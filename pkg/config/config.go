@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/assertion"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/hashicorp/go-multierror"
 	"github.com/spf13/viper"
 	"google.golang.org/api/option"
@@ -43,19 +45,32 @@ type (
 	}
 
 	Config struct {
-		Project          string        `mapstructure:"project" yaml:"project"`
-		Instance         string        `mapstructure:"instance" yaml:"instance"`
-		Database         string        `mapstructure:"database" yaml:"database"`
-		Threads          int           `mapstructure:"threads" yaml:"threads"`
-		NumConns         int           `mapstructure:"num_conns" yaml:"num_cons"`
-		MaxExecutionTime time.Duration `mapstructure:"max_execution_time" yaml:"max_execution_time"`
-		Operations       Operations    `mapstructure:"operations" yaml:"operations"`
-		Pool             Pool          `mapstructure:"pool" yaml:"pool"`
-		Tables           []Table       `mapstructure:"tables" yaml:"tables"`
-		Batch            bool          `mapstructure:"batch"`
-		BatchSize        int           `mapstructure:"batch_size"`
+		Project          string        `mapstructure:"project" yaml:"project" json:"project"`
+		Instance         string        `mapstructure:"instance" yaml:"instance" json:"instance"`
+		Database         string        `mapstructure:"database" yaml:"database" json:"database"`
+		Threads          int           `mapstructure:"threads" yaml:"threads" json:"threads"`
+		NumConns         int           `mapstructure:"num_conns" yaml:"num_cons" json:"num_conns"`
+		MaxExecutionTime time.Duration `mapstructure:"max_execution_time" yaml:"max_execution_time" json:"max_execution_time"`
+		Seed             int64         `mapstructure:"seed" yaml:"seed" json:"seed"`
+		Operations       Operations    `mapstructure:"operations" yaml:"operations" json:"operations"`
+		Pool             Pool          `mapstructure:"pool" yaml:"pool" json:"pool"`
+		Tables           []Table       `mapstructure:"tables" yaml:"tables" json:"tables"`
+		Batch            bool          `mapstructure:"batch" json:"batch"`
+		BatchSize        int           `mapstructure:"batch_size" json:"batch_size"`
+		Assertions       []string      `mapstructure:"assertions" yaml:"assertions" json:"assertions"`       // Conditions evaluated after the run, see package assertion
+		MetricsAddr      string        `mapstructure:"metrics_addr" yaml:"metrics_addr" json:"metrics_addr"` // Serve prometheus metrics on this address while running. Empty disables the server
+		OTel             OTel          `mapstructure:"otel" yaml:"otel" json:"otel"`
+		Report           Report        `mapstructure:"report" yaml:"report" json:"report"`
+		Histogram        Histogram     `mapstructure:"histogram" yaml:"histogram" json:"histogram"`
+		Partition        Partition     `mapstructure:"partition" yaml:"partition" json:"partition"`             // Share of a distributed workload. Assigned by the coordinator
+		EmulatorHost     string        `mapstructure:"emulator_host" yaml:"emulator_host" json:"emulator_host"` // Connect to the Spanner emulator on this address instead of Cloud Spanner
+		Backend          string        `mapstructure:"backend" yaml:"backend" json:"backend"`                   // spanner or memory, see package backend
+		Create           Create        `mapstructure:"create" yaml:"create" json:"create"`
+		SchemaFile       string        `mapstructure:"schema_file" yaml:"schema_file" json:"schema_file"` // Read the schema from this DDL file instead of the information schema
+		Workload         string        `mapstructure:"workload" yaml:"workload" json:"workload"`          // Name of the preset the configuration is based on, see package preset
+		TPCC             TPCC          `mapstructure:"tpcc" yaml:"tpcc" json:"tpcc"`
 		clientOnce       sync.Once
-		client           *spanner.Client
+		client           backend.Client
 		contextOnce      sync.Once
 		ctx              context.Context
 		context          context.Context
@@ -79,6 +94,11 @@ func NewConfig(v *viper.Viper) (*Config, error) {
 		return nil, err
 	}
 
+	// Without a configured seed, pick one so that the run can still be reproduced from the logs
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+
 	return &c, nil
 }
 
@@ -86,20 +106,77 @@ func NewConfig(v *viper.Viper) (*Config, error) {
 func (c *Config) Validate() error {
 	var result *multierror.Error
 
-	if c.Project == "" {
-		result = multierror.Append(result, errors.New("project can not be empty"))
-	}
+	switch c.Backend {
+	case backend.Spanner:
+		if c.Project == "" {
+			result = multierror.Append(result, errors.New("project can not be empty"))
+		}
 
-	if c.Instance == "" {
-		result = multierror.Append(result, errors.New("instance can not be empty"))
+		if c.Instance == "" {
+			result = multierror.Append(result, errors.New("instance can not be empty"))
+		}
+
+		if c.Database == "" {
+			result = multierror.Append(result, errors.New("database can not be empty"))
+		}
+	case backend.Memory:
+		if c.Create.DDLFile == "" {
+			result = multierror.Append(result, errors.New("the memory backend needs create.ddl_file"))
+		}
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown backend '%s'", c.Backend))
 	}
 
-	if c.Database == "" {
-		result = multierror.Append(result, errors.New("database can not be empty"))
+	// Validate operations block
+	errs := c.Operations.Validate()
+	if errs != nil {
+		result = multierror.Append(result, errs)
 	}
 
 	// Validate pool block
-	errs := c.Pool.Validate()
+	errs = c.Pool.Validate()
+	if errs != nil {
+		result = multierror.Append(result, errs)
+	}
+
+	// Validate otel block
+	errs = c.OTel.Validate()
+	if errs != nil {
+		result = multierror.Append(result, errs)
+	}
+
+	// Validate histogram block
+	errs = c.Histogram.Validate()
+	if errs != nil {
+		result = multierror.Append(result, errs)
+	}
+
+	// Validate report block
+	errs = c.Report.Validate()
+	if errs != nil {
+		result = multierror.Append(result, errs)
+	}
+
+	// Validate partition block
+	errs = c.Partition.Validate()
+	if errs != nil {
+		result = multierror.Append(result, errs)
+	}
+
+	// Validate create block
+	errs = c.Create.Validate()
+	if errs != nil {
+		result = multierror.Append(result, errs)
+	}
+
+	// Validate tpcc block
+	errs = c.TPCC.Validate()
+	if errs != nil {
+		result = multierror.Append(result, errs)
+	}
+
+	// Validate assertions
+	_, errs = assertion.ParseAll(c.Assertions)
 	if errs != nil {
 		result = multierror.Append(result, errs)
 	}
@@ -107,36 +184,83 @@ func (c *Config) Validate() error {
 	return result.ErrorOrNil()
 }
 
-// Client returns a configured spanner client
-func (c *Config) Client(ctx context.Context) (*spanner.Client, error) {
+// Client returns a client of the configured backend
+func (c *Config) Client(ctx context.Context) (backend.Client, error) {
 	var err error
 	c.clientOnce.Do(func() {
-		c.client, err = spanner.NewClientWithConfig(ctx, c.DB(), spanner.ClientConfig{
-			SessionPoolConfig: spanner.SessionPoolConfig{
-				MaxOpened:           uint64(c.Pool.MaxOpened),
-				MinOpened:           uint64(c.Pool.MinOpened),
-				MaxIdle:             uint64(c.Pool.MaxIdle),
-				WriteSessions:       c.Pool.WriteSessions,
-				HealthCheckWorkers:  c.Pool.HealthcheckWorkers,
-				HealthCheckInterval: c.Pool.HealthcheckInterval,
-				TrackSessionHandles: c.Pool.TrackSessionHandles,
-			},
+		c.client, err = c.newClient(ctx)
+	})
+
+	return c.client, err
+}
+
+// UseClient makes Client return client instead of connecting on its own, so that the
+// configurations of several phases share a connection and in memory database. It has no effect
+// once Client was called.
+func (c *Config) UseClient(client backend.Client) {
+	c.clientOnce.Do(func() {
+		c.client = client
+	})
+}
+
+func (c *Config) newClient(ctx context.Context) (backend.Client, error) {
+	clientConfig := spanner.ClientConfig{
+		SessionPoolConfig: spanner.SessionPoolConfig{
+			MaxOpened:           uint64(c.Pool.MaxOpened),
+			MinOpened:           uint64(c.Pool.MinOpened),
+			MaxIdle:             uint64(c.Pool.MaxIdle),
+			WriteSessions:       c.Pool.WriteSessions,
+			HealthCheckWorkers:  c.Pool.HealthcheckWorkers,
+			HealthCheckInterval: c.Pool.HealthcheckInterval,
+			TrackSessionHandles: c.Pool.TrackSessionHandles,
 		},
+	}
+
+	if c.Backend == backend.Memory {
+		ddl, err := backend.ParseDDLFile(c.Create.DDLFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return backend.NewMemory(ctx, ddl, clientConfig)
+	}
+
+	opts := c.ClientOptions()
+	if c.EmulatorHost == "" {
+		opts = append(opts,
 			option.WithGRPCConnectionPool(c.NumConns),
 
 			// TODO(grpc/grpc-go#1388) using connection pool without WithBlock
 			// can cause RPCs to fail randomly. We can delete this after the issue is fixed.
 			option.WithGRPCDialOption(grpc.WithBlock()),
 		)
+	}
 
-	})
+	return spanner.NewClientWithConfig(ctx, c.DB(), clientConfig, opts...)
+}
 
-	return c.client, err
+// ClientOptions returns the options shared by clients of the data and admin APIs. On the
+// emulator, clients connect without TLS and authentication.
+func (c *Config) ClientOptions() []option.ClientOption {
+	if c.EmulatorHost == "" {
+		return []option.ClientOption{}
+	}
+
+	return []option.ClientOption{
+		option.WithEndpoint(c.EmulatorHost),
+		option.WithGRPCDialOption(grpc.WithInsecure()),
+		option.WithoutAuthentication(),
+	}
 }
 
 // DB returns the database DSN
 func (c *Config) DB() string {
-	return fmt.Sprintf("projects/%s/instances/%s/databases/%s", c.Project, c.Instance, c.Database)
+	return fmt.Sprintf("%s/databases/%s", c.InstancePath(), c.Database)
+}
+
+// InstancePath returns the resource name of the instance
+func (c *Config) InstancePath() string {
+	return fmt.Sprintf("projects/%s/instances/%s", c.Project, c.Instance)
 }
 
 func (c *Config) Context() (context.Context, context.CancelFunc) {
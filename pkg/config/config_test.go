@@ -17,10 +17,13 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
 )
 
 var (
@@ -87,5 +90,226 @@ func TestConfig(t *testing.T) {
 				So(c.DB(), ShouldEqual, fmt.Sprintf("projects/%s/instances/%s/databases/%s", c.Project, c.Instance, c.Database))
 			})
 		})
+
+		Convey("Retry", func() {
+			v, err := readConfig(append(cfgExample, []byte(`
+operations:
+  timeout: 200ms
+  retry:
+    read:
+      max_attempts: 3
+      codes: [UNAVAILABLE, DeadlineExceeded]
+`)...))
+			So(err, ShouldBeNil)
+
+			c, err := NewConfig(v)
+			So(err, ShouldBeNil)
+			So(c.Operations.Timeout, ShouldEqual, 200*time.Millisecond)
+			So(c.Operations.Retry.Read.MaxAttempts, ShouldEqual, 3)
+			So(c.Operations.Retry.Write.MaxAttempts, ShouldEqual, 1)
+			So(c.Operations.Validate(), ShouldBeNil)
+
+			cs, err := c.Operations.Retry.Read.RetryableCodes()
+			So(err, ShouldBeNil)
+			So(cs, ShouldResemble, []codes.Code{codes.Unavailable, codes.DeadlineExceeded})
+
+			c.Operations.Retry.Read.Codes = []string{"NOT_A_CODE"}
+			So(c.Operations.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("Operation mix", func() {
+			v, err := readConfig(append(cfgExample, []byte(`
+operations:
+  read: 0
+  write: 5
+  scan: 95
+  distribution: zipfian
+`)...))
+			So(err, ShouldBeNil)
+
+			c, err := NewConfig(v)
+			So(err, ShouldBeNil)
+			So(c.Operations.Scan, ShouldEqual, 95)
+			So(c.Operations.MaxScanLength, ShouldEqual, 100)
+			So(c.Operations.ReadsKeys(), ShouldBeTrue)
+			So(c.Operations.Validate(), ShouldBeNil)
+
+			c.Operations.MaxScanLength = 0
+			So(c.Operations.Validate(), ShouldNotBeNil)
+
+			c.Operations.MaxScanLength = 100
+			c.Operations.Distribution = "latest"
+			So(c.Operations.Validate(), ShouldNotBeNil)
+
+			c.Operations.Distribution = DistributionUniform
+			c.Operations.Update = -1
+			So(c.Operations.Validate(), ShouldNotBeNil)
+
+			c.Operations.Update = 0
+			c.Operations.Scan = 0
+			So(c.Operations.ReadsKeys(), ShouldBeFalse)
+		})
+
+		Convey("OTel", func() {
+			o := OTel{
+				Endpoint:           "localhost:4317",
+				Protocol:           OTelProtocolGRPC,
+				Interval:           time.Second,
+				ResourceAttributes: []string{"service.name=gcsb", "deployment.environment = staging"},
+			}
+			So(o.Validate(), ShouldBeNil)
+
+			pairs, err := ParsePairs(o.ResourceAttributes)
+			So(err, ShouldBeNil)
+			So(pairs, ShouldResemble, map[string]string{"service.name": "gcsb", "deployment.environment": "staging"})
+
+			o.Protocol = "udp"
+			o.Headers = []string{"novalue"}
+			So(o.Validate(), ShouldNotBeNil)
+
+			// Nothing is validated while the exporter is disabled
+			o.Endpoint = ""
+			So(o.Validate(), ShouldBeNil)
+		})
+
+		Convey("Report", func() {
+			r := Report{Format: ReportFormatJSON, File: "report.json", Percentiles: []float64{50, 99.99}, IntervalFormat: ReportFormatCSV}
+			So(r.Validate(), ShouldBeNil)
+
+			r.IntervalFormat = ReportFormatJSON
+			So(r.Validate(), ShouldNotBeNil)
+			r.IntervalFormat = ReportFormatJSONL
+
+			r.Percentiles = []float64{0}
+			So(r.Validate(), ShouldNotBeNil)
+
+			r.Percentiles = nil
+			r.Format = "xml"
+			So(r.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("Histogram", func() {
+			v, err := readConfig(append(cfgExample, []byte(`
+histogram:
+  significant_figures: 4
+report:
+  percentiles: [99, 99.999]
+`)...))
+			So(err, ShouldBeNil)
+
+			c, err := NewConfig(v)
+			So(err, ShouldBeNil)
+			So(c.Histogram, ShouldResemble, Histogram{Lowest: time.Microsecond, Highest: time.Hour, SignificantFigures: 4})
+			So(c.Histogram.Validate(), ShouldBeNil)
+			So(c.Report.Percentiles, ShouldResemble, []float64{99, 99.999})
+
+			c.Histogram.SignificantFigures = 6
+			c.Histogram.Highest = c.Histogram.Lowest
+			So(c.Histogram.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("Partition", func() {
+			v, err := readConfig(cfgExample)
+			So(err, ShouldBeNil)
+
+			c, err := NewConfig(v)
+			So(err, ShouldBeNil)
+			So(c.Partition, ShouldResemble, Partition{Index: 0, Count: 1})
+			So(c.Partition.Share(10), ShouldEqual, 10)
+			So(c.Partition.Worker(3), ShouldEqual, 3)
+
+			// Shares add up to the total and workers never collide
+			workers := make(map[int]bool)
+			var total int
+			for i := 0; i < 3; i++ {
+				p := Partition{Index: i, Count: 3}
+				So(p.Validate(), ShouldBeNil)
+				total += p.Share(10)
+
+				for w := 0; w < 4; w++ {
+					So(workers[p.Worker(w)], ShouldBeFalse)
+					workers[p.Worker(w)] = true
+				}
+			}
+			So(total, ShouldEqual, 10)
+			So(Partition{Index: 0, Count: 3}.Share(10), ShouldEqual, 4)
+
+			So((&Partition{Index: 3, Count: 3}).Validate(), ShouldNotBeNil)
+			So((&Partition{Index: 0, Count: 0}).Validate(), ShouldNotBeNil)
+		})
+
+		Convey("Emulator", func() {
+			v, err := readConfig(cfgExample)
+			So(err, ShouldBeNil)
+
+			c, err := NewConfig(v)
+			So(err, ShouldBeNil)
+			So(c.EmulatorHost, ShouldBeEmpty)
+			So(c.Create, ShouldResemble, Create{ProcessingUnits: 100})
+
+			os.Setenv("SPANNER_EMULATOR_HOST", "localhost:9010")
+			defer os.Unsetenv("SPANNER_EMULATOR_HOST")
+
+			v, err = readConfig(append(cfgExample, []byte(`
+create:
+  database: true
+  ddl_file: schemas/single_table.sql
+`)...))
+			So(err, ShouldBeNil)
+
+			c, err = NewConfig(v)
+			So(err, ShouldBeNil)
+			So(c.Validate(), ShouldBeNil)
+			So(c.EmulatorHost, ShouldEqual, "localhost:9010")
+			So(c.Create.Database, ShouldBeTrue)
+			So(c.Create.DDLFile, ShouldEqual, "schemas/single_table.sql")
+			So(c.ClientOptions(), ShouldHaveLength, 3)
+			So(c.InstancePath(), ShouldEqual, "projects/test-123/instances/gcsb-test-1")
+
+			So((&Create{ProcessingUnits: -1}).Validate(), ShouldNotBeNil)
+		})
+
+		Convey("Backend", func() {
+			v, err := readConfig(cfgExample)
+			So(err, ShouldBeNil)
+
+			c, err := NewConfig(v)
+			So(err, ShouldBeNil)
+			So(c.Backend, ShouldEqual, "spanner")
+
+			c.Backend = "memory"
+			So(c.Validate(), ShouldNotBeNil) // Requires create.ddl_file
+
+			c.Create.DDLFile = "schemas/single_table.sql"
+			c.Project, c.Instance, c.Database = "", "", ""
+			So(c.Validate(), ShouldBeNil)
+
+			c.Backend = "bigtable"
+			So(c.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("TPCC", func() {
+			v, err := readConfig(append(cfgExample, []byte(`
+tpcc:
+  warehouses: 4
+  duration: 5m
+`)...))
+			So(err, ShouldBeNil)
+
+			c, err := NewConfig(v)
+			So(err, ShouldBeNil)
+			So(c.TPCC.Warehouses, ShouldEqual, 4)
+			So(c.TPCC.Items, ShouldEqual, TPCCItems)
+			So(c.TPCC.Customers, ShouldEqual, TPCCCustomers)
+			So(c.TPCC.Duration, ShouldEqual, 5*time.Minute)
+			So(c.TPCC.Validate(), ShouldBeNil)
+
+			c.TPCC.Warehouses = 0
+			So(c.TPCC.Validate(), ShouldNotBeNil)
+
+			c.TPCC.Warehouses = 1
+			c.TPCC.Items = 10
+			So(c.TPCC.Validate(), ShouldNotBeNil)
+		})
 	})
 }
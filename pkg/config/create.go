@@ -0,0 +1,49 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Assert that Create implements Validate
+var _ Validate = (*Create)(nil)
+
+const (
+	// Instance configuration of instances created on the emulator
+	EmulatorInstanceConfig = "emulator-config"
+)
+
+type (
+	// Create configures the creation of a missing instance and database before a phase
+	Create struct {
+		Database        bool   `mapstructure:"database" yaml:"database" json:"database"`                         // Create the instance and database if they do not exist
+		DDLFile         string `mapstructure:"ddl_file" yaml:"ddl_file" json:"ddl_file"`                         // Apply the statements in this file to a newly created database
+		InstanceConfig  string `mapstructure:"instance_config" yaml:"instance_config" json:"instance_config"`    // Configuration of a new instance, e.g. regional-us-central1. Defaults to emulator-config on the emulator
+		ProcessingUnits int    `mapstructure:"processing_units" yaml:"processing_units" json:"processing_units"` // Compute capacity of a new instance
+	}
+)
+
+func (c *Create) Validate() error {
+	var result *multierror.Error
+
+	if c.ProcessingUnits < 0 {
+		result = multierror.Append(result, errors.New("create.processing_units must be >= 0"))
+	}
+
+	return result.ErrorOrNil()
+}
@@ -28,13 +28,80 @@ func SetDefaults(v *viper.Viper) {
 	v.SetDefault("batch", true)
 	v.SetDefault("batch_size", 5)
 	v.SetDefault("max_execution_time", 0)
+	v.SetDefault("seed", 0)
+	v.SetDefault("metrics_addr", "")
+	v.SetDefault("emulator_host", "")
+	v.SetDefault("backend", "spanner")
+	v.SetDefault("schema_file", "")
+	v.SetDefault("workload", "")
+
+	// Create defaults
+	v.SetDefault("create.database", false)
+	v.SetDefault("create.ddl_file", "")
+	v.SetDefault("create.instance_config", "")
+	v.SetDefault("create.processing_units", 100)
+
+	// OpenTelemetry defaults
+	v.SetDefault("otel.endpoint", "")
+	v.SetDefault("otel.protocol", "grpc")
+	v.SetDefault("otel.insecure", false)
+	v.SetDefault("otel.interval", "10s")
+	v.SetDefault("otel.resource_attributes", []string{})
+	v.SetDefault("otel.tracing.enabled", false)
+	v.SetDefault("otel.tracing.sample_rate", 0.01)
+
+	// Report defaults
+	v.SetDefault("report.format", "table")
+	v.SetDefault("report.file", "")
+	v.SetDefault("report.percentiles", DefaultReportPercentiles)
+	v.SetDefault("report.interval", 0)
+	v.SetDefault("report.interval_file", "")
+	v.SetDefault("report.interval_format", "jsonl")
+
+	// Histogram defaults
+	v.SetDefault("histogram.lowest", DefaultHistogramLowest)
+	v.SetDefault("histogram.highest", DefaultHistogramHighest)
+	v.SetDefault("histogram.significant_figures", DefaultHistogramSignificantFigures)
+
+	// Partition defaults
+	v.SetDefault("partition.index", 0)
+	v.SetDefault("partition.count", 1)
 
 	// Operations defualts
 	v.SetDefault("operations.total", 10000)
 	v.SetDefault("operations.read", 50)
 	v.SetDefault("operations.write", 50)
-	v.SetDefault("operations.sample_size", 50)
+	v.SetDefault("operations.update", 0)
+	v.SetDefault("operations.scan", 0)
+	v.SetDefault("operations.read_modify_write", 0)
+	v.SetDefault("operations.max_scan_length", 100)
+	v.SetDefault("operations.distribution", "uniform")
+	v.SetDefault("operations.sample_size", 10)
+	v.SetDefault("operations.sampling.strategy", "bernoulli")
+	v.SetDefault("operations.sampling.rows", 100000)
+	v.SetDefault("operations.sampling.max_keys", 1000000)
+	v.SetDefault("operations.sampling.partitions", 0)
+	v.SetDefault("operations.key_file", "")
 	v.SetDefault("operations.read_stale", false)
+	v.SetDefault("operations.latest.fraction", 0)
+	v.SetDefault("operations.latest.size", 10000)
+	v.SetDefault("operations.max_errors", 0)
+	v.SetDefault("operations.max_error_rate", 0)
+	v.SetDefault("operations.timeout", 0)
+	for _, op := range []string{"read", "write"} {
+		v.SetDefault("operations.retry."+op+".max_attempts", 1)
+		v.SetDefault("operations.retry."+op+".initial_backoff", "10ms")
+		v.SetDefault("operations.retry."+op+".max_backoff", "1s")
+		v.SetDefault("operations.retry."+op+".multiplier", 2)
+		v.SetDefault("operations.retry."+op+".codes", []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED", "ABORTED"})
+	}
+
+	// TPC-C defaults
+	v.SetDefault("tpcc.warehouses", 1)
+	v.SetDefault("tpcc.items", TPCCItems)
+	v.SetDefault("tpcc.customers", TPCCCustomers)
+	v.SetDefault("tpcc.duration", 0)
+	v.SetDefault("tpcc.keying", false)
 
 	// Pool Defaults
 	v.SetDefault("pool.max_opened", 1000)
@@ -21,11 +21,11 @@ var _ Validate = (*Generator)(nil)
 
 type (
 	Generator struct {
-		Type         *string  `mapstructure:"type"`
-		Length       *int     `mapstructure:"length"`
-		PrefixLength *int     `mapstructure:"prefix_length" yaml:"prefix_length"`
-		Seed         *int64   `mapstructure:"seed"`
-		Range        []*Range `mapstructure:"range"`
+		Type         *string  `mapstructure:"type" json:"type"`
+		Length       *int     `mapstructure:"length" json:"length"`
+		PrefixLength *int     `mapstructure:"prefix_length" yaml:"prefix_length" json:"prefix_length"`
+		Seed         *int64   `mapstructure:"seed" json:"seed"`
+		Range        []*Range `mapstructure:"range" json:"range"`
 	}
 )
 
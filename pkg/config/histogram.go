@@ -0,0 +1,59 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Assert that Histogram implements Validate
+var _ Validate = (*Histogram)(nil)
+
+const (
+	DefaultHistogramLowest             = time.Microsecond
+	DefaultHistogramHighest            = time.Hour
+	DefaultHistogramSignificantFigures = 3
+)
+
+type (
+	// Histogram configures the HDR histograms that record operation latencies. Values are
+	// recorded with a relative error of at most 10^-significant_figures.
+	Histogram struct {
+		Lowest             time.Duration `mapstructure:"lowest" yaml:"lowest" json:"lowest"`                                        // Smallest latency that is distinguished from 0
+		Highest            time.Duration `mapstructure:"highest" yaml:"highest" json:"highest"`                                     // Larger latencies are recorded as this value
+		SignificantFigures int           `mapstructure:"significant_figures" yaml:"significant_figures" json:"significant_figures"` // 1 to 5
+	}
+)
+
+func (h *Histogram) Validate() error {
+	var result *multierror.Error
+
+	if h.Lowest < 1 {
+		result = multierror.Append(result, errors.New("histogram.lowest must be >= 1ns"))
+	}
+
+	if h.Highest < 2*h.Lowest {
+		result = multierror.Append(result, errors.New("histogram.highest must be at least twice histogram.lowest"))
+	}
+
+	if h.SignificantFigures < 1 || h.SignificantFigures > 5 {
+		result = multierror.Append(result, errors.New("histogram.significant_figures must be between 1 and 5"))
+	}
+
+	return result.ErrorOrNil()
+}
@@ -15,29 +15,85 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"google.golang.org/grpc/codes"
 )
 
 // Assert that Operations implements Validate
 var _ Validate = (*Operations)(nil)
 
+const (
+	SamplingBernoulli   = "bernoulli"   // TABLESAMPLE BERNOULLI (sample_size PERCENT)
+	SamplingReservoir   = "reservoir"   // TABLESAMPLE RESERVOIR (rows ROWS)
+	SamplingPartitioned = "partitioned" // Read whole key ranges from randomly chosen partitions
+	SamplingStratified  = "stratified"  // Read an equal share of keys from every partition
+
+	DistributionUniform = "uniform" // Every sampled key is equally likely
+	DistributionZipfian = "zipfian" // A few sampled keys are hot, like the YCSB zipfian request distribution
+)
+
 type (
 	Operations struct {
-		Total       int           `mapstructure:"total" yaml:"total"`
-		Read        int           `mapstructure:"read" yaml:"read"`
-		Write       int           `mapstructure:"write" yaml:"write"`
-		SampleSize  float64       `mapstructure:"sample_size" yaml:"sample_size"`
-		ReadStale   bool          `mapstructure:"read_stale" yaml:"read_stale"`
-		Staleness   time.Duration `mapstructure:"staleness" yaml:"staleness"`
-		PartialKeys bool          `mapstructure:"partial_keys" yaml:"partial_keys"`
+		Total           int           `mapstructure:"total" yaml:"total" json:"total"`
+		Read            int           `mapstructure:"read" yaml:"read" json:"read"`
+		Write           int           `mapstructure:"write" yaml:"write" json:"write"`
+		Update          int           `mapstructure:"update" yaml:"update" json:"update"`                                  // Weight of updates of existing rows
+		Scan            int           `mapstructure:"scan" yaml:"scan" json:"scan"`                                        // Weight of short range scans
+		ReadModifyWrite int           `mapstructure:"read_modify_write" yaml:"read_modify_write" json:"read_modify_write"` // Weight of read-modify-write transactions
+		MaxScanLength   int           `mapstructure:"max_scan_length" yaml:"max_scan_length" json:"max_scan_length"`       // Scans read between 1 and this many rows, uniformly distributed
+		Distribution    string        `mapstructure:"distribution" yaml:"distribution" json:"distribution"`                // How keys are chosen from the sample, uniform or zipfian
+		SampleSize      float64       `mapstructure:"sample_size" yaml:"sample_size" json:"sample_size"`
+		ReadStale       bool          `mapstructure:"read_stale" yaml:"read_stale" json:"read_stale"`
+		Staleness       time.Duration `mapstructure:"staleness" yaml:"staleness" json:"staleness"`
+		PartialKeys     bool          `mapstructure:"partial_keys" yaml:"partial_keys" json:"partial_keys"`
+		Latest          Latest        `mapstructure:"latest" yaml:"latest" json:"latest"`
+		Sampling        Sampling      `mapstructure:"sampling" yaml:"sampling" json:"sampling"`
+		KeyFile         string        `mapstructure:"key_file" yaml:"key_file" json:"key_file"`
+		MaxErrors       int           `mapstructure:"max_errors" yaml:"max_errors" json:"max_errors"`             // Abort after this many failed operations. 0 disables the limit
+		MaxErrorRate    float64       `mapstructure:"max_error_rate" yaml:"max_error_rate" json:"max_error_rate"` // Abort when this fraction of operations fail. 0 disables the limit
+		Timeout         time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`                      // Deadline for each operation, including retries. 0 disables the deadline
+		Retry           Retries       `mapstructure:"retry" yaml:"retry" json:"retry"`
+	}
+
+	// Retries holds the retry policy for each operation type
+	Retries struct {
+		Read  Retry `mapstructure:"read" yaml:"read" json:"read"`
+		Write Retry `mapstructure:"write" yaml:"write" json:"write"`
+	}
+
+	// Retry configures how failed operations are retried. Retries happen on top of the
+	// retries performed by the spanner client library.
+	Retry struct {
+		MaxAttempts    int           `mapstructure:"max_attempts" yaml:"max_attempts" json:"max_attempts"`          // Attempts per operation, including the first. 1 disables retries
+		InitialBackoff time.Duration `mapstructure:"initial_backoff" yaml:"initial_backoff" json:"initial_backoff"` // Wait before the first retry
+		MaxBackoff     time.Duration `mapstructure:"max_backoff" yaml:"max_backoff" json:"max_backoff"`             // Upper bound on the wait between retries
+		Multiplier     float64       `mapstructure:"multiplier" yaml:"multiplier" json:"multiplier"`                // Backoff growth factor per retry
+		Codes          []string      `mapstructure:"codes" yaml:"codes" json:"codes"`                               // gRPC codes that are retried, such as UNAVAILABLE
+	}
+
+	// Latest configures reads against keys inserted during the current run
+	Latest struct {
+		Fraction float64 `mapstructure:"fraction" yaml:"fraction" json:"fraction"` // Fraction of reads that target recently inserted keys
+		Size     int     `mapstructure:"size" yaml:"size" json:"size"`             // Number of recently inserted keys remembered per table
+	}
+
+	// Sampling configures how tables are sampled to generate point reads
+	Sampling struct {
+		Strategy   string `mapstructure:"strategy" yaml:"strategy" json:"strategy"`       // One of bernoulli, reservoir, partitioned or stratified
+		Rows       int    `mapstructure:"rows" yaml:"rows" json:"rows"`                   // Row count for the reservoir strategy
+		MaxKeys    int    `mapstructure:"max_keys" yaml:"max_keys" json:"max_keys"`       // Maximum number of keys held in memory
+		Partitions int    `mapstructure:"partitions" yaml:"partitions" json:"partitions"` // Desired partition count for partitioned and stratified strategies
 	}
 
 	TableOperations struct {
 		// Read  int `mapstructure:"read"`
 		// Write int `mapstructure:"write"`
-		Total int `mapstructure:"total"`
+		Total int `mapstructure:"total" json:"total"`
 	}
 )
 
@@ -46,5 +102,129 @@ func (o *Operations) Validate() error {
 
 	// TODO: Validate table config
 
+	if o.Latest.Fraction < 0 || o.Latest.Fraction > 1 {
+		result = multierror.Append(result, errors.New("operations.latest.fraction must be between 0 and 1"))
+	}
+
+	if o.Latest.Fraction > 0 && o.Latest.Size <= 0 {
+		result = multierror.Append(result, errors.New("operations.latest.size must be > 0 when operations.latest.fraction is set"))
+	}
+
+	for _, w := range []struct {
+		name   string
+		weight int
+	}{{"read", o.Read}, {"write", o.Write}, {"update", o.Update}, {"scan", o.Scan}, {"read_modify_write", o.ReadModifyWrite}} {
+		if w.weight < 0 {
+			result = multierror.Append(result, fmt.Errorf("operations.%s must be >= 0", w.name))
+		}
+	}
+
+	if o.Scan > 0 && o.MaxScanLength <= 0 {
+		result = multierror.Append(result, errors.New("operations.max_scan_length must be > 0 when operations.scan is set"))
+	}
+
+	switch o.Distribution {
+	case DistributionUniform, DistributionZipfian:
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown key distribution '%s'", o.Distribution))
+	}
+
+	switch o.Sampling.Strategy {
+	case SamplingBernoulli:
+		if o.SampleSize <= 0 || o.SampleSize > 100 {
+			result = multierror.Append(result, errors.New("operations.sample_size must be > 0 and <= 100"))
+		}
+	case SamplingReservoir:
+		if o.Sampling.Rows <= 0 {
+			result = multierror.Append(result, errors.New("operations.sampling.rows must be > 0 for the reservoir strategy"))
+		}
+	case SamplingPartitioned, SamplingStratified:
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown sampling strategy '%s'", o.Sampling.Strategy))
+	}
+
+	if o.Sampling.MaxKeys <= 0 {
+		result = multierror.Append(result, errors.New("operations.sampling.max_keys must be > 0"))
+	}
+
+	if o.MaxErrors < 0 {
+		result = multierror.Append(result, errors.New("operations.max_errors must be >= 0"))
+	}
+
+	if o.MaxErrorRate < 0 || o.MaxErrorRate > 1 {
+		result = multierror.Append(result, errors.New("operations.max_error_rate must be between 0 and 1"))
+	}
+
+	if o.Timeout < 0 {
+		result = multierror.Append(result, errors.New("operations.timeout must be >= 0"))
+	}
+
+	if err := o.Retry.Read.validate("operations.retry.read"); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	if err := o.Retry.Write.validate("operations.retry.write"); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	return result.ErrorOrNil()
+}
+
+// ReadsKeys returns true if any of the weighted operations targets existing rows, which are
+// chosen from a sample of the table
+func (o *Operations) ReadsKeys() bool {
+	return o.Read > 0 || o.Update > 0 || o.Scan > 0 || o.ReadModifyWrite > 0
+}
+
+func (r *Retry) validate(prefix string) error {
+	var result *multierror.Error
+
+	if r.MaxAttempts < 1 {
+		result = multierror.Append(result, fmt.Errorf("%s.max_attempts must be >= 1", prefix))
+	}
+
+	if r.InitialBackoff < 0 {
+		result = multierror.Append(result, fmt.Errorf("%s.initial_backoff must be >= 0", prefix))
+	}
+
+	if r.MaxBackoff < r.InitialBackoff {
+		result = multierror.Append(result, fmt.Errorf("%s.max_backoff must be >= initial_backoff", prefix))
+	}
+
+	if r.Multiplier < 1 {
+		result = multierror.Append(result, fmt.Errorf("%s.multiplier must be >= 1", prefix))
+	}
+
+	if _, err := r.RetryableCodes(); err != nil {
+		result = multierror.Append(result, fmt.Errorf("%s.codes: %s", prefix, err.Error()))
+	}
+
 	return result.ErrorOrNil()
 }
+
+// RetryableCodes parses Codes. Names are case insensitive and underscores are optional,
+// so both DEADLINE_EXCEEDED and DeadlineExceeded are accepted.
+func (r *Retry) RetryableCodes() ([]codes.Code, error) {
+	ret := make([]codes.Code, 0, len(r.Codes))
+	for _, name := range r.Codes {
+		c, ok := parseCode(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown gRPC code '%s'", name)
+		}
+
+		ret = append(ret, c)
+	}
+
+	return ret, nil
+}
+
+func parseCode(name string) (codes.Code, bool) {
+	name = strings.ToUpper(strings.ReplaceAll(name, "_", ""))
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if strings.ToUpper(c.String()) == name {
+			return c, true
+		}
+	}
+
+	return 0, false
+}
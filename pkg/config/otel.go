@@ -0,0 +1,99 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Assert that OTel implements Validate
+var _ Validate = (*OTel)(nil)
+
+const (
+	OTelProtocolGRPC = "grpc"
+	OTelProtocolHTTP = "http"
+)
+
+type (
+	// OTel configures the OpenTelemetry (OTLP) exporter
+	OTel struct {
+		Endpoint           string        `mapstructure:"endpoint" yaml:"endpoint" json:"endpoint"`                                  // Collector host:port. Empty disables the exporter
+		Protocol           string        `mapstructure:"protocol" yaml:"protocol" json:"protocol"`                                  // grpc or http
+		Insecure           bool          `mapstructure:"insecure" yaml:"insecure" json:"insecure"`                                  // Disable TLS
+		Headers            []string      `mapstructure:"headers" yaml:"headers" json:"headers"`                                     // key=value pairs sent with every export
+		ResourceAttributes []string      `mapstructure:"resource_attributes" yaml:"resource_attributes" json:"resource_attributes"` // key=value pairs describing this process
+		Interval           time.Duration `mapstructure:"interval" yaml:"interval" json:"interval"`                                  // Metric export interval
+		Tracing            Tracing       `mapstructure:"tracing" yaml:"tracing" json:"tracing"`
+	}
+
+	// Tracing configures the export of operation spans
+	Tracing struct {
+		Enabled    bool    `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+		SampleRate float64 `mapstructure:"sample_rate" yaml:"sample_rate" json:"sample_rate"` // Fraction of operations that are traced
+	}
+)
+
+func (o *OTel) Validate() error {
+	var result *multierror.Error
+
+	if o.Endpoint == "" {
+		return nil
+	}
+
+	switch o.Protocol {
+	case OTelProtocolGRPC, OTelProtocolHTTP:
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown otel.protocol '%s'", o.Protocol))
+	}
+
+	if o.Interval <= 0 {
+		result = multierror.Append(result, errors.New("otel.interval must be > 0"))
+	}
+
+	if _, err := ParsePairs(o.Headers); err != nil {
+		result = multierror.Append(result, fmt.Errorf("otel.headers: %s", err.Error()))
+	}
+
+	if _, err := ParsePairs(o.ResourceAttributes); err != nil {
+		result = multierror.Append(result, fmt.Errorf("otel.resource_attributes: %s", err.Error()))
+	}
+
+	if o.Tracing.SampleRate < 0 || o.Tracing.SampleRate > 1 {
+		result = multierror.Append(result, errors.New("otel.tracing.sample_rate must be between 0 and 1"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+// ParsePairs parses a list of key=value strings. Lists are used instead of maps because viper
+// splits map keys containing dots, such as service.name, into nested maps.
+func ParsePairs(pairs []string) (map[string]string, error) {
+	ret := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		i := strings.Index(p, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("expected key=value, got '%s'", p)
+		}
+
+		ret[strings.TrimSpace(p[:i])] = strings.TrimSpace(p[i+1:])
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Assert that Partition implements Validate
+var _ Validate = (*Partition)(nil)
+
+type (
+	// Partition is the share of a workload executed by one of several gcsb processes. Each
+	// process executes its share of the operations and owns a disjoint range of the keys of every
+	// table: it inserts, samples, reads and updates only keys of its range. The coordinator
+	// assigns partitions to agents, a single process executes partition 0 of 1.
+	Partition struct {
+		Index int `mapstructure:"index" yaml:"index" json:"index"` // 0 based index of this process
+		Count int `mapstructure:"count" yaml:"count" json:"count"` // Number of processes sharing the workload
+	}
+)
+
+func (p *Partition) Validate() error {
+	var result *multierror.Error
+
+	if p.Count < 1 {
+		result = multierror.Append(result, errors.New("partition.count must be >= 1"))
+	}
+
+	if p.Index < 0 || p.Index >= p.Count {
+		result = multierror.Append(result, errors.New("partition.index must be >= 0 and < partition.count"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+// Share returns the part of n operations executed by this partition. Shares of all partitions
+// add up to n.
+func (p Partition) Share(n int) int {
+	if p.Count <= 1 {
+		return n
+	}
+
+	share := n / p.Count
+	if p.Index < n%p.Count {
+		share++
+	}
+
+	return share
+}
+
+// Worker returns the index of a local worker among the workers of all partitions. Workers of
+// different partitions never share an index, so they draw from different seeded sources.
+func (p Partition) Worker(worker int) int {
+	if p.Count <= 1 {
+		return worker
+	}
+
+	return worker*p.Count + p.Index
+}
@@ -21,13 +21,13 @@ var _ Validate = (*Range)(nil)
 
 type (
 	Range struct {
-		Begin   *interface{} `mapstructure:"begin"`   // Begin for ranges like ranged string & date
-		End     *interface{} `mapstructure:"end"`     // End of ranges like ranged string & date
-		Length  *int         `mapstructure:"length"`  // Length for generators like string or bytes
-		Static  *bool        `mapstructure:"static"`  // Static value indicator for bool generator
-		Value   *interface{} `mapstructure:"value"`   // Value for static generation
-		Minimum *interface{} `mapstructure:"minimum"` // Minimum for numeric generators
-		Maximum *interface{} `mapstructure:"maximum"` // Maximum for numeric generators
+		Begin   *interface{} `mapstructure:"begin" json:"begin"`     // Begin for ranges like ranged string & date
+		End     *interface{} `mapstructure:"end" json:"end"`         // End of ranges like ranged string & date
+		Length  *int         `mapstructure:"length" json:"length"`   // Length for generators like string or bytes
+		Static  *bool        `mapstructure:"static" json:"static"`   // Static value indicator for bool generator
+		Value   *interface{} `mapstructure:"value" json:"value"`     // Value for static generation
+		Minimum *interface{} `mapstructure:"minimum" json:"minimum"` // Minimum for numeric generators
+		Maximum *interface{} `mapstructure:"maximum" json:"maximum"` // Maximum for numeric generators
 	}
 )
 
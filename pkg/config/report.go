@@ -0,0 +1,81 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Assert that Report implements Validate
+var _ Validate = (*Report)(nil)
+
+const (
+	ReportFormatTable = "table"
+	ReportFormatJSON  = "json"
+	ReportFormatCSV   = "csv"
+	ReportFormatJSONL = "jsonl" // One JSON document per line, for interval statistics
+)
+
+var (
+	// Percentiles reported for every latency metric unless configured otherwise
+	DefaultReportPercentiles = []float64{50, 75, 90, 95, 99, 99.9, 99.99}
+)
+
+type (
+	// Report configures the report written at the end of a phase and the interval statistics
+	// written while it runs
+	Report struct {
+		Format      string    `mapstructure:"format" yaml:"format" json:"format"`                // table, json or csv
+		File        string    `mapstructure:"file" yaml:"file" json:"file"`                      // Write the report to this file. Empty writes json and csv reports to stdout
+		Percentiles []float64 `mapstructure:"percentiles" yaml:"percentiles" json:"percentiles"` // Percentiles (0 - 100) reported for every timer
+
+		Interval       time.Duration `mapstructure:"interval" yaml:"interval" json:"interval"`                      // Log statistics of every interval while running. 0 disables
+		IntervalFile   string        `mapstructure:"interval_file" yaml:"interval_file" json:"interval_file"`       // Append interval statistics to this file
+		IntervalFormat string        `mapstructure:"interval_format" yaml:"interval_format" json:"interval_format"` // jsonl or csv
+	}
+)
+
+func (r *Report) Validate() error {
+	var result *multierror.Error
+
+	switch r.Format {
+	case ReportFormatTable, ReportFormatJSON, ReportFormatCSV:
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown report.format '%s'", r.Format))
+	}
+
+	if r.Interval < 0 {
+		result = multierror.Append(result, errors.New("report.interval must be >= 0"))
+	}
+
+	switch r.IntervalFormat {
+	case ReportFormatJSONL, ReportFormatCSV:
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown report.interval_format '%s'", r.IntervalFormat))
+	}
+
+	for _, p := range r.Percentiles {
+		if p <= 0 || p > 100 {
+			result = multierror.Append(result, errors.New("report.percentiles must be > 0 and <= 100"))
+			break
+		}
+	}
+
+	return result.ErrorOrNil()
+}
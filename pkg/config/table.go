@@ -21,9 +21,9 @@ var _ Validate = (*Table)(nil)
 
 type (
 	Table struct {
-		Name       string           `mapstructure:"name"`
-		Operations *TableOperations `mapstructure:"operations" yaml:"operations"`
-		Columns    []Column         `mapstructure:"columns"`
+		Name       string           `mapstructure:"name" json:"name"`
+		Operations *TableOperations `mapstructure:"operations" yaml:"operations" json:"operations"`
+		Columns    []Column         `mapstructure:"columns" json:"columns"`
 	}
 )
 
@@ -0,0 +1,66 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Assert that TPCC implements Validate
+var _ Validate = (*TPCC)(nil)
+
+const (
+	// Cardinalities required by the TPC-C specification
+	TPCCItems     = 100000
+	TPCCCustomers = 3000
+)
+
+type (
+	// TPCC configures the TPC-C workload of gcsb tpcc, see package tpcc
+	TPCC struct {
+		Warehouses int           `mapstructure:"warehouses" yaml:"warehouses" json:"warehouses"` // Scale of the database. Every warehouse adds 10 districts with their customers, orders and stock
+		Items      int           `mapstructure:"items" yaml:"items" json:"items"`                // Items in the catalog, each stocked by every warehouse. Smaller values scale the database down for testing
+		Customers  int           `mapstructure:"customers" yaml:"customers" json:"customers"`    // Customers and initial orders per district. Smaller values scale the database down for testing
+		Duration   time.Duration `mapstructure:"duration" yaml:"duration" json:"duration"`       // Run the transaction mix for this long. 0 runs operations.total transactions
+		Keying     bool          `mapstructure:"keying" yaml:"keying" json:"keying"`             // Wait the keying and think times of the specification around every transaction
+	}
+)
+
+func (t *TPCC) Validate() error {
+	var result *multierror.Error
+
+	if t.Warehouses < 1 {
+		result = multierror.Append(result, errors.New("tpcc.warehouses must be >= 1"))
+	}
+
+	// New orders pick up to 15 distinct items
+	if t.Items < 100 {
+		result = multierror.Append(result, errors.New("tpcc.items must be >= 100"))
+	}
+
+	// The newest 30% of the initial orders are undelivered
+	if t.Customers < 10 {
+		result = multierror.Append(result, errors.New("tpcc.customers must be >= 10"))
+	}
+
+	if t.Duration < 0 {
+		result = multierror.Append(result, errors.New("tpcc.duration must be >= 0"))
+	}
+
+	return result.ErrorOrNil()
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+)
+
+type (
+	// Agent executes its share of a phase for a coordinator
+	Agent struct {
+		Coordinator string       // Base URL of the coordinator, e.g. http://coordinator:7070
+		Name        string       // Name of the agent in the coordinators logs
+		Client      *http.Client // Defaults to http.DefaultClient
+
+		id  int
+		mu  sync.Mutex
+		seq int // Sequence number of the next interval
+	}
+
+	// Phase prepares and executes an assignment. It must call ready once it is prepared, which
+	// blocks until every agent is ready and returns the common start time. Interval statistics
+	// passed to the agents SendInterval are streamed to the coordinator.
+	Phase func(ctx context.Context, a *Assignment, ready func() (time.Time, error)) (*report.Report, error)
+)
+
+// NewAgent returns an agent for the coordinator at url. The scheme defaults to http.
+func NewAgent(url string, name string) *Agent {
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+
+	return &Agent{
+		Coordinator: strings.TrimSuffix(url, "/"),
+		Name:        name,
+		Client:      http.DefaultClient,
+	}
+}
+
+// Run registers with the coordinator, executes the assigned phase and posts its result
+func (a *Agent) Run(ctx context.Context, phase Phase) error {
+	err := a.Register(ctx)
+	if err != nil {
+		return fmt.Errorf("registering: %s", err.Error())
+	}
+
+	as, err := a.Assignment(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching assignment: %s", err.Error())
+	}
+
+	log.Printf("Executing %s phase as partition %d of %d", as.Phase, as.Partition.Index, as.Partition.Count)
+
+	rep, err := phase(ctx, as, func() (time.Time, error) { return a.Ready(ctx) })
+
+	res := Result{Agent: a.id, Report: rep}
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	// The run context may be canceled, still tell the coordinator why
+	sctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	serr := a.post(sctx, PathResult, res, nil)
+	if serr != nil {
+		return fmt.Errorf("sending result: %s", serr.Error())
+	}
+
+	return err
+}
+
+// Register joins the test
+func (a *Agent) Register(ctx context.Context) error {
+	var reg Registered
+	err := a.post(ctx, PathRegister, Registration{Name: a.Name}, &reg)
+	if err != nil {
+		return err
+	}
+
+	a.id = reg.Agent
+	log.Printf("Registered with %s as agent %d", a.Coordinator, a.id)
+
+	return nil
+}
+
+// Assignment waits until every agent registered and returns the work of this agent
+func (a *Agent) Assignment(ctx context.Context) (*Assignment, error) {
+	var as Assignment
+	err := a.do(ctx, http.MethodGet, a.path(PathAssignment), nil, &as)
+	if err != nil {
+		return nil, err
+	}
+
+	return &as, nil
+}
+
+// Ready tells the coordinator that the agent is prepared, waits until every agent is and then
+// until the common start time
+func (a *Agent) Ready(ctx context.Context) (time.Time, error) {
+	var s Start
+	err := a.do(ctx, http.MethodPost, a.path(PathReady), nil, &s)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	d := time.Until(s.At)
+	if d < 0 {
+		log.Printf("Start time %s passed %s ago, are clocks synchronized?", s.At.Format(time.RFC3339Nano), -d)
+		return s.At, nil
+	}
+
+	log.Printf("Starting in %s", d.Round(time.Millisecond))
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		return s.At, ctx.Err()
+	}
+
+	return s.At, nil
+}
+
+// SendInterval streams the statistics of an interval to the coordinator. Failures are logged,
+// they do not affect the workload.
+func (a *Agent) SendInterval(s observer.IntervalStats) {
+	a.mu.Lock()
+	seq := a.seq
+	a.seq++
+	a.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := a.post(ctx, PathInterval, Interval{Agent: a.id, Seq: seq, Stats: s}, nil)
+	if err != nil {
+		log.Printf("sending interval statistics: %s", err.Error())
+	}
+}
+
+func (a *Agent) path(p string) string {
+	return fmt.Sprintf("%s?agent=%d", p, a.id)
+}
+
+func (a *Agent) post(ctx context.Context, path string, in interface{}, out interface{}) error {
+	return a.do(ctx, http.MethodPost, path, in, out)
+}
+
+func (a *Agent) do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.Coordinator+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,442 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// Time between the last agent becoming ready and the start, so that the start time reaches
+	// every agent before it passes
+	DefaultStartDelay = 2 * time.Second
+)
+
+type (
+	// CoordinatorConfig describes the phase executed by the agents
+	CoordinatorConfig struct {
+		Agents     int           // Number of agents to wait for
+		Phase      string        // load or run
+		Tables     []string      // Tables to load or the table to run against
+		Settings   []byte        // Configuration handed to the agents as YAML
+		StartDelay time.Duration // Delay between the last agent becoming ready and the start
+	}
+
+	// Coordinator hands out work to agents and collects their results
+	Coordinator struct {
+		cfg CoordinatorConfig
+
+		mu         sync.Mutex
+		names      []string           // Names of registered agents by index
+		ready      map[int]bool       // Agents that are ready to start
+		start      time.Time          // Set once all agents are ready
+		intervals  map[int][]Interval // Interval statistics by sequence number
+		results    map[int]Result
+		registered chan struct{} // Closed once all agents registered
+		started    chan struct{} // Closed once all agents are ready
+		aborted    chan struct{} // Closed if an agent failed before the start
+		done       chan struct{} // Closed once all agents posted a result
+
+		server *http.Server
+		addr   string
+	}
+)
+
+// NewCoordinator returns a coordinator for cfg.Agents agents
+func NewCoordinator(cfg CoordinatorConfig) (*Coordinator, error) {
+	if cfg.Agents < 1 {
+		return nil, errors.New("at least one agent is required")
+	}
+
+	switch cfg.Phase {
+	case PhaseLoad:
+	case PhaseRun:
+		if len(cfg.Tables) != 1 {
+			return nil, errors.New("the run phase requires exactly one table")
+		}
+	default:
+		return nil, fmt.Errorf("unknown phase '%s'", cfg.Phase)
+	}
+
+	if len(cfg.Tables) == 0 {
+		return nil, errors.New("missing table name")
+	}
+
+	if cfg.StartDelay <= 0 {
+		cfg.StartDelay = DefaultStartDelay
+	}
+
+	return &Coordinator{
+		cfg:        cfg,
+		names:      make([]string, 0, cfg.Agents),
+		ready:      make(map[int]bool),
+		intervals:  make(map[int][]Interval),
+		results:    make(map[int]Result),
+		registered: make(chan struct{}),
+		started:    make(chan struct{}),
+		aborted:    make(chan struct{}),
+		done:       make(chan struct{}),
+	}, nil
+}
+
+// Handler returns the http.Handler agents talk to
+func (c *Coordinator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathRegister, c.handleRegister)
+	mux.HandleFunc(PathAssignment, c.handleAssignment)
+	mux.HandleFunc(PathReady, c.handleReady)
+	mux.HandleFunc(PathInterval, c.handleInterval)
+	mux.HandleFunc(PathResult, c.handleResult)
+
+	return mux
+}
+
+// Serve starts accepting agents on addr in the background. It returns once the address is bound.
+func (c *Coordinator) Serve(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	c.addr = l.Addr().String()
+	c.server = &http.Server{Handler: c.Handler()}
+
+	go func() {
+		err := c.server.Serve(l)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("coordinator stopped: %s", err.Error())
+		}
+	}()
+
+	log.Printf("Waiting for %d agents on %s", c.cfg.Agents, c.addr)
+
+	return nil
+}
+
+// Addr returns the address the coordinator listens on
+func (c *Coordinator) Addr() string {
+	return c.addr
+}
+
+// Shutdown stops the server, waiting up to timeout for pending requests
+func (c *Coordinator) Shutdown(timeout time.Duration) error {
+	if c.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return c.server.Shutdown(ctx)
+}
+
+// Wait blocks until every agent posted its result and returns their reports in agent order.
+// The error lists the agents that failed. Reports of agents that failed after starting their
+// workload are still returned.
+func (c *Coordinator) Wait(ctx context.Context) ([]*report.Report, error) {
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		c.mu.Lock()
+		n := len(c.results)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("waiting for agents: %d of %d finished: %s", n, c.cfg.Agents, ctx.Err().Error())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Log intervals that not every agent reported, e.g. the final, shorter one
+	seqs := make([]int, 0, len(c.intervals))
+	for seq := range c.intervals {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	for _, seq := range seqs {
+		c.logInterval(c.intervals[seq])
+	}
+	c.intervals = make(map[int][]Interval)
+
+	var result *multierror.Error
+	reports := make([]*report.Report, 0, len(c.results))
+	for i := range c.names {
+		r := c.results[i]
+		if r.Error != "" {
+			result = multierror.Append(result, fmt.Errorf("agent %d (%s): %s", i, c.names[i], r.Error))
+		}
+		if r.Report != nil {
+			reports = append(reports, r.Report)
+		}
+	}
+
+	return reports, result.ErrorOrNil()
+}
+
+func (c *Coordinator) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var reg Registration
+	if !decode(w, r, &reg) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.names) >= c.cfg.Agents {
+		http.Error(w, fmt.Sprintf("all %d agents are registered", c.cfg.Agents), http.StatusConflict)
+		return
+	}
+
+	agent := len(c.names)
+	c.names = append(c.names, reg.Name)
+	log.Printf("Agent %d (%s) registered (%d/%d)", agent, reg.Name, len(c.names), c.cfg.Agents)
+
+	if len(c.names) == c.cfg.Agents {
+		close(c.registered)
+	}
+
+	respond(w, Registered{Agent: agent})
+}
+
+func (c *Coordinator) handleAssignment(w http.ResponseWriter, r *http.Request) {
+	agent, ok := c.agent(w, r)
+	if !ok {
+		return
+	}
+
+	// Partitions are only known once every agent registered
+	select {
+	case <-c.registered:
+	case <-r.Context().Done():
+		return
+	}
+
+	respond(w, Assignment{
+		Phase:     c.cfg.Phase,
+		Tables:    c.cfg.Tables,
+		Settings:  string(c.cfg.Settings),
+		Partition: config.Partition{Index: agent, Count: c.cfg.Agents},
+	})
+}
+
+func (c *Coordinator) handleReady(w http.ResponseWriter, r *http.Request) {
+	agent, ok := c.agent(w, r)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	if !c.ready[agent] {
+		c.ready[agent] = true
+		log.Printf("Agent %d (%s) is ready (%d/%d)", agent, c.names[agent], len(c.ready), c.cfg.Agents)
+
+		if len(c.ready) == c.cfg.Agents {
+			c.start = time.Now().Add(c.cfg.StartDelay)
+			log.Printf("Starting all agents at %s", c.start.Format(time.RFC3339Nano))
+			close(c.started)
+		}
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-c.started:
+	case <-c.aborted:
+		http.Error(w, "an agent failed before the start", http.StatusConflict)
+		return
+	case <-r.Context().Done():
+		return
+	}
+
+	respond(w, Start{At: c.start})
+}
+
+func (c *Coordinator) handleInterval(w http.ResponseWriter, r *http.Request) {
+	var i Interval
+	if !decode(w, r, &i) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.intervals[i.Seq] = append(c.intervals[i.Seq], i)
+	if len(c.intervals[i.Seq]) == c.cfg.Agents {
+		c.logInterval(c.intervals[i.Seq])
+		delete(c.intervals, i.Seq)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Coordinator) handleResult(w http.ResponseWriter, r *http.Request) {
+	var res Result
+	if !decode(w, r, &res) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if res.Agent < 0 || res.Agent >= len(c.names) {
+		http.Error(w, fmt.Sprintf("unknown agent %d", res.Agent), http.StatusNotFound)
+		return
+	}
+
+	if _, ok := c.results[res.Agent]; !ok {
+		c.results[res.Agent] = res
+		if res.Error != "" {
+			log.Printf("Agent %d (%s) failed: %s", res.Agent, c.names[res.Agent], res.Error)
+
+			// Do not keep the other agents waiting for a start that never comes
+			if c.start.IsZero() && !isClosed(c.aborted) {
+				close(c.aborted)
+			}
+		} else {
+			log.Printf("Agent %d (%s) finished (%d/%d)", res.Agent, c.names[res.Agent], len(c.results), c.cfg.Agents)
+		}
+
+		if len(c.results) == c.cfg.Agents {
+			close(c.done)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// agent returns the registered agent named by the agent query parameter
+func (c *Coordinator) agent(w http.ResponseWriter, r *http.Request) (int, bool) {
+	agent, err := strconv.Atoi(r.URL.Query().Get("agent"))
+	if err != nil {
+		http.Error(w, "invalid agent", http.StatusBadRequest)
+		return 0, false
+	}
+
+	c.mu.Lock()
+	n := len(c.names)
+	c.mu.Unlock()
+
+	if agent < 0 || agent >= n {
+		http.Error(w, fmt.Sprintf("unknown agent %d", agent), http.StatusNotFound)
+		return 0, false
+	}
+
+	return agent, true
+}
+
+// logInterval logs the combined statistics of all agents in one interval. c.mu must be held.
+func (c *Coordinator) logInterval(is []Interval) {
+	log.Printf("Cluster (%d/%d agents) %s", len(is), c.cfg.Agents, CombineIntervals(is).String())
+}
+
+// CombineIntervals sums the statistics of several agents in the same interval. Latency
+// percentiles can not be combined exactly, they are the highest of any agent.
+func CombineIntervals(is []Interval) observer.IntervalStats {
+	var ret observer.IntervalStats
+	ops := make(map[string]*observer.IntervalOperationStats)
+	names := make([]string, 0)
+
+	for _, i := range is {
+		s := i.Stats
+		if s.Time.After(ret.Time) {
+			ret.Time = s.Time
+		}
+		if s.Elapsed > ret.Elapsed {
+			ret.Elapsed = s.Elapsed
+		}
+		if s.Interval > ret.Interval {
+			ret.Interval = s.Interval
+		}
+		ret.InFlight += s.InFlight
+
+		for _, o := range s.Operations {
+			c, ok := ops[o.Operation]
+			if !ok {
+				c = &observer.IntervalOperationStats{Operation: o.Operation}
+				ops[o.Operation] = c
+				names = append(names, o.Operation)
+			}
+
+			c.Operations += o.Operations
+			c.Rows += o.Rows
+			c.Rate += o.Rate
+			c.Errors += o.Errors
+			c.P50 = maxInt64(c.P50, o.P50)
+			c.P95 = maxInt64(c.P95, o.P95)
+			c.P99 = maxInt64(c.P99, o.P99)
+		}
+	}
+
+	sort.Strings(names)
+	ret.Operations = make([]observer.IntervalOperationStats, 0, len(names))
+	for _, n := range names {
+		ret.Operations = append(ret.Operations, *ops[n])
+	}
+
+	return ret
+}
+
+// decode reads a JSON request body into v and answers bad requests
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decoding request: %s", err.Error()), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func respond(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("writing response: %s", err.Error())
+	}
+}
+
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,215 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/histogram"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+	"github.com/rcrowley/go-metrics"
+)
+
+const testOperations = 1000
+
+func newTestCoordinator(t *testing.T, agents int) *Coordinator {
+	t.Helper()
+
+	c, err := NewCoordinator(CoordinatorConfig{
+		Agents:     agents,
+		Phase:      PhaseLoad,
+		Tables:     []string{"Singers"},
+		Settings:   []byte("threads: 4\n"),
+		StartDelay: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("creating coordinator: %v", err)
+	}
+
+	if err := c.Serve("localhost:0"); err != nil {
+		t.Fatalf("starting coordinator: %v", err)
+	}
+	t.Cleanup(func() { c.Shutdown(time.Second) })
+
+	return c
+}
+
+// testPhase writes the agents share of testOperations rows with a latency of 1ms per row index
+func testPhase(a *Agent, starts chan<- time.Time) Phase {
+	return func(ctx context.Context, as *Assignment, ready func() (time.Time, error)) (*report.Report, error) {
+		if as.Phase != PhaseLoad || as.Settings != "threads: 4\n" {
+			return nil, fmt.Errorf("unexpected assignment %+v", as)
+		}
+
+		at, err := ready()
+		if err != nil {
+			return nil, err
+		}
+		starts <- at
+
+		registry := metrics.NewRegistry()
+		tmr := histogram.GetOrRegisterTimer("operations.write.time", registry, config.Histogram{})
+		meter := metrics.GetOrRegisterMeter("operations.write.rate", registry)
+
+		start := time.Now()
+		n := as.Partition.Share(testOperations)
+		for i := 0; i < n; i++ {
+			// Partitions interleave like their workers do, so together they cover every index once
+			tmr.Update(time.Duration(i*as.Partition.Count+as.Partition.Index+1) * time.Millisecond)
+			meter.Mark(1)
+		}
+
+		a.SendInterval(observer.IntervalStats{
+			Interval:   time.Second,
+			Operations: []observer.IntervalOperationStats{{Operation: "write", Operations: int64(n), Rows: int64(n), Rate: float64(n)}},
+		})
+
+		return report.New(PhaseLoad, start, time.Now(), &config.Config{Partition: as.Partition}, nil, registry)
+	}
+}
+
+func TestCoordinator(t *testing.T) {
+	const agents = 3
+	c := newTestCoordinator(t, agents)
+
+	starts := make(chan time.Time, agents)
+	errs := make(chan error, agents)
+	for i := 0; i < agents; i++ {
+		go func(i int) {
+			a := NewAgent(c.Addr(), fmt.Sprintf("agent-%d", i))
+			errs <- a.Run(context.Background(), testPhase(a, starts))
+		}(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reports, err := c.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	for i := 0; i < agents; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("agent failed: %v", err)
+		}
+	}
+	if len(reports) != agents {
+		t.Fatalf("got %d reports, want %d", len(reports), agents)
+	}
+
+	// Every agent was handed the same start time
+	first := <-starts
+	for i := 1; i < agents; i++ {
+		if at := <-starts; !at.Equal(first) {
+			t.Errorf("agents started at %s and %s", first, at)
+		}
+	}
+
+	// The merged report is exactly the report of a single process executing every operation
+	m, err := report.Merge(reports...)
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+
+	registry := metrics.NewRegistry()
+	tmr := histogram.GetOrRegisterTimer("operations.write.time", registry, config.Histogram{})
+	for i := 1; i <= testOperations; i++ {
+		tmr.Update(time.Duration(i) * time.Millisecond)
+	}
+	want, _ := report.New(PhaseLoad, m.Start, m.End, nil, nil, registry)
+
+	got, _ := m.Metric("operations.write.time")
+	exp, _ := want.Metric("operations.write.time")
+	if got.Count != testOperations || got.Max != exp.Max || got.Percentiles["p99.9"] != exp.Percentiles["p99.9"] {
+		t.Errorf("merged timer %+v, want %+v", got, exp)
+	}
+	if m.Throughput.Operations != testOperations {
+		t.Errorf("merged %d operations, want %d", m.Throughput.Operations, testOperations)
+	}
+}
+
+func TestCoordinatorRejectsExtraAgents(t *testing.T) {
+	c := newTestCoordinator(t, 1)
+	ctx := context.Background()
+
+	if err := NewAgent(c.Addr(), "a").Register(ctx); err != nil {
+		t.Fatalf("registering the first agent: %v", err)
+	}
+	if err := NewAgent(c.Addr(), "b").Register(ctx); err == nil {
+		t.Error("registered more agents than expected")
+	}
+}
+
+func TestCoordinatorAbort(t *testing.T) {
+	const agents = 2
+	c := newTestCoordinator(t, agents)
+
+	// One agent fails to prepare, the other must not wait for the start forever
+	var wg sync.WaitGroup
+	errs := make([]error, agents)
+	for i := 0; i < agents; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			a := NewAgent(c.Addr(), fmt.Sprintf("agent-%d", i))
+			errs[i] = a.Run(context.Background(), func(ctx context.Context, as *Assignment, ready func() (time.Time, error)) (*report.Report, error) {
+				if as.Partition.Index == 0 {
+					return nil, errors.New("table not found")
+				}
+
+				_, err := ready()
+				return nil, err
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("agent %d did not fail", i)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := c.Wait(ctx); err == nil {
+		t.Error("Wait did not report the failed agents")
+	}
+}
+
+func TestCombineIntervals(t *testing.T) {
+	s := CombineIntervals([]Interval{
+		{Agent: 0, Stats: observer.IntervalStats{InFlight: 1, Operations: []observer.IntervalOperationStats{{Operation: "write", Rows: 10, Rate: 10, P99: 5}}}},
+		{Agent: 1, Stats: observer.IntervalStats{InFlight: 2, Operations: []observer.IntervalOperationStats{{Operation: "write", Rows: 20, Rate: 20, P99: 9}, {Operation: "read", Rows: 1}}}},
+	})
+
+	if s.InFlight != 3 || len(s.Operations) != 2 {
+		t.Fatalf("unexpected combined stats %+v", s)
+	}
+
+	w := s.Operations[1]
+	if w.Operation != "write" || w.Rows != 30 || w.Rate != 30 || w.P99 != 9 {
+		t.Errorf("unexpected combined writes %+v", w)
+	}
+}
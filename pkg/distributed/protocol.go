@@ -0,0 +1,88 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// package distributed runs a phase on several gcsb agents at once and merges their results.
+//
+// A coordinator waits for a fixed number of agents to register over HTTP. Every agent receives
+// the coordinators configuration and a partition of the workload, prepares its workload and
+// reports that it is ready. Once all agents are ready, the coordinator hands out a common start
+// time. While they run, agents stream interval statistics to the coordinator, and finally post
+// their report. The coordinator merges the reports, including their latency histograms, into one.
+//
+// All messages are JSON documents:
+//
+//	POST /v1/register          Registration -> Registered
+//	GET  /v1/assignment?agent= -> Assignment, blocks until all agents registered
+//	POST /v1/ready?agent=      -> Start, blocks until all agents are ready
+//	POST /v1/interval          Interval
+//	POST /v1/result            Result
+package distributed
+
+import (
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+)
+
+const (
+	PathRegister   = "/v1/register"
+	PathAssignment = "/v1/assignment"
+	PathReady      = "/v1/ready"
+	PathInterval   = "/v1/interval"
+	PathResult     = "/v1/result"
+
+	PhaseLoad = "load"
+	PhaseRun  = "run"
+)
+
+type (
+	// Registration is sent by an agent to join the test
+	Registration struct {
+		Name string `json:"name"` // Used in logs, e.g. the host name
+	}
+
+	// Registered identifies an agent in later requests
+	Registered struct {
+		Agent int `json:"agent"`
+	}
+
+	// Assignment is the work of an agent
+	Assignment struct {
+		Phase     string           `json:"phase"`     // load or run
+		Tables    []string         `json:"tables"`    // Tables to load or the table to run against
+		Settings  string           `json:"settings"`  // Resolved configuration of the coordinator as YAML
+		Partition config.Partition `json:"partition"` // Share of the workload executed by the agent
+	}
+
+	// Start is the moment all agents start executing their workload
+	Start struct {
+		At time.Time `json:"at"`
+	}
+
+	// Interval are the statistics of one interval of an agent
+	Interval struct {
+		Agent int                    `json:"agent"`
+		Seq   int                    `json:"seq"` // Index of the interval, starting at 0
+		Stats observer.IntervalStats `json:"stats"`
+	}
+
+	// Result is the outcome of an agents workload
+	Result struct {
+		Agent  int            `json:"agent"`
+		Report *report.Report `json:"report,omitempty"` // Set unless the workload could not be started
+		Error  string         `json:"error,omitempty"`  // Set if the workload failed or was aborted
+	}
+)
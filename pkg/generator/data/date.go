@@ -33,7 +33,7 @@ const (
 
 type (
 	DateGenerator struct {
-		src   rand.Source
+		src   *rand.Rand
 		delta int64
 		min   int64
 		max   int64
@@ -50,7 +50,7 @@ type (
 
 func NewDateGenerator(cfg Config) (Generator, error) {
 	ret := &DateGenerator{
-		src: cfg.Source(),
+		src: rand.New(cfg.Source()),
 	}
 
 	if cfg.Range() {
@@ -83,7 +83,7 @@ func NewDateGenerator(cfg Config) (Generator, error) {
 }
 
 func (g *DateGenerator) Next() interface{} {
-	sec := rand.Int63n(g.delta) + g.min
+	sec := g.src.Int63n(g.delta) + g.min
 	return civil.DateOf(time.Unix(sec, 0))
 }
 
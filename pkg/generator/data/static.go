@@ -177,7 +177,7 @@ func (g *StaticBooleanGenerator) Type() spansql.TypeBase {
 }
 
 func (g *StaticBooleanGenerator) Next() interface{} {
-	return g.vals[rand.Intn(g.l)]
+	return g.vals[g.src.Intn(g.l)]
 }
 
 func (g *StaticStringGenerator) Type() spansql.TypeBase {
@@ -185,7 +185,7 @@ func (g *StaticStringGenerator) Type() spansql.TypeBase {
 }
 
 func (g *StaticStringGenerator) Next() interface{} {
-	return g.vals[rand.Intn(g.l)]
+	return g.vals[g.src.Intn(g.l)]
 }
 
 func (g *StaticBytesGenerator) Type() spansql.TypeBase {
@@ -193,7 +193,7 @@ func (g *StaticBytesGenerator) Type() spansql.TypeBase {
 }
 
 func (g *StaticBytesGenerator) Next() interface{} {
-	return g.vals[rand.Intn(g.l)]
+	return g.vals[g.src.Intn(g.l)]
 }
 
 func (g *StaticInt64Generator) Type() spansql.TypeBase {
@@ -201,7 +201,7 @@ func (g *StaticInt64Generator) Type() spansql.TypeBase {
 }
 
 func (g *StaticInt64Generator) Next() interface{} {
-	return g.vals[rand.Intn(g.l)]
+	return g.vals[g.src.Intn(g.l)]
 }
 
 func (g *StaticFloat64Generator) Type() spansql.TypeBase {
@@ -209,7 +209,7 @@ func (g *StaticFloat64Generator) Type() spansql.TypeBase {
 }
 
 func (g *StaticFloat64Generator) Next() interface{} {
-	return g.vals[rand.Intn(g.l)]
+	return g.vals[g.src.Intn(g.l)]
 }
 
 func (g *StaticNumericGenerator) Type() spansql.TypeBase {
@@ -217,7 +217,7 @@ func (g *StaticNumericGenerator) Type() spansql.TypeBase {
 }
 
 func (g *StaticNumericGenerator) Next() interface{} {
-	return g.vals[rand.Intn(g.l)]
+	return g.vals[g.src.Intn(g.l)]
 }
 
 func (g *StaticDateGenerator) Type() spansql.TypeBase {
@@ -225,7 +225,7 @@ func (g *StaticDateGenerator) Type() spansql.TypeBase {
 }
 
 func (g *StaticDateGenerator) Next() interface{} {
-	return g.vals[rand.Intn(g.l)]
+	return g.vals[g.src.Intn(g.l)]
 }
 
 func (g *StaticTimestampGenerator) Type() spansql.TypeBase {
@@ -233,5 +233,5 @@ func (g *StaticTimestampGenerator) Type() spansql.TypeBase {
 }
 
 func (g *StaticTimestampGenerator) Next() interface{} {
-	return g.vals[rand.Intn(g.l)]
+	return g.vals[g.src.Intn(g.l)]
 }
@@ -27,7 +27,7 @@ var _ Generator = (*TimestampGenerator)(nil)
 
 type (
 	TimestampGenerator struct {
-		src   rand.Source
+		src   *rand.Rand
 		delta int64
 		min   int64
 		max   int64
@@ -37,7 +37,7 @@ type (
 
 func NewTimestampGenerator(cfg Config) (Generator, error) {
 	ret := &TimestampGenerator{
-		src: cfg.Source(),
+		src: rand.New(cfg.Source()),
 	}
 
 	if cfg.Range() {
@@ -71,7 +71,7 @@ func NewTimestampGenerator(cfg Config) (Generator, error) {
 }
 
 func (g *TimestampGenerator) Next() interface{} {
-	sec := rand.Int63n(g.delta) + g.min
+	sec := g.src.Int63n(g.delta) + g.min
 	return time.Unix(sec, 0)
 }
 
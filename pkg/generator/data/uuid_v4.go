@@ -16,6 +16,8 @@ package data
 
 import (
 	"fmt"
+	"io"
+	"math/rand"
 	"strings"
 
 	"cloud.google.com/go/spanner/spansql"
@@ -35,10 +37,17 @@ var _ Generator = (*UUIDV4Generator)(nil)
 type UUIDV4Generator struct {
 	colType   spansql.TypeBase
 	colLength int64
+	rnd       io.Reader
 }
 
 // NewUUIDV4Generator returns a generator for UUID v4.
 func NewUUIDV4Generator(colType spansql.TypeBase, colLength int64) (Generator, error) {
+	return NewUUIDV4GeneratorWithSource(colType, colLength, nil)
+}
+
+// NewUUIDV4GeneratorWithSource returns a generator for UUID v4 that draws its random bits from src,
+// so that the sequence of UUIDs is reproducible. If src is nil, crypto/rand is used.
+func NewUUIDV4GeneratorWithSource(colType spansql.TypeBase, colLength int64, src rand.Source) (Generator, error) {
 	// Validate column.
 	switch colType {
 	case spansql.String:
@@ -53,15 +62,27 @@ func NewUUIDV4Generator(colType spansql.TypeBase, colLength int64) (Generator, e
 		return nil, fmt.Errorf("invalid column type for UUID: %v", colType.SQL())
 	}
 
-	return &UUIDV4Generator{
+	g := &UUIDV4Generator{
 		colType:   colType,
 		colLength: colLength,
-	}, nil
+	}
+
+	if src != nil {
+		g.rnd = rand.New(src)
+	}
+
+	return g, nil
 }
 
 // Next returns the random UUID v4 value.
 func (g *UUIDV4Generator) Next() interface{} {
-	id, err := uuid.NewRandom()
+	var id uuid.UUID
+	var err error
+	if g.rnd != nil {
+		id, err = uuid.NewRandomFromReader(g.rnd)
+	} else {
+		id, err = uuid.NewRandom()
+	}
 	if err != nil {
 		panic(fmt.Sprintf("unexpected UUID v4 generation error: %v", err))
 	}
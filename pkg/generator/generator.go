@@ -22,6 +22,7 @@ import (
 	"cloud.google.com/go/spanner/spansql"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/data"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/seed"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
 )
 
@@ -33,7 +34,8 @@ const (
 // TODO: Handle static value generator (table samples)
 // TODO: Handle random string generator vs ranged string generation
 
-func GetDataGeneratorMap(cfg config.Config, s schema.Schema) (map[string]data.GeneratorMap, error) {
+// GetDataGeneratorMap returns a generator map for every table in the schema, seeded for the given phase and worker
+func GetDataGeneratorMap(cfg *config.Config, s schema.Schema, phase string, worker int) (map[string]data.GeneratorMap, error) {
 	tables := s.Tables()
 	ret := make(map[string]data.GeneratorMap, tables.Len())
 
@@ -41,7 +43,7 @@ func GetDataGeneratorMap(cfg config.Config, s schema.Schema) (map[string]data.Ge
 	for tables.HasNext() {
 		t := tables.GetNext()
 
-		gm, err := GetDataGeneratorMapForTable(cfg, t)
+		gm, err := GetDataGeneratorMapForTable(cfg, t, phase, worker)
 		if err != nil {
 			return nil, fmt.Errorf("error getting generator map for table '%s': %s", t.Name(), err.Error())
 		}
@@ -54,9 +56,14 @@ func GetDataGeneratorMap(cfg config.Config, s schema.Schema) (map[string]data.Ge
 	return ret, nil
 }
 
+// GetDataGeneratorMapForTable returns a generator map for the table. Each column generator draws from its own
+// source derived from the run seed, the table, the phase (load or run), the worker and the column, so that
+// every worker produces a distinct but reproducible stream of rows. Including the phase keeps the rows
+// inserted by a run from colliding with the rows loaded with the same seed. When the workload is
+// distributed, keys are generated within the key range of the partition, see GetKeyRange.
 // TODO: Check that schema column and config column are compatible types
 // TODO: Check that generator config and column type are compatible types
-func GetDataGeneratorMapForTable(cfg config.Config, t schema.Table) (data.GeneratorMap, error) {
+func GetDataGeneratorMapForTable(cfg *config.Config, t schema.Table, phase string, worker int) (data.GeneratorMap, error) {
 	cols := t.Columns()
 	gm := make(data.GeneratorMap, cols.Len())
 
@@ -67,23 +74,30 @@ func GetDataGeneratorMapForTable(cfg config.Config, t schema.Table) (data.Genera
 	for cols.HasNext() {
 		col := cols.GetNext()
 		colType := col.Type()
+		src := seed.Source(cfg.Seed, "table", t.Name(), "phase", phase, "worker", seed.Worker(worker), "column", col.Name())
 
 		var g data.Generator
 
 		var gErr error
 		// There is no table/col configs. Use default generators
 		if ct == nil {
-			g, gErr = GetDefaultGeneratorForType(colType, nil)
+			g, gErr = GetDefaultGeneratorForType(colType, sourceConfig(src))
 		} else {
 			// Check if column is in config
 			cc := ct.Column(col.Name())
 
 			// The table is in the config, but it has no column config for this column, use default generators
 			if cc == nil {
-				g, gErr = GetDefaultGeneratorForType(colType, nil)
+				g, gErr = GetDefaultGeneratorForType(colType, sourceConfig(src))
 			} else {
-				// The column is referenced in the configuration... Use it to create a generator
-				g, gErr = GetConfiguredGenerator(colType, cc)
+				// The column is referenced in the configuration... Use it to create a generator.
+				// A seed in the generator config block replaces the run seed for this column. It is still
+				// combined with the worker, otherwise every worker would generate the same values.
+				if cc.Generator != nil && cc.Generator.Seed != nil {
+					src = seed.Source(*cc.Generator.Seed, "phase", phase, "worker", seed.Worker(worker))
+				}
+
+				g, gErr = GetConfiguredGenerator(colType, cc, src)
 			}
 		}
 
@@ -108,31 +122,36 @@ func GetDataGeneratorMapForTable(cfg config.Config, t schema.Table) (data.Genera
 	// Reset the schema columns iterator for future use
 	cols.ResetIterator()
 
+	// Keep the keys of a distributed workload in the range of this partition
+	kr, err := GetKeyRange(cfg, t)
+	if err != nil {
+		return nil, err
+	}
+	if kr != nil {
+		gm[kr.Column] = kr.Constrain(gm[kr.Column])
+	}
+
 	return gm, nil
 }
 
-func GetConfiguredGenerator(t spansql.Type, col *config.Column) (data.Generator, error) {
+// GetConfiguredGenerator assembles a generator from a column config. Values are drawn from src.
+func GetConfiguredGenerator(t spansql.Type, col *config.Column, src rand.Source) (data.Generator, error) {
 	// The column is referenced in the config file but has no generator config. Use a default
 	if col.Generator == nil {
-		return GetDefaultGeneratorForType(t, nil)
+		return GetDefaultGeneratorForType(t, sourceConfig(src))
 	}
 
 	var g data.Generator
 	var err error
 
-	cfg := data.NewConfig()
-
-	// If the generator config block has a seed, use it as our source
-	if col.Generator.Seed != nil {
-		cfg.SetSource(rand.NewSource(*col.Generator.Seed))
-	}
+	cfg := sourceConfig(src)
 
 	if col.Generator.Length != nil {
 		cfg.SetLength(*col.Generator.Length)
 	}
 
 	if col.Generator.Type != nil && *col.Generator.Type == generatorTypeUUIDV4 {
-		return data.NewUUIDV4Generator(t.Base, t.Len)
+		return data.NewUUIDV4GeneratorWithSource(t.Base, t.Len, cfg.Source())
 	}
 
 	// If there are multiple ranges, assemble a sub range generator that
@@ -151,8 +170,9 @@ func GetConfiguredGenerator(t spansql.Type, col *config.Column) (data.Generator,
 
 		// Iterate over each range in the generator config
 		for _, r := range col.Generator.Range {
-			// Copy the config
+			// Copy the config and give the range its own source, split off from the column source
 			cpCfg := cfg.Copy()
+			cpCfg.SetSource(rand.NewSource(cfg.Source().Int63()))
 
 			// Set the copies settings based off the current range
 			SetDataConfigFromRange(cpCfg, r)
@@ -206,7 +226,7 @@ func GetDefaultGeneratorForType(t spansql.Type, cfg data.Config) (data.Generator
 	case spansql.String:
 		// Infer UUID v4 pattern from the column length.
 		if t.Len == uuidV4Length {
-			g, err = data.NewUUIDV4Generator(t.Base, t.Len)
+			g, err = data.NewUUIDV4GeneratorWithSource(t.Base, t.Len, cfg.Source())
 			break
 		}
 
@@ -251,6 +271,16 @@ func GetDefaultGeneratorForType(t spansql.Type, cfg data.Config) (data.Generator
 	return g, err
 }
 
+// sourceConfig returns a data.Config that draws values from src. A nil src leaves the default source in place.
+func sourceConfig(src rand.Source) data.Config {
+	cfg := data.NewConfig()
+	if src != nil {
+		cfg.SetSource(src)
+	}
+
+	return cfg
+}
+
 // SetDataConfigFromRange will set values from a range in the data.Config if they're defined
 func SetDataConfigFromRange(cpCfg data.Config, r *config.Range) {
 	if r.Begin != nil {
@@ -66,7 +66,7 @@ func TestGenerator(t *testing.T) {
 		foo.AddColumn(bar)
 		foo.AddColumn(baz)
 
-		gmap, err := GetDataGeneratorMapForTable(cfg, foo)
+		gmap, err := GetDataGeneratorMapForTable(&cfg, foo, "load", 0)
 		So(err, ShouldBeNil)
 		So(gmap, ShouldNotBeNil)
 
@@ -84,4 +84,59 @@ func TestGenerator(t *testing.T) {
 		So(ok, ShouldBeTrue)
 		So(intBazVal, ShouldBeBetween, 10, 100)
 	})
+
+	Convey("Seeded generator maps", t, func() {
+		cfg := config.Config{Seed: 42}
+
+		tbl := schema.NewTable()
+		tbl.SetName("seeded")
+		for name, typ := range map[string]string{
+			"id":      "STRING(36)",
+			"name":    "STRING(64)",
+			"count":   "INT64",
+			"score":   "FLOAT64",
+			"created": "TIMESTAMP",
+			"day":     "DATE",
+			"blob":    "BYTES(16)",
+			"tags":    "ARRAY<STRING(8)>",
+		} {
+			c := schema.NewColumn()
+			c.SetName(name)
+			c.SetSpannerType(typ)
+			tbl.AddColumn(c)
+		}
+
+		rows := func(phase string, worker int) []map[string]interface{} {
+			gmap, err := GetDataGeneratorMapForTable(&cfg, tbl, phase, worker)
+			So(err, ShouldBeNil)
+
+			ret := make([]map[string]interface{}, 0, 10)
+			for i := 0; i < 10; i++ {
+				row := make(map[string]interface{}, len(gmap))
+				for k, g := range gmap {
+					row[k] = g.Next()
+				}
+				ret = append(ret, row)
+			}
+			return ret
+		}
+
+		Convey("Same seed and worker produce the same rows", func() {
+			So(rows("load", 0), ShouldResemble, rows("load", 0))
+		})
+
+		Convey("Workers produce different rows", func() {
+			So(rows("load", 0), ShouldNotResemble, rows("load", 1))
+		})
+
+		Convey("Phases produce different rows", func() {
+			So(rows("load", 0), ShouldNotResemble, rows("run", 0))
+		})
+
+		Convey("Seed changes the rows", func() {
+			a := rows("load", 0)
+			cfg.Seed = 43
+			So(rows("load", 0), ShouldNotResemble, a)
+		})
+	})
 }
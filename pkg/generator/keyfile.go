@@ -0,0 +1,362 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+)
+
+// Key files are JSON lines. The first line is a header describing the table and its primary key
+// columns. Every following line is a JSON array holding one primary key, encoded per column type:
+//
+//	BOOL      true / false
+//	INT64     decimal string (avoids float64 precision loss)
+//	FLOAT64   number, or "NaN", "Infinity", "-Infinity"
+//	STRING    string
+//	BYTES     base64 string
+//	TIMESTAMP RFC 3339 string with nanoseconds
+//	DATE      YYYY-MM-DD string
+//	NUMERIC   decimal string with 9 fractional digits
+//	JSON      JSON value
+
+const (
+	// NUMERIC columns have a scale of 9
+	numericScale = 9
+
+	// Maximum length of a key file line
+	maxKeyLineLen = 10 * 1024 * 1024
+)
+
+type (
+	// KeyFileHeader is the first line of a key file
+	KeyFileHeader struct {
+		Table   string          `json:"table"`
+		Columns []KeyFileColumn `json:"columns"`
+	}
+
+	// KeyFileColumn describes one primary key column in a key file
+	KeyFileColumn struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+)
+
+// WriteKeyFile writes the samples returned by SampleTable to w and returns the number of keys written
+func WriteKeyFile(w io.Writer, table schema.Table, samples map[string]interface{}) (int, error) {
+	pkeys := table.PrimaryKeys().Columns()
+	if len(pkeys) <= 0 {
+		return 0, fmt.Errorf("cannot find primary key(s) for table '%s'", table.Name())
+	}
+
+	header := KeyFileHeader{
+		Table:   table.Name(),
+		Columns: make([]KeyFileColumn, 0, len(pkeys)),
+	}
+
+	cols := make([]reflect.Value, 0, len(pkeys))
+	l := -1
+	for _, pkey := range pkeys {
+		header.Columns = append(header.Columns, KeyFileColumn{
+			Name: pkey.Name(),
+			Type: pkey.Type().SQL(),
+		})
+
+		s, ok := samples[pkey.Name()]
+		if !ok {
+			return 0, fmt.Errorf("samples are missing primary key column '%s'", pkey.Name())
+		}
+
+		v := reflect.ValueOf(s)
+		if v.Kind() != reflect.Slice {
+			return 0, fmt.Errorf("sample for column '%s' is not a slice", pkey.Name())
+		}
+
+		if l >= 0 && v.Len() != l {
+			return 0, fmt.Errorf("samples for composite primary keys must be of equal length (%s column mismatch)", pkey.Name())
+		}
+
+		l = v.Len()
+		cols = append(cols, v)
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	err := enc.Encode(header)
+	if err != nil {
+		return 0, fmt.Errorf("writing key file header: %s", err.Error())
+	}
+
+	line := make([]interface{}, len(pkeys))
+	for i := 0; i < l; i++ {
+		for c, pkey := range pkeys {
+			line[c], err = encodeKeyValue(pkey.Type().Base, cols[c].Index(i).Interface())
+			if err != nil {
+				return i, fmt.Errorf("encoding column '%s': %s", pkey.Name(), err.Error())
+			}
+		}
+
+		err = enc.Encode(line)
+		if err != nil {
+			return i, fmt.Errorf("writing key: %s", err.Error())
+		}
+	}
+
+	return l, bw.Flush()
+}
+
+// ReadKeyFile reads a key file written by WriteKeyFile and returns it in the same format as SampleTable.
+// Only keys in the range within are loaded, all keys if it is nil. If max > 0, only the first max keys are loaded.
+func ReadKeyFile(r io.Reader, table schema.Table, max int, within *KeyRange) (map[string]interface{}, error) {
+	pkeys := table.PrimaryKeys().Columns()
+	if len(pkeys) <= 0 {
+		return nil, fmt.Errorf("cannot find primary key(s) for table '%s'", table.Name())
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxKeyLineLen)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading key file header: %s", err.Error())
+		}
+		return nil, fmt.Errorf("key file is empty")
+	}
+
+	var header KeyFileHeader
+	err := json.Unmarshal(scanner.Bytes(), &header)
+	if err != nil {
+		return nil, fmt.Errorf("decoding key file header: %s", err.Error())
+	}
+
+	err = header.validate(table, pkeys)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]spanner.Key, 0)
+	lineNo := 1
+	for scanner.Scan() {
+		lineNo++
+		if max > 0 && len(keys) >= max {
+			break
+		}
+
+		var raw []json.RawMessage
+		err := json.Unmarshal(scanner.Bytes(), &raw)
+		if err != nil {
+			return nil, fmt.Errorf("decoding key on line %d: %s", lineNo, err.Error())
+		}
+
+		if len(raw) != len(pkeys) {
+			return nil, fmt.Errorf("key on line %d has %d values, expected %d", lineNo, len(raw), len(pkeys))
+		}
+
+		k := make(spanner.Key, 0, len(pkeys))
+		for c, pkey := range pkeys {
+			v, err := decodeKeyValue(pkey.Type().Base, raw[c])
+			if err != nil {
+				return nil, fmt.Errorf("decoding column '%s' on line %d: %s", pkey.Name(), lineNo, err.Error())
+			}
+
+			k = append(k, v)
+		}
+
+		if within.Contains(k) {
+			keys = append(keys, k)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading key file: %s", err.Error())
+	}
+
+	return keysToSamples(keys, pkeys), nil
+}
+
+// validate checks that the key file was written for the given table and primary key
+func (h KeyFileHeader) validate(table schema.Table, pkeys []schema.Column) error {
+	if h.Table != table.Name() {
+		return fmt.Errorf("key file was written for table '%s', not '%s'", h.Table, table.Name())
+	}
+
+	if len(h.Columns) != len(pkeys) {
+		return fmt.Errorf("key file has %d primary key columns, table '%s' has %d", len(h.Columns), table.Name(), len(pkeys))
+	}
+
+	for i, pkey := range pkeys {
+		if h.Columns[i].Name != pkey.Name() || h.Columns[i].Type != pkey.Type().SQL() {
+			return fmt.Errorf("key file column %d is %s %s, table has %s %s", i, h.Columns[i].Name, h.Columns[i].Type, pkey.Name(), pkey.Type().SQL())
+		}
+	}
+
+	return nil
+}
+
+// encodeKeyValue converts a key value into a JSON friendly value
+func encodeKeyValue(t spansql.TypeBase, v interface{}) (interface{}, error) {
+	switch t {
+	case spansql.Bool:
+		return v, nil
+	case spansql.String:
+		return v, nil
+	case spansql.Int64:
+		i, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("expected int64, got %T", v)
+		}
+		return strconv.FormatInt(i, 10), nil
+	case spansql.Float64:
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected float64, got %T", v)
+		}
+		switch {
+		case math.IsNaN(f):
+			return "NaN", nil
+		case math.IsInf(f, 1):
+			return "Infinity", nil
+		case math.IsInf(f, -1):
+			return "-Infinity", nil
+		}
+		return f, nil
+	case spansql.Bytes:
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("expected []byte, got %T", v)
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
+	case spansql.Timestamp:
+		ts, ok := v.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("expected time.Time, got %T", v)
+		}
+		return ts.UTC().Format(time.RFC3339Nano), nil
+	case spansql.Date:
+		d, ok := v.(civil.Date)
+		if !ok {
+			return nil, fmt.Errorf("expected civil.Date, got %T", v)
+		}
+		return d.String(), nil
+	case spansql.Numeric:
+		n, ok := v.(*big.Rat)
+		if !ok {
+			return nil, fmt.Errorf("expected *big.Rat, got %T", v)
+		}
+		return n.FloatString(numericScale), nil
+	case spansql.JSON:
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type '%s'", t.SQL())
+}
+
+// decodeKeyValue converts a JSON value written by encodeKeyValue back into a spanner key value
+func decodeKeyValue(t spansql.TypeBase, raw json.RawMessage) (interface{}, error) {
+	var err error
+
+	switch t {
+	case spansql.Bool:
+		var b bool
+		err = json.Unmarshal(raw, &b)
+		return b, err
+	case spansql.String:
+		var s string
+		err = json.Unmarshal(raw, &s)
+		return s, err
+	case spansql.Int64:
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case spansql.Float64:
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			switch s {
+			case "NaN":
+				return math.NaN(), nil
+			case "Infinity":
+				return math.Inf(1), nil
+			case "-Infinity":
+				return math.Inf(-1), nil
+			}
+			return nil, fmt.Errorf("invalid FLOAT64 value '%s'", s)
+		}
+		var f float64
+		err = json.Unmarshal(raw, &f)
+		return f, err
+	case spansql.Bytes:
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(s)
+	case spansql.Timestamp:
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case spansql.Date:
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return civil.ParseDate(s)
+	case spansql.Numeric:
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		n, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid NUMERIC value '%s'", s)
+		}
+		return n, nil
+	case spansql.JSON:
+		m := make(map[string]interface{}) // TODO: This needs to be spanner.NullJSON
+		err = json.Unmarshal(raw, &m)
+		return m, err
+	}
+
+	return nil, fmt.Errorf("unsupported type '%s'", t.SQL())
+}
+
+// LoadKeyFile opens the key file at path and reads it with ReadKeyFile
+func LoadKeyFile(path string, table schema.Table, max int, within *KeyRange) (map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening key file: %s", err.Error())
+	}
+	defer f.Close()
+
+	return ReadKeyFile(f, table, max, within)
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func keyFileTable(types ...string) schema.Table {
+	t := schema.NewTable()
+	t.SetName("keys")
+
+	for i, typ := range types {
+		c := schema.NewColumn()
+		c.SetName(strings.Map(func(r rune) rune {
+			if r >= 'A' && r <= 'Z' {
+				return r
+			}
+			return -1
+		}, typ) + string(rune('0'+i)))
+		c.SetSpannerType(typ)
+		c.SetPrimaryKey(true)
+		t.AddColumn(c)
+	}
+
+	return t
+}
+
+func TestKeyFile(t *testing.T) {
+	Convey("KeyFile", t, func() {
+		table := keyFileTable("BOOL", "INT64", "FLOAT64", "STRING(MAX)", "BYTES(MAX)", "TIMESTAMP", "DATE", "NUMERIC")
+		names := table.PrimaryKeyNames()
+
+		ts := time.Date(2022, 3, 4, 5, 6, 7, 123456789, time.UTC)
+		samples := map[string]interface{}{
+			names[0]: []bool{true, false, true},
+			names[1]: []int64{math.MaxInt64, math.MinInt64, 0},
+			names[2]: []float64{1.5, math.Inf(-1), math.NaN()},
+			names[3]: []string{"a", "\"quoted\"\n", ""},
+			names[4]: [][]byte{{0x00, 0xff}, []byte("bytes"), {}},
+			names[5]: []time.Time{ts, ts.Add(time.Hour), time.Unix(0, 0).UTC()},
+			names[6]: []civil.Date{{Year: 2022, Month: 1, Day: 2}, {Year: 1, Month: 1, Day: 1}, {Year: 9999, Month: 12, Day: 31}},
+			names[7]: []*big.Rat{big.NewRat(1, 3), big.NewRat(-5, 2), new(big.Rat).SetInt64(99999999999)},
+		}
+
+		Convey("Round trip", func() {
+			var buf bytes.Buffer
+			n, err := WriteKeyFile(&buf, table, samples)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 3)
+
+			got, err := ReadKeyFile(&buf, table, 0, nil)
+			So(err, ShouldBeNil)
+
+			So(got[names[0]], ShouldResemble, samples[names[0]])
+			So(got[names[1]], ShouldResemble, samples[names[1]])
+			So(got[names[3]], ShouldResemble, samples[names[3]])
+			So(got[names[4]], ShouldResemble, samples[names[4]])
+			So(got[names[6]], ShouldResemble, samples[names[6]])
+
+			floats := got[names[2]].([]float64)
+			So(floats[0], ShouldEqual, 1.5)
+			So(math.IsInf(floats[1], -1), ShouldBeTrue)
+			So(math.IsNaN(floats[2]), ShouldBeTrue)
+
+			times := got[names[5]].([]time.Time)
+			for i, want := range samples[names[5]].([]time.Time) {
+				So(times[i].Equal(want), ShouldBeTrue)
+			}
+
+			// NUMERIC has a scale of 9, so 1/3 is truncated to 0.333333333
+			nums := got[names[7]].([]*big.Rat)
+			So(nums[0].FloatString(9), ShouldEqual, "0.333333333")
+			So(nums[1].Cmp(big.NewRat(-5, 2)), ShouldEqual, 0)
+			So(nums[2].Cmp(new(big.Rat).SetInt64(99999999999)), ShouldEqual, 0)
+		})
+
+		Convey("Max keys", func() {
+			var buf bytes.Buffer
+			_, err := WriteKeyFile(&buf, table, samples)
+			So(err, ShouldBeNil)
+
+			got, err := ReadKeyFile(&buf, table, 2, nil)
+			So(err, ShouldBeNil)
+			So(got[names[1]], ShouldResemble, []int64{math.MaxInt64, math.MinInt64})
+		})
+
+		Convey("Mismatched table", func() {
+			var buf bytes.Buffer
+			_, err := WriteKeyFile(&buf, table, samples)
+			So(err, ShouldBeNil)
+
+			_, err = ReadKeyFile(&buf, keyFileTable("INT64"), 0, nil)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Empty file", func() {
+			_, err := ReadKeyFile(strings.NewReader(""), table, 0, nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
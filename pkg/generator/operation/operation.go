@@ -16,7 +16,6 @@ package operation
 
 import (
 	"math/rand"
-	"time"
 
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/selector"
@@ -27,14 +26,21 @@ type (
 )
 
 const (
-	READ Operation = 1 + iota
-	WRITE
+	READ              Operation = 1 + iota // Point read of an existing row
+	WRITE                                  // Insert of a new row
+	UPDATE                                 // Update of an existing row
+	SCAN                                   // Range read starting at an existing row
+	READ_MODIFY_WRITE                      // Read and update of an existing row in one transaction
 )
 
-func NewOperationSelector(cfg *config.Config) (selector.Selector, error) {
+// NewOperationSelector returns a selector choosing between the operations using the configured weights
+func NewOperationSelector(cfg *config.Config, src rand.Source) (selector.Selector, error) {
 	return selector.NewWeightedRandomSelector(
-		rand.New(rand.NewSource(time.Now().UnixNano())),
+		rand.New(src),
 		selector.NewWeightedChoice(READ, uint(cfg.Operations.Read)),
 		selector.NewWeightedChoice(WRITE, uint(cfg.Operations.Write)),
+		selector.NewWeightedChoice(UPDATE, uint(cfg.Operations.Update)),
+		selector.NewWeightedChoice(SCAN, uint(cfg.Operations.Scan)),
+		selector.NewWeightedChoice(READ_MODIFY_WRITE, uint(cfg.Operations.ReadModifyWrite)),
 	)
 }
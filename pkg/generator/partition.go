@@ -0,0 +1,314 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/data"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+)
+
+const (
+	// First characters of generated strings and string UUIDs in key order
+	letterAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	hexAlphabet    = "0123456789abcdef"
+)
+
+// Assert that partitionGenerator implements Generator
+var _ data.Generator = (*partitionGenerator)(nil)
+
+type (
+	// KeyRange is the part of the key space of a table owned by one partition of a distributed
+	// workload. It bounds the first primary key column, Begin is inclusive and End exclusive. The
+	// first partition has no Begin and the last no End, so that every key of the table belongs to
+	// exactly one partition.
+	KeyRange struct {
+		Column string
+		Begin  interface{} // nil if unbounded
+		End    interface{} // nil if unbounded
+
+		compare   func(a, b interface{}) int
+		constrain func(v interface{}) interface{} // Maps a generated value into the range
+	}
+
+	// partitionGenerator maps the values of a key generator into a key range
+	partitionGenerator struct {
+		g         data.Generator
+		constrain func(v interface{}) interface{}
+	}
+)
+
+// GetKeyRange returns the key range of table t owned by the partition of cfg, or nil if the
+// workload is not distributed. Ranges split the values the generator of the first primary key
+// column produces, so that every process of a distributed workload derives the same disjoint
+// ranges from the configuration: INT64 keys by the range of the generator, STRING and BYTES keys
+// by their first character or byte.
+func GetKeyRange(cfg *config.Config, t schema.Table) (*KeyRange, error) {
+	p := cfg.Partition
+	if p.Count <= 1 {
+		return nil, nil
+	}
+
+	pkeys := t.PrimaryKeys().Columns()
+	if len(pkeys) <= 0 {
+		return nil, fmt.Errorf("cannot find primary key(s) for table '%s'", t.Name())
+	}
+	col := pkeys[0]
+
+	var gen *config.Generator
+	if ct := cfg.Table(t.Name()); ct != nil {
+		if cc := ct.Column(col.Name()); cc != nil {
+			gen = cc.Generator
+		}
+	}
+
+	var r *KeyRange
+	var err error
+	switch {
+	case col.AllowCommitTimestamp():
+		err = errors.New("commit timestamps can not be partitioned")
+	case col.Type().Base == spansql.Int64:
+		r, err = int64KeyRange(p, gen)
+	case col.Type().Base == spansql.String:
+		alphabet := letterAlphabet
+		if col.Type().Len == uuidV4Length || (gen != nil && gen.Type != nil && *gen.Type == generatorTypeUUIDV4) {
+			alphabet = hexAlphabet
+		}
+		r, err = prefixKeyRange(p, alphabet, false)
+	case col.Type().Base == spansql.Bytes:
+		alphabet := make([]byte, math.MaxUint8+1)
+		for i := range alphabet {
+			alphabet[i] = byte(i)
+		}
+		r, err = prefixKeyRange(p, string(alphabet), true)
+	default:
+		err = fmt.Errorf("keys of type %s can not be partitioned", col.SpannerType())
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("partitioning the keys of table '%s': %s", t.Name(), err.Error())
+	}
+
+	r.Column = col.Name()
+
+	return r, nil
+}
+
+// int64KeyRange splits the range of an INT64 generator, all positive int64 values by default
+func int64KeyRange(p config.Partition, gen *config.Generator) (*KeyRange, error) {
+	lo, hi := int64(0), int64(math.MaxInt64)
+	if gen != nil && len(gen.Range) > 1 {
+		return nil, errors.New("keys generated from several ranges can not be partitioned")
+	}
+
+	// Like SetDataConfigFromRange, a minimum or maximum constrains the generator to the range
+	if gen != nil && len(gen.Range) == 1 && (gen.Range[0].Minimum != nil || gen.Range[0].Maximum != nil) {
+		var err error
+		lo, err = rangeInt64(gen.Range[0].Minimum)
+		if err != nil {
+			return nil, err
+		}
+
+		hi, err = rangeInt64(gen.Range[0].Maximum)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if hi <= lo {
+		return nil, fmt.Errorf("empty key range [%d, %d)", lo, hi)
+	}
+
+	// Compute in uint64, the span of the range may exceed math.MaxInt64
+	width := (uint64(hi) - uint64(lo)) / uint64(p.Count)
+	if width == 0 {
+		return nil, fmt.Errorf("key range [%d, %d) is too small for %d partitions", lo, hi, p.Count)
+	}
+
+	begin := int64(uint64(lo) + width*uint64(p.Index))
+	end := int64(uint64(begin) + width)
+	if p.Index == p.Count-1 {
+		end = hi
+	}
+
+	r := &KeyRange{
+		compare: func(a, b interface{}) int {
+			x, y := a.(int64), b.(int64)
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		},
+		constrain: func(v interface{}) interface{} {
+			offset := (uint64(v.(int64)) - uint64(lo)) % (uint64(end) - uint64(begin))
+			return int64(uint64(begin) + offset)
+		},
+	}
+
+	if p.Index > 0 {
+		r.Begin = begin
+	}
+	if p.Index < p.Count-1 {
+		r.End = end
+	}
+
+	return r, nil
+}
+
+// rangeInt64 returns a minimum or maximum of an INT64 generator range. Unset bounds are 0
+func rangeInt64(v *interface{}) (int64, error) {
+	if v == nil {
+		return 0, nil
+	}
+
+	switch x := (*v).(type) {
+	case int:
+		return int64(x), nil
+	case int64:
+		return x, nil
+	}
+
+	return 0, fmt.Errorf("bound '%v' of type '%T' invalid for int64 keys", *v, *v)
+}
+
+// prefixKeyRange splits the first characters of STRING keys, or the first bytes of BYTES keys,
+// that a generator draws from alphabet. alphabet must be in key order.
+func prefixKeyRange(p config.Partition, alphabet string, isBytes bool) (*KeyRange, error) {
+	n := len(alphabet)
+	if p.Count > n {
+		return nil, fmt.Errorf("can not split %d first characters among %d partitions", n, p.Count)
+	}
+
+	a := p.Index * n / p.Count
+	b := (p.Index + 1) * n / p.Count
+
+	// Replace the first character with one of the range. Characters outside of the alphabet
+	// are mapped by their byte value.
+	first := func(c byte) byte {
+		i := strings.IndexByte(alphabet, c)
+		if i < 0 {
+			i = int(c)
+		}
+
+		return alphabet[a+i%(b-a)]
+	}
+
+	r := &KeyRange{}
+	if isBytes {
+		r.compare = func(x, y interface{}) int { return bytes.Compare(x.([]byte), y.([]byte)) }
+		r.constrain = func(v interface{}) interface{} {
+			s := v.([]byte)
+			if len(s) > 0 {
+				s[0] = first(s[0])
+			}
+			return s
+		}
+		if p.Index > 0 {
+			r.Begin = []byte{alphabet[a]}
+		}
+		if p.Index < p.Count-1 {
+			r.End = []byte{alphabet[b]}
+		}
+	} else {
+		r.compare = func(x, y interface{}) int { return strings.Compare(x.(string), y.(string)) }
+		r.constrain = func(v interface{}) interface{} {
+			s := v.(string)
+			if len(s) > 0 {
+				s = string(first(s[0])) + s[1:]
+			}
+			return s
+		}
+		if p.Index > 0 {
+			r.Begin = alphabet[a : a+1]
+		}
+		if p.Index < p.Count-1 {
+			r.End = alphabet[b : b+1]
+		}
+	}
+
+	return r, nil
+}
+
+// Contains reports whether the key k is in the range. A nil *KeyRange contains every key.
+func (r *KeyRange) Contains(k spanner.Key) bool {
+	if r == nil || len(k) == 0 {
+		return true
+	}
+
+	if r.Begin != nil && r.compare(k[0], r.Begin) < 0 {
+		return false
+	}
+
+	return r.End == nil || r.compare(k[0], r.End) < 0
+}
+
+// Constrain returns a generator that maps the values of g into the range. It returns g if r is nil.
+func (r *KeyRange) Constrain(g data.Generator) data.Generator {
+	if r == nil || g == nil {
+		return g
+	}
+
+	return &partitionGenerator{g: g, constrain: r.constrain}
+}
+
+// String describes the range as a condition on the key column
+func (r *KeyRange) String() string {
+	if r == nil {
+		return "all keys"
+	}
+
+	conds := make([]string, 0, 2)
+	if r.Begin != nil {
+		conds = append(conds, fmt.Sprintf("%s >= %s", r.Column, formatKeyBound(r.Begin)))
+	}
+	if r.End != nil {
+		conds = append(conds, fmt.Sprintf("%s < %s", r.Column, formatKeyBound(r.End)))
+	}
+	if len(conds) == 0 {
+		return "all keys"
+	}
+
+	return strings.Join(conds, " AND ")
+}
+
+func formatKeyBound(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", x)
+	case []byte:
+		return fmt.Sprintf("b\"\\x%02x\"", x)
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+func (g *partitionGenerator) Next() interface{} {
+	return g.constrain(g.g.Next())
+}
+
+func (g *partitionGenerator) Type() spansql.TypeBase {
+	return g.g.Type()
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// partitionTable returns a table keyed by a single column of type typ
+func partitionTable(typ string) schema.Table {
+	t := schema.NewTable()
+	t.SetName("t")
+
+	c := schema.NewColumn()
+	c.SetName("k")
+	c.SetSpannerType(typ)
+	c.SetPrimaryKey(true)
+	t.AddColumn(c)
+
+	return t
+}
+
+// partitionRanges returns the key ranges of count partitions and keys generated by each of them
+func partitionRanges(cfg *config.Config, t schema.Table, count int) ([]*KeyRange, [][]interface{}) {
+	ranges := make([]*KeyRange, count)
+	keys := make([][]interface{}, count)
+	for i := 0; i < count; i++ {
+		cfg.Partition = config.Partition{Index: i, Count: count}
+
+		r, err := GetKeyRange(cfg, t)
+		So(err, ShouldBeNil)
+		ranges[i] = r
+
+		gm, err := GetDataGeneratorMapForTable(cfg, t, "load", 0)
+		So(err, ShouldBeNil)
+		for j := 0; j < 200; j++ {
+			keys[i] = append(keys[i], gm["k"].Next())
+		}
+	}
+
+	return ranges, keys
+}
+
+// shouldOwnTheirKeys checks that the keys of every partition are in its range and no other
+func shouldOwnTheirKeys(ranges []*KeyRange, keys [][]interface{}) {
+	for i := range keys {
+		for j, r := range ranges {
+			for _, k := range keys[i] {
+				So(r.Contains(spanner.Key{k}), ShouldEqual, i == j)
+			}
+		}
+	}
+}
+
+func TestKeyRange(t *testing.T) {
+	Convey("GetKeyRange", t, func() {
+		Convey("A single process owns every key", func() {
+			r, err := GetKeyRange(&config.Config{Partition: config.Partition{Count: 1}}, partitionTable("INT64"))
+			So(err, ShouldBeNil)
+			So(r, ShouldBeNil)
+			So(r.Contains(spanner.Key{int64(1)}), ShouldBeTrue)
+		})
+
+		Convey("INT64 keys split the range of the generator", func() {
+			ranges, keys := partitionRanges(&config.Config{}, partitionTable("INT64"), 4)
+			shouldOwnTheirKeys(ranges, keys)
+			So(ranges[0].Begin, ShouldBeNil)
+			So(ranges[3].End, ShouldBeNil)
+			So(ranges[0].End, ShouldEqual, ranges[1].Begin)
+
+			var min, max interface{} = 10, 110
+			cfg := &config.Config{Tables: []config.Table{{Name: "t", Columns: []config.Column{{
+				Name:      "k",
+				Generator: &config.Generator{Range: []*config.Range{{Minimum: &min, Maximum: &max}}},
+			}}}}}
+			ranges, keys = partitionRanges(cfg, partitionTable("INT64"), 4)
+			shouldOwnTheirKeys(ranges, keys)
+			So(ranges[1].Begin, ShouldEqual, int64(35))
+			So(ranges[1].String(), ShouldEqual, "k >= 35 AND k < 60")
+		})
+
+		Convey("STRING keys split the first character", func() {
+			ranges, keys := partitionRanges(&config.Config{}, partitionTable("STRING(16)"), 3)
+			shouldOwnTheirKeys(ranges, keys)
+			So(ranges[1].String(), ShouldEqual, `k >= "R" AND k < "i"`)
+
+			// Keys that were not generated belong to the first or last partition
+			So(ranges[0].Contains(spanner.Key{"0"}), ShouldBeTrue)
+			So(ranges[2].Contains(spanner.Key{"~"}), ShouldBeTrue)
+		})
+
+		Convey("UUID keys split the first hex digit", func() {
+			ranges, keys := partitionRanges(&config.Config{}, partitionTable("STRING(36)"), 4)
+			shouldOwnTheirKeys(ranges, keys)
+			So(ranges[1].Begin, ShouldEqual, "4")
+		})
+
+		Convey("BYTES keys split the first byte", func() {
+			ranges, keys := partitionRanges(&config.Config{}, partitionTable("BYTES(8)"), 2)
+			shouldOwnTheirKeys(ranges, keys)
+			So(ranges[1].Begin, ShouldResemble, []byte{0x80})
+		})
+
+		Convey("Other key types can not be partitioned", func() {
+			_, err := GetKeyRange(&config.Config{Partition: config.Partition{Index: 0, Count: 2}}, partitionTable("DATE"))
+			So(err, ShouldNotBeNil)
+
+			_, err = GetKeyRange(&config.Config{Partition: config.Partition{Index: 0, Count: 100}}, partitionTable("STRING(16)"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("keyReservoir keeps keys of its range", t, func() {
+		cfg := &config.Config{Partition: config.Partition{Index: 1, Count: 2}}
+		r, err := GetKeyRange(cfg, partitionTable("STRING(16)"))
+		So(err, ShouldBeNil)
+
+		res := newKeyReservoir(nil, 0)
+		res.within = r
+		for _, k := range []string{"Apple", "Zebra", "apple", "zebra"} {
+			res.Offer(spanner.Key{k})
+		}
+		So(res.keys, ShouldResemble, []spanner.Key{{"apple"}, {"zebra"}})
+	})
+}
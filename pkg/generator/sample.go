@@ -16,166 +16,438 @@ package generator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"math/big"
 	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"cloud.google.com/go/civil"
 	"cloud.google.com/go/spanner"
 	"cloud.google.com/go/spanner/spansql"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/sample"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/seed"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"google.golang.org/api/iterator"
 )
 
-func SampleTable(cfg *config.Config, ctx context.Context, client *spanner.Client, table schema.Table) (map[string]interface{}, error) {
+const (
+	// How many scanned rows between sampling progress log lines
+	sampleProgressInterval = 100000
+)
+
+var (
+	// errSampleFull is returned from row iterators to stop reading once enough keys are collected
+	errSampleFull = errors.New("sample is full")
+)
+
+type (
+	// keyReservoir keeps a uniform random sample of at most max keys (Algorithm R).
+	// A max <= 0 keeps every key offered to it. Keys outside of within are ignored.
+	keyReservoir struct {
+		mu     sync.Mutex
+		src    *rand.Rand
+		max    int
+		within *KeyRange
+		seen   int64
+		keys   []spanner.Key
+	}
+
+	// sampleProgress logs how many rows have been scanned while sampling a table
+	sampleProgress struct {
+		table   string
+		scanned int64
+		start   time.Time
+	}
+)
+
+// SampleTable will return a map of primary key column name to a slice of sampled values using the
+// sampling strategy from the configuration. The number of keys held in memory is bounded by
+// operations.sampling.max_keys. When the workload is distributed, only keys in the key range of the
+// partition are kept, so that processes do not read and update each others rows.
+func SampleTable(cfg *config.Config, ctx context.Context, client backend.Client, table schema.Table) (map[string]interface{}, error) {
 	// Get primary keys for table
-	pkeys := table.PrimaryKeys()
-	if pkeys.Len() <= 0 {
+	pkeys := table.PrimaryKeys().Columns()
+	if len(pkeys) <= 0 {
 		return nil, fmt.Errorf("cannot find primary key(s) for table '%s'", table.Name())
 	}
 
-	ret := make(map[string]interface{}, pkeys.Len())
-	for pkeys.HasNext() {
-		pkey := pkeys.GetNext()
-		pt := pkey.Type().Base
+	kr, err := GetKeyRange(cfg, table)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := cfg.Operations.Sampling
+	src := rand.New(seed.Source(cfg.Seed, "table", table.Name(), "sample"))
+	res := newKeyReservoir(src, sc.MaxKeys)
+	res.within = kr
+	progress := &sampleProgress{table: table.Name(), start: time.Now()}
+
+	switch sc.Strategy {
+	case config.SamplingReservoir:
+		log.Printf("Sampling table '%s' (RESERVOIR %d ROWS, max keys %d)", table.Name(), sc.Rows, sc.MaxKeys)
+		var stmt string
+		stmt, err = table.TableSampleRows(sc.Rows)
+		if err != nil {
+			return nil, err
+		}
+
+		err = sampleQuery(ctx, client.Single(), stmt, pkeys, res, progress)
+	case config.SamplingPartitioned:
+		log.Printf("Sampling table '%s' (partitioned key ranges, max keys %d)", table.Name(), sc.MaxKeys)
+		err = samplePartitioned(ctx, client, table, pkeys, sc, res, progress)
+	case config.SamplingStratified:
+		log.Printf("Sampling table '%s' (stratified across partitions, max keys %d)", table.Name(), sc.MaxKeys)
+		err = sampleStratified(ctx, client, table, pkeys, sc, cfg.Threads, res, progress)
+	default:
+		log.Printf("Sampling table '%s' (BERNOULLI %f PERCENT, max keys %d)", table.Name(), cfg.Operations.SampleSize, sc.MaxKeys)
+		var stmt string
+		stmt, err = table.TableSample(cfg.Operations.SampleSize)
+		if err != nil {
+			return nil, err
+		}
+
+		err = sampleQuery(ctx, client.Single(), stmt, pkeys, res, progress)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error fetching results from table sample: %s", err.Error())
+	}
+
+	log.Printf("Sampled table '%s': scanned %d rows, kept %d keys in %s", table.Name(), progress.Scanned(), len(res.keys), time.Since(progress.start))
+
+	return keysToSamples(res.keys, pkeys), nil
+}
+
+// samplePartitioned reads whole key ranges from randomly ordered partitions until the reservoir is full
+func samplePartitioned(ctx context.Context, client backend.Client, table schema.Table, pkeys []schema.Column, sc config.Sampling, res *keyReservoir, progress *sampleProgress) error {
+	txn, partitions, err := partitionKeys(ctx, client, table, sc)
+	if err != nil {
+		return err
+	}
+	defer txn.Cleanup(ctx)
+
+	res.src.Shuffle(len(partitions), func(i, j int) {
+		partitions[i], partitions[j] = partitions[j], partitions[i]
+	})
+
+	for i, p := range partitions {
+		if res.Full() {
+			break
+		}
+
+		err := iterateKeys(txn.Execute(ctx, p), pkeys, progress, func(k spanner.Key) error {
+			if res.Full() {
+				return errSampleFull
+			}
+
+			res.Offer(k)
+			return nil
+		})
+		if err != nil && err != errSampleFull {
+			return err
+		}
+
+		log.Printf("Sampling table '%s': finished partition %d/%d (%d keys kept)", table.Name(), i+1, len(partitions), res.Len())
+	}
+
+	return nil
+}
+
+// sampleStratified reads every partition and keeps an equal share of keys from each
+func sampleStratified(ctx context.Context, client backend.Client, table schema.Table, pkeys []schema.Column, sc config.Sampling, threads int, res *keyReservoir, progress *sampleProgress) error {
+	txn, partitions, err := partitionKeys(ctx, client, table, sc)
+	if err != nil {
+		return err
+	}
+	defer txn.Cleanup(ctx)
+
+	if len(partitions) == 0 {
+		return nil
+	}
+
+	quota := 0
+	if sc.MaxKeys > 0 {
+		quota = sc.MaxKeys / len(partitions)
+		if quota < 1 {
+			quota = 1
+		}
+	}
+
+	if threads < 1 {
+		threads = 1
+	}
+
+	var wg sync.WaitGroup
+	var done int64
+	errs := make(chan error, len(partitions))
+	work := make(chan *spanner.Partition)
+
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func(workerSeed int64) {
+			defer wg.Done()
+
+			for p := range work {
+				stratum := newKeyReservoir(rand.New(rand.NewSource(workerSeed)), quota)
+				stratum.within = res.within
+				err := iterateKeys(txn.Execute(ctx, p), pkeys, progress, func(k spanner.Key) error {
+					stratum.Offer(k)
+					return nil
+				})
+				if err != nil {
+					errs <- err
+					continue
+				}
+
+				for _, k := range stratum.keys {
+					res.Offer(k)
+				}
+
+				log.Printf("Sampling table '%s': finished partition %d/%d (%d keys kept)", table.Name(), atomic.AddInt64(&done, 1), len(partitions), res.Len())
+			}
+		}(res.src.Int63())
+	}
+
+	for _, p := range partitions {
+		work <- p
+	}
+	close(work)
+	wg.Wait()
+	close(errs)
+
+	return <-errs // nil if the channel is empty
+}
+
+// partitionKeys creates a batch read only transaction and partitions a primary key query with it
+func partitionKeys(ctx context.Context, client backend.Client, table schema.Table, sc config.Sampling) (*spanner.BatchReadOnlyTransaction, []*spanner.Partition, error) {
+	stmt, err := table.PrimaryKeyQuery()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txn, err := client.BatchReadOnlyTransaction(ctx, spanner.StrongRead())
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating batch read only transaction: %s", err.Error())
+	}
+
+	partitions, err := txn.PartitionQuery(ctx, spanner.NewStatement(stmt), spanner.PartitionOptions{
+		MaxPartitions: int64(sc.Partitions),
+	})
+	if err != nil {
+		txn.Cleanup(ctx)
+		return nil, nil, fmt.Errorf("partitioning key query: %s", err.Error())
+	}
+
+	log.Printf("Sampling table '%s': key space split into %d partitions", table.Name(), len(partitions))
+
+	return txn, partitions, nil
+}
+
+// sampleQuery runs a sampling statement and offers every returned key to the reservoir
+func sampleQuery(ctx context.Context, tx *spanner.ReadOnlyTransaction, stmt string, pkeys []schema.Column, res *keyReservoir, progress *sampleProgress) error {
+	defer tx.Close()
+
+	return iterateKeys(tx.Query(ctx, spanner.NewStatement(stmt)), pkeys, progress, func(k spanner.Key) error {
+		res.Offer(k)
+		return nil
+	})
+}
+
+// iterateKeys decodes the primary key of each row and passes it to f. Returning an error from f stops iteration.
+func iterateKeys(iter *spanner.RowIterator, pkeys []schema.Column, progress *sampleProgress, f func(spanner.Key) error) error {
+	defer iter.Stop()
+
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		k, err := decodeKey(row, pkeys)
+		if err != nil {
+			return err
+		}
+
+		progress.Add(1)
+
+		err = f(k)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// decodeKey reads the primary key columns of a row into a spanner.Key
+func decodeKey(r *spanner.Row, pkeys []schema.Column) (spanner.Key, error) {
+	ret := make(spanner.Key, 0, len(pkeys))
 
-		switch pt {
+	for _, pkey := range pkeys {
+		var val interface{}
+		var err error
+
+		switch pkey.Type().Base {
 		case spansql.Bool:
-			ret[pkey.Name()] = make([]bool, 0)
+			var v bool
+			err = r.ColumnByName(pkey.Name(), &v)
+			val = v
 		case spansql.String:
-			ret[pkey.Name()] = make([]string, 0)
+			var v string
+			err = r.ColumnByName(pkey.Name(), &v)
+			val = v
 		case spansql.Int64:
-			ret[pkey.Name()] = make([]int64, 0)
+			var v int64
+			err = r.ColumnByName(pkey.Name(), &v)
+			val = v
 		case spansql.Float64:
-			ret[pkey.Name()] = make([]float64, 0)
+			var v float64
+			err = r.ColumnByName(pkey.Name(), &v)
+			val = v
 		case spansql.Bytes:
-			ret[pkey.Name()] = make([][]byte, 0)
+			var v []byte
+			err = r.ColumnByName(pkey.Name(), &v)
+			val = v
 		case spansql.Timestamp:
-			ret[pkey.Name()] = make([]time.Time, 0)
+			var v time.Time
+			err = r.ColumnByName(pkey.Name(), &v)
+			val = v
 		case spansql.Date:
-			ret[pkey.Name()] = make([]civil.Date, 0)
+			var v civil.Date
+			err = r.ColumnByName(pkey.Name(), &v)
+			val = v
 		case spansql.Numeric:
-			ret[pkey.Name()] = make([]*big.Rat, 0)
+			var v big.Rat
+			err = r.ColumnByName(pkey.Name(), &v)
+			val = &v
 		case spansql.JSON:
-			ret[pkey.Name()] = make([]map[string]interface{}, 0) // TODO: This needs to be spanner.NullJSON
+			v := make(map[string]interface{}) // TODO: This needs to be spanner.NullJSON
+			err = r.ColumnByName(pkey.Name(), &v)
+			val = v
+		default:
+			err = fmt.Errorf("unsupported primary key type '%s'", pkey.SpannerType())
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to read row value: %s", err.Error())
 		}
+
+		ret = append(ret, val)
 	}
 
-	pkeys.ResetIterator()
+	return ret, nil
+}
 
-	stmt, err := table.TableSample(cfg.Operations.SampleSize)
-	if err != nil {
-		return nil, err
+// newSampleSlice returns an empty typed slice suitable for holding samples of the given type
+func newSampleSlice(t spansql.TypeBase, capacity int) interface{} {
+	switch t {
+	case spansql.Bool:
+		return make([]bool, 0, capacity)
+	case spansql.String:
+		return make([]string, 0, capacity)
+	case spansql.Int64:
+		return make([]int64, 0, capacity)
+	case spansql.Float64:
+		return make([]float64, 0, capacity)
+	case spansql.Bytes:
+		return make([][]byte, 0, capacity)
+	case spansql.Timestamp:
+		return make([]time.Time, 0, capacity)
+	case spansql.Date:
+		return make([]civil.Date, 0, capacity)
+	case spansql.Numeric:
+		return make([]*big.Rat, 0, capacity)
+	case spansql.JSON:
+		return make([]map[string]interface{}, 0, capacity) // TODO: This needs to be spanner.NullJSON
 	}
 
-	iter := client.Single().Query(ctx, spanner.NewStatement(stmt))
-	err = iter.Do(func(r *spanner.Row) error {
-		defer pkeys.ResetIterator()
+	return nil
+}
 
-		for pkeys.HasNext() {
-			pkey := pkeys.GetNext()
-			pt := pkey.Type().Base
+// keysToSamples converts a slice of keys into the column oriented map used by sample.SampleGenerator
+func keysToSamples(keys []spanner.Key, pkeys []schema.Column) map[string]interface{} {
+	ret := make(map[string]interface{}, len(pkeys))
+	for i, pkey := range pkeys {
+		s := reflect.ValueOf(newSampleSlice(pkey.Type().Base, len(keys)))
+		for _, k := range keys {
+			s = reflect.Append(s, reflect.ValueOf(k[i]))
+		}
 
-			switch pt {
-			case spansql.Bool:
-				var val bool
-				err := r.ColumnByName(pkey.Name(), &val)
-				if err != nil {
-					return fmt.Errorf("unable to read row value: %s", err.Error())
-				}
+		ret[pkey.Name()] = s.Interface()
+	}
 
-				arr := ret[pkey.Name()].([]bool)
-				ret[pkey.Name()] = append(arr, val)
-			case spansql.String:
-				var val string
-				err := r.ColumnByName(pkey.Name(), &val)
-				if err != nil {
-					return fmt.Errorf("unable to read row value: %s", err.Error())
-				}
+	return ret
+}
 
-				arr := ret[pkey.Name()].([]string)
-				ret[pkey.Name()] = append(arr, val)
-			case spansql.Int64:
-				var val int64
-				err := r.ColumnByName(pkey.Name(), &val)
-				if err != nil {
-					return fmt.Errorf("unable to read row value: %s", err.Error())
-				}
+func newKeyReservoir(src *rand.Rand, max int) *keyReservoir {
+	return &keyReservoir{
+		src:  src,
+		max:  max,
+		keys: make([]spanner.Key, 0),
+	}
+}
 
-				arr := ret[pkey.Name()].([]int64)
-				ret[pkey.Name()] = append(arr, val)
-			case spansql.Float64:
-				var val float64
-				err := r.ColumnByName(pkey.Name(), &val)
-				if err != nil {
-					return fmt.Errorf("unable to read row value: %s", err.Error())
-				}
+// Offer considers a key for inclusion in the sample
+func (r *keyReservoir) Offer(k spanner.Key) {
+	if !r.within.Contains(k) {
+		return
+	}
 
-				arr := ret[pkey.Name()].([]float64)
-				ret[pkey.Name()] = append(arr, val)
-			case spansql.Bytes:
-				var val []byte
-				err := r.ColumnByName(pkey.Name(), &val)
-				if err != nil {
-					return fmt.Errorf("unable to read row value: %s", err.Error())
-				}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-				arr := ret[pkey.Name()].([][]byte)
-				ret[pkey.Name()] = append(arr, val)
-			case spansql.Timestamp:
-				var val time.Time
-				err := r.ColumnByName(pkey.Name(), &val)
-				if err != nil {
-					return fmt.Errorf("unable to read row value: %s", err.Error())
-				}
+	r.seen++
+	if r.max <= 0 || len(r.keys) < r.max {
+		r.keys = append(r.keys, k)
+		return
+	}
 
-				arr := ret[pkey.Name()].([]time.Time)
-				ret[pkey.Name()] = append(arr, val)
-			case spansql.Date:
-				var val civil.Date
-				err := r.ColumnByName(pkey.Name(), &val)
-				if err != nil {
-					return fmt.Errorf("unable to read row value: %s", err.Error())
-				}
+	if i := r.src.Int63n(r.seen); i < int64(r.max) {
+		r.keys[i] = k
+	}
+}
 
-				arr := ret[pkey.Name()].([]civil.Date)
-				ret[pkey.Name()] = append(arr, val)
-			case spansql.Numeric:
-				var val *big.Rat
-				err := r.ColumnByName(pkey.Name(), &val)
-				if err != nil {
-					return fmt.Errorf("unable to read row value: %s", err.Error())
-				}
+// Full returns true when the reservoir holds max keys
+func (r *keyReservoir) Full() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-				arr := ret[pkey.Name()].([]*big.Rat)
-				ret[pkey.Name()] = append(arr, val)
-			case spansql.JSON:
-				val := make(map[string]interface{}) // TODO: This needs to be spanner.NullJSON
-				err := r.ColumnByName(pkey.Name(), &val)
-				if err != nil {
-					return fmt.Errorf("unable to read row value: %s", err.Error())
-				}
+	return r.max > 0 && len(r.keys) >= r.max
+}
 
-				arr := ret[pkey.Name()].([]map[string]interface{}) // TODO: This needs to be spanner.NullJSON
-				ret[pkey.Name()] = append(arr, val)
-			}
-		}
+// Len returns the number of keys held by the reservoir
+func (r *keyReservoir) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-		return nil
-	})
+	return len(r.keys)
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("error fetching results from table sample: %s", err.Error())
+// Add records n scanned rows and logs progress every sampleProgressInterval rows
+func (p *sampleProgress) Add(n int64) {
+	scanned := atomic.AddInt64(&p.scanned, n)
+	if scanned%sampleProgressInterval == 0 {
+		log.Printf("Sampling table '%s': scanned %d rows (%s)", p.table, scanned, time.Since(p.start).Round(time.Second))
 	}
+}
 
-	return ret, nil
+// Scanned returns the number of rows scanned so far
+func (p *sampleProgress) Scanned() int64 {
+	return atomic.LoadInt64(&p.scanned)
 }
 
-func GetReadGeneratorMap(samples map[string]interface{}, cols []string) (*sample.SampleGenerator, error) {
+// GetReadGeneratorMap returns a generator of point read keys drawn from samples using src
+func GetReadGeneratorMap(src rand.Source, samples map[string]interface{}, cols []string) (*sample.SampleGenerator, error) {
 	ret, err := sample.NewSampleGenerator(
-		rand.New(rand.NewSource(time.Now().UnixNano())),
+		rand.New(src),
 		samples,
 		cols,
 	)
@@ -0,0 +1,183 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sample
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+)
+
+var (
+	// Assert that SampleGenerator and LatestGenerator implement KeyGenerator
+	_ KeyGenerator = (*SampleGenerator)(nil)
+	_ KeyGenerator = (*LatestGenerator)(nil)
+)
+
+const (
+	// recencyBias skews selection towards the newest keys in the ring.
+	// A value of 1 is uniform. Higher values favor recent keys more heavily.
+	recencyBias = 3.0
+)
+
+type (
+	// KeyGenerator returns spanner.Key values suitable for point reads. Key generators are not
+	// safe for concurrent use. Use Fork to get an instance per goroutine.
+	KeyGenerator interface {
+		Next() interface{}
+		// Fork returns an independent generator over the same keys that draws from src
+		Fork(src *rand.Rand) KeyGenerator
+	}
+
+	// RecentKeys is a bounded ring of recently inserted primary keys. It is safe for concurrent use.
+	RecentKeys struct {
+		mu   sync.Mutex
+		src  *rand.Rand
+		keys []spanner.Key
+		pos  int // Position the next key will be written to
+		full bool
+	}
+
+	// LatestGenerator returns a key from RecentKeys for a fraction of calls and falls back to
+	// a KeyGenerator (usually a table sample) for the rest.
+	LatestGenerator struct {
+		src      *rand.Rand
+		recent   *RecentKeys
+		fallback KeyGenerator
+		fraction float64
+	}
+)
+
+// NewRecentKeys returns a ring that remembers at most size keys
+func NewRecentKeys(src *rand.Rand, size int) (*RecentKeys, error) {
+	if src == nil {
+		return nil, errors.New("missing random source")
+	}
+
+	if size <= 0 {
+		return nil, errors.New("recent key ring size must be > 0")
+	}
+
+	return &RecentKeys{
+		src:  src,
+		keys: make([]spanner.Key, size),
+	}, nil
+}
+
+// Add stores a key in the ring, evicting the oldest key when the ring is full
+func (r *RecentKeys) Add(k spanner.Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[r.pos] = k
+	r.pos++
+	if r.pos >= len(r.keys) {
+		r.pos = 0
+		r.full = true
+	}
+}
+
+// Len returns the number of keys currently held in the ring
+func (r *RecentKeys) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.len()
+}
+
+func (r *RecentKeys) len() int {
+	if r.full {
+		return len(r.keys)
+	}
+
+	return r.pos
+}
+
+// Next returns a key from the ring biased towards the most recently added keys.
+// The boolean is false when the ring is empty.
+func (r *RecentKeys) Next() (spanner.Key, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l := r.len()
+	if l == 0 {
+		return nil, false
+	}
+
+	// offset 0 is the newest key, offset l-1 is the oldest
+	offset := int(float64(l) * math.Pow(r.src.Float64(), recencyBias))
+	if offset >= l {
+		offset = l - 1
+	}
+
+	idx := r.pos - 1 - offset
+	if idx < 0 {
+		idx += len(r.keys)
+	}
+
+	return r.keys[idx], true
+}
+
+// NewLatestGenerator returns a generator that reads recent keys for the given fraction of calls
+func NewLatestGenerator(src *rand.Rand, recent *RecentKeys, fallback KeyGenerator, fraction float64) (*LatestGenerator, error) {
+	if src == nil {
+		return nil, errors.New("missing random source")
+	}
+
+	if recent == nil {
+		return nil, errors.New("missing recent key ring")
+	}
+
+	if fallback == nil {
+		return nil, errors.New("missing fallback key generator")
+	}
+
+	return &LatestGenerator{
+		src:      src,
+		recent:   recent,
+		fallback: fallback,
+		fraction: fraction,
+	}, nil
+}
+
+// Next returns a spanner.Key from the recent key ring or the fallback generator
+func (g *LatestGenerator) Next() interface{} {
+	if g.src.Float64() < g.fraction {
+		if k, ok := g.recent.Next(); ok {
+			return k
+		}
+	}
+
+	return g.fallback.Next()
+}
+
+// Fork returns a generator that draws from src and shares the recent key ring, so that keys
+// inserted by any job can be read by every other job. The fallback generator is forked as well.
+func (g *LatestGenerator) Fork(src *rand.Rand) KeyGenerator {
+	return &LatestGenerator{
+		src:      src,
+		recent:   g.recent,
+		fallback: g.fallback.Fork(rand.New(rand.NewSource(src.Int63()))),
+		fraction: g.fraction,
+	}
+}
+
+// Recent returns the ring backing this generator
+func (g *LatestGenerator) Recent() *RecentKeys {
+	return g.recent
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sample
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecentKeys(t *testing.T) {
+	Convey("RecentKeys", t, func() {
+		Convey("Invalid size", func() {
+			r, err := NewRecentKeys(rand.New(rand.NewSource(1)), 0)
+			So(err, ShouldNotBeNil)
+			So(r, ShouldBeNil)
+		})
+
+		Convey("Empty ring", func() {
+			r, err := NewRecentKeys(rand.New(rand.NewSource(1)), 10)
+			So(err, ShouldBeNil)
+
+			_, ok := r.Next()
+			So(ok, ShouldBeFalse)
+			So(r.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Evicts oldest keys", func() {
+			r, err := NewRecentKeys(rand.New(rand.NewSource(1)), 5)
+			So(err, ShouldBeNil)
+
+			for i := int64(0); i < 20; i++ {
+				r.Add(spanner.Key{i})
+			}
+			So(r.Len(), ShouldEqual, 5)
+
+			for i := 0; i < 1000; i++ {
+				k, ok := r.Next()
+				So(ok, ShouldBeTrue)
+				So(k[0], ShouldBeBetweenOrEqual, int64(15), int64(19))
+			}
+		})
+
+		Convey("Favors recent keys", func() {
+			r, err := NewRecentKeys(rand.New(rand.NewSource(1)), 100)
+			So(err, ShouldBeNil)
+
+			for i := int64(0); i < 100; i++ {
+				r.Add(spanner.Key{i})
+			}
+
+			newest := 0
+			for i := 0; i < 10000; i++ {
+				k, _ := r.Next()
+				if k[0].(int64) >= 50 {
+					newest++
+				}
+			}
+
+			So(newest, ShouldBeGreaterThan, 7500)
+		})
+
+		Convey("Concurrent use", func() {
+			r, err := NewRecentKeys(rand.New(rand.NewSource(1)), 50)
+			So(err, ShouldBeNil)
+
+			var wg sync.WaitGroup
+			for w := 0; w < 8; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < 1000; i++ {
+						r.Add(spanner.Key{int64(w*1000 + i)})
+						r.Next()
+					}
+				}(w)
+			}
+			wg.Wait()
+
+			So(r.Len(), ShouldEqual, 50)
+		})
+	})
+
+	Convey("LatestGenerator", t, func() {
+		samples := map[string]interface{}{"id": []int64{-1}}
+		sg, err := NewSampleGenerator(rand.New(rand.NewSource(1)), samples, []string{"id"})
+		So(err, ShouldBeNil)
+
+		r, err := NewRecentKeys(rand.New(rand.NewSource(1)), 10)
+		So(err, ShouldBeNil)
+
+		Convey("Falls back when ring is empty", func() {
+			lg, err := NewLatestGenerator(rand.New(rand.NewSource(1)), r, sg, 1)
+			So(err, ShouldBeNil)
+			So(lg.Next(), ShouldResemble, spanner.Key{int64(-1)})
+		})
+
+		Convey("Uses recent keys", func() {
+			r.Add(spanner.Key{int64(42)})
+			lg, err := NewLatestGenerator(rand.New(rand.NewSource(1)), r, sg, 1)
+			So(err, ShouldBeNil)
+			So(lg.Next(), ShouldResemble, spanner.Key{int64(42)})
+		})
+
+		Convey("Fork shares the recent key ring", func() {
+			lg, err := NewLatestGenerator(rand.New(rand.NewSource(1)), r, sg, 1)
+			So(err, ShouldBeNil)
+
+			fork := lg.Fork(rand.New(rand.NewSource(2)))
+			So(fork.Next(), ShouldResemble, spanner.Key{int64(-1)})
+
+			r.Add(spanner.Key{int64(42)})
+			So(fork.Next(), ShouldResemble, spanner.Key{int64(42)})
+		})
+
+		Convey("Zero fraction never uses recent keys", func() {
+			r.Add(spanner.Key{int64(42)})
+			lg, err := NewLatestGenerator(rand.New(rand.NewSource(1)), r, sg, 0)
+			So(err, ShouldBeNil)
+			for i := 0; i < 100; i++ {
+				So(lg.Next(), ShouldResemble, spanner.Key{int64(-1)})
+			}
+		})
+	})
+}
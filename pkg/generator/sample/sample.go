@@ -73,10 +73,30 @@ func NewSampleGenerator(src *rand.Rand, samples map[string]interface{}, cols []s
 	return ret, nil
 }
 
+// Fork returns a generator over the same samples that draws from src.
+// The samples are shared since they are never modified after construction.
+func (s *SampleGenerator) Fork(src *rand.Rand) KeyGenerator {
+	return &SampleGenerator{
+		src:  src,
+		l:    s.l,
+		s:    s.s,
+		cols: s.cols,
+	}
+}
+
 func (s *SampleGenerator) Next() interface{} {
+	return s.key(s.src.Intn(s.l))
+}
+
+// Len returns the number of sampled keys
+func (s *SampleGenerator) Len() int {
+	return s.l
+}
+
+// key returns the sampled key at idx
+func (s *SampleGenerator) key(idx int) spanner.Key {
 	ret := spanner.Key{}
 
-	idx := s.src.Intn(s.l)
 	for _, col := range s.cols {
 		// This is terrible, inefficient, and unsafe
 		s := reflect.ValueOf(s.s[col])
@@ -0,0 +1,58 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sample
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSampleGenerator(t *testing.T) {
+	Convey("SampleGenerator", t, func() {
+		samples := map[string]interface{}{
+			"id":   []int64{1, 2, 3, 4, 5, 6, 7, 8},
+			"name": []string{"a", "b", "c", "d", "e", "f", "g", "h"},
+		}
+
+		sg, err := NewSampleGenerator(rand.New(rand.NewSource(1)), samples, []string{"id", "name"})
+		So(err, ShouldBeNil)
+
+		Convey("Forks with equal sources return the same keys", func() {
+			a := sg.Fork(rand.New(rand.NewSource(2)))
+			b := sg.Fork(rand.New(rand.NewSource(2)))
+			for i := 0; i < 100; i++ {
+				sg.Next()
+				So(a.Next(), ShouldResemble, b.Next())
+			}
+		})
+
+		Convey("Forks can be used concurrently", func() {
+			var wg sync.WaitGroup
+			for w := 0; w < 8; w++ {
+				wg.Add(1)
+				go func(g KeyGenerator) {
+					defer wg.Done()
+					for i := 0; i < 1000; i++ {
+						g.Next()
+					}
+				}(sg.Fork(rand.New(rand.NewSource(int64(w)))))
+			}
+			wg.Wait()
+		})
+	})
+}
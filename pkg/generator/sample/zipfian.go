@@ -0,0 +1,146 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sample
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/rand"
+)
+
+var (
+	// Assert that ZipfianGenerator implements KeyGenerator
+	_ KeyGenerator = (*ZipfianGenerator)(nil)
+)
+
+const (
+	// ZipfianConstant is the skew YCSB uses for its zipfian request distribution
+	ZipfianConstant = 0.99
+)
+
+type (
+	// ZipfianGenerator returns sampled keys following a zipfian distribution, so that a few keys
+	// receive most of the requests. Like the scrambled zipfian generator of YCSB the popular keys
+	// are spread across the sample instead of clustering at the start of the key space.
+	ZipfianGenerator struct {
+		src     *rand.Rand
+		samples *SampleGenerator
+		z       *zipfian
+	}
+
+	// zipfian draws ranks in [0, items) using the algorithm of Gray et al., "Quickly Generating
+	// Billion-Record Synthetic Databases", which YCSB implements as well
+	zipfian struct {
+		items int
+		theta float64
+		alpha float64
+		zetan float64
+		eta   float64
+	}
+)
+
+// NewZipfianGenerator returns a generator over the keys of samples with the skew theta, which
+// must be between 0 and 1 (exclusive). See ZipfianConstant.
+func NewZipfianGenerator(src *rand.Rand, samples *SampleGenerator, theta float64) (*ZipfianGenerator, error) {
+	if src == nil {
+		return nil, errors.New("missing random source")
+	}
+
+	if samples == nil {
+		return nil, errors.New("missing sample generator")
+	}
+
+	if theta <= 0 || theta >= 1 {
+		return nil, errors.New("zipfian constant must be between 0 and 1")
+	}
+
+	return &ZipfianGenerator{
+		src:     src,
+		samples: samples,
+		z:       newZipfian(samples.Len(), theta),
+	}, nil
+}
+
+// Next returns a spanner.Key from the sample
+func (g *ZipfianGenerator) Next() interface{} {
+	return g.samples.key(g.Index())
+}
+
+// Index returns the sample index of the next key
+func (g *ZipfianGenerator) Index() int {
+	rank := g.z.next(g.src.Float64())
+
+	// Hash the rank so that neighbouring ranks land on unrelated keys
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(rank))
+	h := fnv.New64a()
+	h.Write(b[:])
+
+	return int(h.Sum64() % uint64(g.z.items))
+}
+
+// Fork returns a generator over the same samples that draws from src. The distribution is
+// shared since computing it takes time proportional to the sample size.
+func (g *ZipfianGenerator) Fork(src *rand.Rand) KeyGenerator {
+	return &ZipfianGenerator{
+		src:     src,
+		samples: g.samples,
+		z:       g.z,
+	}
+}
+
+func newZipfian(items int, theta float64) *zipfian {
+	zeta2 := zeta(2, theta)
+	zetan := zeta(items, theta)
+
+	return &zipfian{
+		items: items,
+		theta: theta,
+		alpha: 1 / (1 - theta),
+		zetan: zetan,
+		eta:   (1 - math.Pow(2/float64(items), 1-theta)) / (1 - zeta2/zetan),
+	}
+}
+
+// next maps u, uniformly distributed in [0, 1), to a rank. Rank 0 is the most popular.
+func (z *zipfian) next(u float64) int {
+	uz := u * z.zetan
+	if uz < 1 {
+		return 0
+	}
+
+	if uz < 1+math.Pow(0.5, z.theta) {
+		return 1
+	}
+
+	ret := int(float64(z.items) * math.Pow(z.eta*u-z.eta+1, z.alpha))
+	if ret >= z.items {
+		ret = z.items - 1
+	}
+
+	return ret
+}
+
+// zeta returns the sum of 1/i^theta for i in [1, n]
+func zeta(n int, theta float64) float64 {
+	var sum float64
+	for i := 1; i <= n; i++ {
+		sum += 1 / math.Pow(float64(i), theta)
+	}
+
+	return sum
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sample
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestZipfianGenerator(t *testing.T) {
+	Convey("ZipfianGenerator", t, func() {
+		ids := make([]int64, 1000)
+		for i := range ids {
+			ids[i] = int64(i)
+		}
+
+		sg, err := NewSampleGenerator(rand.New(rand.NewSource(1)), map[string]interface{}{"id": ids}, []string{"id"})
+		So(err, ShouldBeNil)
+
+		zg, err := NewZipfianGenerator(rand.New(rand.NewSource(1)), sg, ZipfianConstant)
+		So(err, ShouldBeNil)
+
+		Convey("A few keys receive most requests", func() {
+			counts := make(map[int]int)
+			n := 100000
+			for i := 0; i < n; i++ {
+				counts[zg.Index()]++
+			}
+
+			var top int
+			for idx, c := range counts {
+				So(idx, ShouldBeBetweenOrEqual, 0, len(ids)-1)
+				if c > top {
+					top = c
+				}
+			}
+
+			// The most popular of 1000 keys gets about 13% of requests, uniform keys get 0.1%
+			So(float64(top)/float64(n), ShouldBeGreaterThan, 0.1)
+		})
+
+		Convey("Keys are scrambled across the sample", func() {
+			counts := make(map[int]int)
+			for i := 0; i < 10000; i++ {
+				counts[zg.Index()]++
+			}
+
+			// Without scrambling the popular keys would be the first ones
+			var first int
+			for i := 0; i < 10; i++ {
+				first += counts[i]
+			}
+			So(first, ShouldBeLessThan, 5000)
+		})
+
+		Convey("Forks with equal sources return the same keys", func() {
+			a := zg.Fork(rand.New(rand.NewSource(2)))
+			b := zg.Fork(rand.New(rand.NewSource(2)))
+			for i := 0; i < 100; i++ {
+				So(a.Next(), ShouldResemble, b.Next())
+			}
+		})
+
+		Convey("Rejects invalid constants", func() {
+			_, err := NewZipfianGenerator(rand.New(rand.NewSource(1)), sg, 1)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"math/rand"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSample(t *testing.T) {
+	Convey("keyReservoir", t, func() {
+		Convey("Bounded", func() {
+			r := newKeyReservoir(rand.New(rand.NewSource(1)), 100)
+			for i := int64(0); i < 10000; i++ {
+				r.Offer(spanner.Key{i})
+			}
+
+			So(r.Len(), ShouldEqual, 100)
+			So(r.Full(), ShouldBeTrue)
+
+			// A uniform sample of 0..9999 should contain keys from the second half of the stream
+			late := 0
+			for _, k := range r.keys {
+				if k[0].(int64) >= 5000 {
+					late++
+				}
+			}
+			So(late, ShouldBeGreaterThan, 0)
+		})
+
+		Convey("Unbounded", func() {
+			r := newKeyReservoir(rand.New(rand.NewSource(1)), 0)
+			for i := int64(0); i < 1000; i++ {
+				r.Offer(spanner.Key{i})
+			}
+
+			So(r.Len(), ShouldEqual, 1000)
+			So(r.Full(), ShouldBeFalse)
+		})
+	})
+
+	Convey("keysToSamples", t, func() {
+		id := schema.NewColumn()
+		id.SetName("id")
+		id.SetSpannerType("INT64")
+
+		name := schema.NewColumn()
+		name.SetName("name")
+		name.SetSpannerType("STRING(10)")
+
+		samples := keysToSamples([]spanner.Key{
+			{int64(1), "a"},
+			{int64(2), "b"},
+		}, []schema.Column{id, name})
+
+		So(samples["id"], ShouldResemble, []int64{1, 2})
+		So(samples["name"], ShouldResemble, []string{"a", "b"})
+
+		sg, err := GetReadGeneratorMap(rand.NewSource(1), samples, []string{"id", "name"})
+		So(err, ShouldBeNil)
+		So(sg.Next(), ShouldHaveLength, 2)
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// package seed derives independent random sources from a single run seed.
+//
+// Every consumer of randomness (a column generator for one worker, an operation selector, ...)
+// is identified by a path of labels such as ("table", "Singers", "worker", "3", "column", "Name").
+// Hashing the run seed together with that path gives each consumer its own stream that does not
+// depend on the order in which streams are created, so a run can be replayed from its seed.
+package seed
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+)
+
+// Derive returns the seed for the stream identified by labels
+func Derive(base int64, labels ...string) int64 {
+	h := fnv.New64a()
+
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(base))
+	h.Write(b[:])
+
+	for _, l := range labels {
+		// Separate labels so that ("ab", "c") and ("a", "bc") hash differently
+		h.Write([]byte{0})
+		h.Write([]byte(l))
+	}
+
+	return int64(mix(h.Sum64()))
+}
+
+// Source returns a rand.Source seeded for the stream identified by labels
+func Source(base int64, labels ...string) rand.Source {
+	return rand.NewSource(Derive(base, labels...))
+}
+
+// Worker returns the label for the worker with index i
+func Worker(i int) string {
+	return strconv.Itoa(i)
+}
+
+// mix is the splitmix64 finalizer. It spreads the bits of the hash so that nearby
+// inputs produce unrelated seeds.
+func mix(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seed
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDerive(t *testing.T) {
+	Convey("Derive", t, func() {
+		Convey("Is deterministic", func() {
+			So(Derive(42, "table", "t", "column", "c"), ShouldEqual, Derive(42, "table", "t", "column", "c"))
+		})
+
+		Convey("Depends on the base seed", func() {
+			So(Derive(42, "table", "t"), ShouldNotEqual, Derive(43, "table", "t"))
+		})
+
+		Convey("Depends on every label", func() {
+			So(Derive(42, "worker", Worker(0)), ShouldNotEqual, Derive(42, "worker", Worker(1)))
+			So(Derive(42, "ab", "c"), ShouldNotEqual, Derive(42, "a", "bc"))
+			So(Derive(42), ShouldNotEqual, Derive(42, ""))
+		})
+
+		Convey("Sources produce the same stream", func() {
+			a := Source(7, "selector")
+			b := Source(7, "selector")
+			for i := 0; i < 100; i++ {
+				So(a.Int63(), ShouldEqual, b.Int63())
+			}
+		})
+	})
+}
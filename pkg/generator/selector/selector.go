@@ -15,7 +15,12 @@
 // selector package provides a mechanism for selecting between things
 package selector
 
-// Selector provides an interface for selecting choices in various ways
+import "math/rand"
+
+// Selector provides an interface for selecting choices in various ways.
+// Selectors are not safe for concurrent use. Use Fork to get an instance per goroutine.
 type Selector interface {
 	Select() Choice
+	// Fork returns an independent selector with the same choices that draws from src
+	Fork(src *rand.Rand) Selector
 }
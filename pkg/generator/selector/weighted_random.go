@@ -77,6 +77,17 @@ func (s *WeightedRandomSelector) Select() Choice {
 	return s.data[i]
 }
 
+// Fork returns a selector with the same choices and weights that draws from src.
+// The choices are shared since they are never modified after construction.
+func (s *WeightedRandomSelector) Fork(src *rand.Rand) Selector {
+	return &WeightedRandomSelector{
+		source: src,
+		data:   s.data,
+		totals: s.totals,
+		max:    s.max,
+	}
+}
+
 // Borrowed from https://github.com/mroth/weightedrand
 //
 // The standard library sort.SearchInts() just wraps the generic sort.Search()
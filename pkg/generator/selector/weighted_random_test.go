@@ -108,6 +108,24 @@ func TestWeightedRandomSelector(t *testing.T) {
 				}
 			})
 		})
+
+		Convey("Fork", func() {
+			wrs, err := NewWeightedRandomSelector(
+				rand.New(rand.NewSource(1)),
+				NewWeightedChoice("ONE", 50),
+				NewWeightedChoice("TWO", 50),
+			)
+			So(err, ShouldBeNil)
+
+			a := wrs.Fork(rand.New(rand.NewSource(2)))
+			b := wrs.Fork(rand.New(rand.NewSource(2)))
+
+			// Forks with equal sources make the same choices, no matter how the parent is used
+			for i := 0; i < 100; i++ {
+				wrs.Select()
+				So(a.Select().Item(), ShouldEqual, b.Select().Item())
+			}
+		})
 	})
 }
 
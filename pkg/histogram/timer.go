@@ -0,0 +1,240 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// package histogram records latencies in HDR histograms. Unlike the sampled timers of go-metrics,
+// every value is recorded, so tail percentiles such as p99.99 are exact within the configured
+// precision, and histograms of several runs or pods can be merged without losing accuracy.
+package histogram
+
+import (
+	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/rcrowley/go-metrics"
+)
+
+var (
+	// Assert that Timer implements metrics.Timer
+	_ metrics.Timer = (*Timer)(nil)
+)
+
+type (
+	// Timer is a metrics.Timer backed by an HDR histogram of durations in nanoseconds. Min and max
+	// are reported within the precision of the histogram. It is safe for concurrent use.
+	Timer struct {
+		mu    sync.Mutex
+		hist  *hdrhistogram.Histogram
+		sum   int64
+		meter metrics.Meter
+	}
+)
+
+// New returns an empty histogram. A zero configuration uses the defaults.
+func New(cfg config.Histogram) *hdrhistogram.Histogram {
+	if cfg.SignificantFigures == 0 {
+		cfg = config.Histogram{
+			Lowest:             config.DefaultHistogramLowest,
+			Highest:            config.DefaultHistogramHighest,
+			SignificantFigures: config.DefaultHistogramSignificantFigures,
+		}
+	}
+
+	return hdrhistogram.New(int64(cfg.Lowest), int64(cfg.Highest), cfg.SignificantFigures)
+}
+
+// NewTimer returns a timer recording into an empty histogram
+func NewTimer(cfg config.Histogram) *Timer {
+	return NewTimerFrom(New(cfg))
+}
+
+// NewTimerFrom returns a timer recording into h, for example a decoded histogram
+func NewTimerFrom(h *hdrhistogram.Histogram) *Timer {
+	return &Timer{
+		hist:  h,
+		sum:   int64(h.Mean() * float64(h.TotalCount())),
+		meter: metrics.NewMeter(),
+	}
+}
+
+// GetOrRegisterTimer returns the timer registered under name or registers a new HDR timer
+func GetOrRegisterTimer(name string, r metrics.Registry, cfg config.Histogram) metrics.Timer {
+	if r == nil {
+		r = metrics.DefaultRegistry
+	}
+
+	return r.GetOrRegister(name, func() metrics.Timer { return NewTimer(cfg) }).(metrics.Timer)
+}
+
+// Encode returns the histogram in the base64 encoded, compressed HdrHistogram V2 format
+func Encode(h *hdrhistogram.Histogram) (string, error) {
+	b, err := h.Encode(hdrhistogram.V2CompressedEncodingCookieBase)
+	return string(b), err
+}
+
+// Decode decodes a histogram written by Encode
+func Decode(s string) (*hdrhistogram.Histogram, error) {
+	return hdrhistogram.Decode([]byte(s))
+}
+
+// Histogram returns a copy of the recorded histogram
+func (t *Timer) Histogram() *hdrhistogram.Histogram {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return hdrhistogram.Import(t.hist.Export())
+}
+
+// Merge adds the values recorded in h and returns the number of values that were out of range
+func (t *Timer) Merge(h *hdrhistogram.Histogram) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sum += int64(h.Mean() * float64(h.TotalCount()))
+	return t.hist.Merge(h)
+}
+
+func (t *Timer) Count() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.hist.TotalCount()
+}
+
+func (t *Timer) Max() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.hist.Max()
+}
+
+func (t *Timer) Mean() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.hist.Mean()
+}
+
+func (t *Timer) Min() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.hist.Min()
+}
+
+// Percentile returns the value at quantile q (0.0 - 1.0)
+func (t *Timer) Percentile(q float64) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return float64(t.hist.ValueAtQuantile(q * 100))
+}
+
+// Percentiles returns the values at quantiles qs (0.0 - 1.0)
+func (t *Timer) Percentiles(qs []float64) []float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ret := make([]float64, len(qs))
+	for i, q := range qs {
+		ret[i] = float64(t.hist.ValueAtQuantile(q * 100))
+	}
+
+	return ret
+}
+
+func (t *Timer) Rate1() float64 {
+	return t.meter.Rate1()
+}
+
+func (t *Timer) Rate5() float64 {
+	return t.meter.Rate5()
+}
+
+func (t *Timer) Rate15() float64 {
+	return t.meter.Rate15()
+}
+
+func (t *Timer) RateMean() float64 {
+	return t.meter.RateMean()
+}
+
+// Snapshot returns a read-only copy of the timer
+func (t *Timer) Snapshot() metrics.Timer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return &Timer{
+		hist:  hdrhistogram.Import(t.hist.Export()),
+		sum:   t.sum,
+		meter: t.meter.Snapshot(),
+	}
+}
+
+func (t *Timer) StdDev() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.hist.StdDev()
+}
+
+// Stop stops the meter tracking rates
+func (t *Timer) Stop() {
+	t.meter.Stop()
+}
+
+// Sum returns the sum of recorded values. Values merged from other histograms contribute
+// their count times their mean.
+func (t *Timer) Sum() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.sum
+}
+
+// Time records the duration of f
+func (t *Timer) Time(f func()) {
+	ts := time.Now()
+	f()
+	t.UpdateSince(ts)
+}
+
+// Update records d. Negative durations are recorded as 0 and durations beyond the highest
+// trackable value as that value.
+func (t *Timer) Update(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+
+	t.mu.Lock()
+	if h := t.hist.HighestTrackableValue(); v > h {
+		v = h
+	}
+	t.hist.RecordValue(v)
+	t.sum += v
+	t.mu.Unlock()
+
+	t.meter.Mark(1)
+}
+
+func (t *Timer) UpdateSince(ts time.Time) {
+	t.Update(time.Since(ts))
+}
+
+func (t *Timer) Variance() float64 {
+	s := t.StdDev()
+	return s * s
+}
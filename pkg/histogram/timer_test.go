@@ -0,0 +1,139 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/rcrowley/go-metrics"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// withinPrecision reports whether actual is within the relative error of 3 significant figures
+func withinPrecision(actual float64, expected time.Duration) bool {
+	return math.Abs(actual-float64(expected)) <= float64(expected)/1000
+}
+
+func TestTimer(t *testing.T) {
+	Convey("Timer", t, func() {
+		tmr := NewTimer(config.Histogram{})
+
+		// 1ms .. 10s in 1ms steps, so that every percentile is known exactly
+		for i := 1; i <= 10000; i++ {
+			tmr.Update(time.Duration(i) * time.Millisecond)
+		}
+
+		Convey("Statistics", func() {
+			So(tmr.Count(), ShouldEqual, 10000)
+			So(withinPrecision(float64(tmr.Min()), time.Millisecond), ShouldBeTrue)
+			So(withinPrecision(float64(tmr.Max()), 10*time.Second), ShouldBeTrue)
+			So(withinPrecision(tmr.Mean(), 5000500*time.Microsecond), ShouldBeTrue)
+			So(tmr.Sum(), ShouldEqual, int64(10000*10001/2)*int64(time.Millisecond))
+			So(tmr.Variance(), ShouldAlmostEqual, tmr.StdDev()*tmr.StdDev())
+		})
+
+		Convey("Tail percentiles", func() {
+			So(withinPrecision(tmr.Percentile(0.5), 5*time.Second), ShouldBeTrue)
+			So(withinPrecision(tmr.Percentile(0.999), 9990*time.Millisecond), ShouldBeTrue)
+			So(withinPrecision(tmr.Percentile(0.9999), 9999*time.Millisecond), ShouldBeTrue)
+
+			ps := tmr.Percentiles([]float64{0.99, 0.9999})
+			So(ps[0], ShouldEqual, tmr.Percentile(0.99))
+			So(ps[1], ShouldEqual, tmr.Percentile(0.9999))
+		})
+
+		Convey("Out of range values", func() {
+			tmr := NewTimer(config.Histogram{Lowest: time.Microsecond, Highest: time.Second, SignificantFigures: 2})
+			tmr.Update(-time.Second)
+			tmr.Update(time.Minute)
+
+			So(tmr.Count(), ShouldEqual, 2)
+			So(tmr.Min(), ShouldEqual, 0)
+			So(float64(tmr.Max()), ShouldAlmostEqual, float64(time.Second), float64(time.Second)/100)
+		})
+
+		Convey("Snapshot", func() {
+			s := tmr.Snapshot()
+			tmr.Update(time.Millisecond)
+
+			So(s.Count(), ShouldEqual, 10000)
+			So(tmr.Count(), ShouldEqual, 10001)
+		})
+
+		Convey("Encode and merge", func() {
+			enc, err := Encode(tmr.Histogram())
+			So(err, ShouldBeNil)
+
+			h, err := Decode(enc)
+			So(err, ShouldBeNil)
+			So(h.Equals(tmr.Histogram()), ShouldBeTrue)
+
+			// Merging two halves is exactly the same as recording everything in one timer
+			a, b := NewTimer(config.Histogram{}), NewTimer(config.Histogram{})
+			for i := 1; i <= 10000; i++ {
+				if i%2 == 0 {
+					a.Update(time.Duration(i) * time.Millisecond)
+				} else {
+					b.Update(time.Duration(i) * time.Millisecond)
+				}
+			}
+
+			enc, err = Encode(b.Histogram())
+			So(err, ShouldBeNil)
+			h, err = Decode(enc)
+			So(err, ShouldBeNil)
+
+			So(a.Merge(h), ShouldEqual, 0)
+			So(a.Histogram().Equals(tmr.Histogram()), ShouldBeTrue)
+			So(a.Percentile(0.9999), ShouldEqual, tmr.Percentile(0.9999))
+
+			m := NewTimerFrom(h)
+			So(m.Count(), ShouldEqual, 5000)
+		})
+
+		Convey("Concurrent updates", func() {
+			tmr := NewTimer(config.Histogram{})
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 1000; j++ {
+						tmr.Update(time.Millisecond)
+					}
+				}()
+			}
+			wg.Wait()
+
+			So(tmr.Count(), ShouldEqual, 10000)
+		})
+	})
+}
+
+func TestGetOrRegisterTimer(t *testing.T) {
+	Convey("GetOrRegisterTimer", t, func() {
+		r := metrics.NewRegistry()
+
+		a := GetOrRegisterTimer("operations.read.time", r, config.Histogram{})
+		b := GetOrRegisterTimer("operations.read.time", r, config.Histogram{})
+		So(a, ShouldEqual, b)
+		So(r.Get("operations.read.time"), ShouldHaveSameTypeAs, &Timer{})
+	})
+}
@@ -0,0 +1,302 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/histogram"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	// Assert that Interval implements Observer
+	_ Observer = (*Interval)(nil)
+
+	// Header of interval statistics written as csv
+	intervalCSVHeader = []string{"time", "elapsed_ns", "interval_ns", "in_flight", "operation", "operations", "rows", "rate", "errors", "p50_ns", "p95_ns", "p99_ns"}
+)
+
+type (
+	// Interval logs statistics of every interval while a workload runs. Latencies are recorded in
+	// histograms that are reset at every interval, so a degradation late in a long run is not
+	// averaged out by the hours before it.
+	Interval struct {
+		histogram config.Histogram
+		w         io.Writer           // Optional
+		csv       *csv.Writer         // Set if w is written as csv
+		notify    func(IntervalStats) // Optional
+
+		inFlight int64 // Accessed atomically
+
+		mu    sync.Mutex
+		start time.Time
+		last  time.Time
+		ops   map[string]*intervalOperation
+
+		cancel context.CancelFunc
+		done   chan struct{}
+	}
+
+	intervalOperation struct {
+		hist       *hdrhistogram.Histogram
+		operations int64
+		rows       int64
+		errors     int64
+	}
+
+	// IntervalStats are the statistics of one interval
+	IntervalStats struct {
+		Time       time.Time                `json:"time"`        // End of the interval
+		Elapsed    time.Duration            `json:"elapsed_ns"`  // Time since the observer was started
+		Interval   time.Duration            `json:"interval_ns"` // Length of the interval
+		InFlight   int64                    `json:"in_flight"`   // Operations waiting on spanner at the end of the interval
+		Operations []IntervalOperationStats `json:"operations"`
+	}
+
+	// IntervalOperationStats are the statistics of an operation type in one interval. Latencies
+	// are in nanoseconds and cover successful operations.
+	IntervalOperationStats struct {
+		Operation  string  `json:"operation"`
+		Operations int64   `json:"operations"` // Successful operations
+		Rows       int64   `json:"rows"`       // Rows read or written by successful operations
+		Rate       float64 `json:"rate"`       // Rows per second
+		Errors     int64   `json:"errors"`     // Rows covered by failed operations
+		P50        int64   `json:"p50_ns"`
+		P95        int64   `json:"p95_ns"`
+		P99        int64   `json:"p99_ns"`
+	}
+)
+
+// NewInterval returns an Interval observer. If w is not nil, the statistics of every interval are
+// also written to w as jsonl or csv. A csv header is written if header is set.
+func NewInterval(cfg config.Histogram, w io.Writer, format string, header bool) *Interval {
+	i := &Interval{
+		histogram: cfg,
+		w:         w,
+		ops:       make(map[string]*intervalOperation),
+	}
+
+	if w != nil && format == config.ReportFormatCSV {
+		i.csv = csv.NewWriter(w)
+		if header {
+			i.csv.Write(intervalCSVHeader)
+			i.csv.Flush()
+		}
+	}
+
+	return i
+}
+
+// Run logs and writes statistics every interval in the background until Stop is called
+func (i *Interval) Run(interval time.Duration) {
+	var ctx context.Context
+	ctx, i.cancel = context.WithCancel(context.Background())
+	i.done = make(chan struct{})
+
+	i.mu.Lock()
+	i.start = time.Now()
+	i.last = i.start
+	i.mu.Unlock()
+
+	go func() {
+		defer close(i.done)
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				i.flush()
+			}
+		}
+	}()
+}
+
+// Notify calls fn with the statistics of every interval. It must be called before Run.
+func (i *Interval) Notify(fn func(IntervalStats)) {
+	i.notify = fn
+}
+
+// Stop stops the background loop and flushes the final, possibly shorter interval
+func (i *Interval) Stop() {
+	if i.cancel == nil {
+		return
+	}
+
+	i.cancel()
+	<-i.done
+	i.flush()
+}
+
+func (i *Interval) Start(ctx context.Context, table string, op string) context.Context {
+	atomic.AddInt64(&i.inFlight, 1)
+	return ctx
+}
+
+func (i *Interval) Done(ctx context.Context, table string, op string, n int, d time.Duration, code codes.Code) {
+	atomic.AddInt64(&i.inFlight, -1)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	o := i.operation(op)
+	if code != codes.OK {
+		o.errors += int64(n)
+		return
+	}
+
+	v := int64(d)
+	if h := o.hist.HighestTrackableValue(); v > h {
+		v = h
+	}
+	o.hist.RecordValue(v)
+	o.operations++
+	o.rows += int64(n)
+}
+
+// operation returns the current interval of op. i.mu must be held.
+func (i *Interval) operation(op string) *intervalOperation {
+	o, ok := i.ops[op]
+	if !ok {
+		o = &intervalOperation{hist: histogram.New(i.histogram)}
+		i.ops[op] = o
+	}
+
+	return o
+}
+
+// Collect returns the statistics of the interval since the last call and starts a new one
+func (i *Interval) Collect() IntervalStats {
+	now := time.Now()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	s := IntervalStats{
+		Time:       now,
+		Elapsed:    now.Sub(i.start),
+		Interval:   now.Sub(i.last),
+		InFlight:   atomic.LoadInt64(&i.inFlight),
+		Operations: make([]IntervalOperationStats, 0, len(i.ops)),
+	}
+	i.last = now
+
+	for op, o := range i.ops {
+		st := IntervalOperationStats{
+			Operation:  op,
+			Operations: o.operations,
+			Rows:       o.rows,
+			Errors:     o.errors,
+		}
+		if o.operations > 0 {
+			st.P50 = o.hist.ValueAtQuantile(50)
+			st.P95 = o.hist.ValueAtQuantile(95)
+			st.P99 = o.hist.ValueAtQuantile(99)
+		}
+		if s.Interval > 0 {
+			st.Rate = float64(o.rows) / s.Interval.Seconds()
+		}
+
+		s.Operations = append(s.Operations, st)
+
+		// Keep the histogram to avoid reallocating it every interval
+		o.hist.Reset()
+		o.operations, o.rows, o.errors = 0, 0, 0
+	}
+
+	sort.Slice(s.Operations, func(a, b int) bool { return s.Operations[a].Operation < s.Operations[b].Operation })
+
+	return s
+}
+
+// flush logs the statistics of the current interval and writes them to the writer
+func (i *Interval) flush() {
+	s := i.Collect()
+	log.Println(s.String())
+
+	if i.notify != nil {
+		i.notify(s)
+	}
+
+	if i.w == nil {
+		return
+	}
+
+	err := i.write(s)
+	if err != nil {
+		log.Printf("writing interval statistics: %s", err.Error())
+	}
+}
+
+func (i *Interval) write(s IntervalStats) error {
+	if i.csv == nil {
+		return json.NewEncoder(i.w).Encode(s)
+	}
+
+	for _, o := range s.Operations {
+		i.csv.Write([]string{
+			s.Time.Format(time.RFC3339Nano),
+			strconv.FormatInt(int64(s.Elapsed), 10),
+			strconv.FormatInt(int64(s.Interval), 10),
+			strconv.FormatInt(s.InFlight, 10),
+			o.Operation,
+			strconv.FormatInt(o.Operations, 10),
+			strconv.FormatInt(o.Rows, 10),
+			strconv.FormatFloat(o.Rate, 'f', 1, 64),
+			strconv.FormatInt(o.Errors, 10),
+			strconv.FormatInt(o.P50, 10),
+			strconv.FormatInt(o.P95, 10),
+			strconv.FormatInt(o.P99, 10),
+		})
+	}
+
+	i.csv.Flush()
+	return i.csv.Error()
+}
+
+// String formats the statistics as a single log line
+func (s IntervalStats) String() string {
+	parts := []string{fmt.Sprintf("[%s]", s.Elapsed.Round(time.Second))}
+	for _, o := range s.Operations {
+		parts = append(parts, fmt.Sprintf("%s: %.1f/s p50 %s p95 %s p99 %s errors %d",
+			o.Operation,
+			o.Rate,
+			time.Duration(o.P50).Round(time.Microsecond),
+			time.Duration(o.P95).Round(time.Microsecond),
+			time.Duration(o.P99).Round(time.Microsecond),
+			o.Errors,
+		))
+	}
+	parts = append(parts, fmt.Sprintf("in flight: %d", s.InFlight))
+
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observer
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"google.golang.org/grpc/codes"
+)
+
+func TestIntervalCollect(t *testing.T) {
+	i := NewInterval(config.Histogram{}, nil, "", false)
+	ctx := context.Background()
+
+	// 100 reads of 1ms .. 100ms, one failed write and one write still in flight
+	for n := 1; n <= 100; n++ {
+		i.Start(ctx, "Singers", "read")
+		i.Done(ctx, "Singers", "read", 1, time.Duration(n)*time.Millisecond, codes.OK)
+	}
+	i.Start(ctx, "Singers", "write")
+	i.Done(ctx, "Singers", "write", 5, time.Second, codes.Aborted)
+	i.Start(ctx, "Singers", "write")
+
+	s := i.Collect()
+	if s.InFlight != 1 {
+		t.Errorf("in flight = %d, want 1", s.InFlight)
+	}
+	if len(s.Operations) != 2 {
+		t.Fatalf("got %d operations, want 2", len(s.Operations))
+	}
+
+	read, write := s.Operations[0], s.Operations[1]
+	if read.Operation != "read" || read.Operations != 100 || read.Rows != 100 || read.Errors != 0 {
+		t.Errorf("unexpected read stats %+v", read)
+	}
+	for _, p := range []struct {
+		name string
+		got  int64
+		want time.Duration
+	}{
+		{"p50", read.P50, 50 * time.Millisecond},
+		{"p95", read.P95, 95 * time.Millisecond},
+		{"p99", read.P99, 99 * time.Millisecond},
+	} {
+		if d := p.got - int64(p.want); d < 0 || d > int64(p.want)/1000 {
+			t.Errorf("%s = %s, want %s", p.name, time.Duration(p.got), p.want)
+		}
+	}
+	if write.Operations != 0 || write.Errors != 5 || write.P99 != 0 {
+		t.Errorf("unexpected write stats %+v", write)
+	}
+
+	// The next interval starts empty, so a slow interval is not averaged with earlier ones
+	i.Start(ctx, "Singers", "read")
+	i.Done(ctx, "Singers", "read", 1, 2*time.Second, codes.OK)
+
+	s = i.Collect()
+	if s.Operations[0].Operations != 1 || s.Operations[0].P50 < int64(2*time.Second) {
+		t.Errorf("unexpected read stats in second interval %+v", s.Operations[0])
+	}
+	if s.Operations[1].Errors != 0 {
+		t.Errorf("errors were not reset: %+v", s.Operations[1])
+	}
+}
+
+// syncBuffer is a bytes.Buffer that can be written by the interval loop while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestIntervalRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("jsonl", func(t *testing.T) {
+		w := &syncBuffer{}
+		i := NewInterval(config.Histogram{}, w, config.ReportFormatJSONL, true)
+		i.Run(10 * time.Millisecond)
+
+		i.Start(ctx, "Singers", "write")
+		i.Done(ctx, "Singers", "write", 10, time.Millisecond, codes.OK)
+		time.Sleep(25 * time.Millisecond)
+		i.Stop()
+
+		lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+		if len(lines) < 3 {
+			t.Fatalf("got %d intervals, want at least 3 (two ticks and the final flush)", len(lines))
+		}
+
+		var rows int64
+		for _, l := range lines {
+			var s IntervalStats
+			if err := json.Unmarshal([]byte(l), &s); err != nil {
+				t.Fatalf("decoding %q: %v", l, err)
+			}
+			for _, o := range s.Operations {
+				rows += o.Rows
+			}
+		}
+		if rows != 10 {
+			t.Errorf("rows across intervals = %d, want 10", rows)
+		}
+	})
+
+	t.Run("notify", func(t *testing.T) {
+		i := NewInterval(config.Histogram{}, nil, "", false)
+
+		var got []IntervalStats
+		i.Notify(func(s IntervalStats) { got = append(got, s) })
+		i.Run(time.Hour)
+
+		i.Start(ctx, "Singers", "read")
+		i.Done(ctx, "Singers", "read", 1, time.Millisecond, codes.OK)
+		i.Stop()
+
+		if len(got) != 1 || got[0].Operations[0].Rows != 1 {
+			t.Errorf("notified %+v, want the final interval", got)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		w := &syncBuffer{}
+		i := NewInterval(config.Histogram{}, w, config.ReportFormatCSV, true)
+		i.Run(time.Hour)
+
+		i.Start(ctx, "Singers", "read")
+		i.Done(ctx, "Singers", "read", 1, time.Millisecond, codes.OK)
+		i.Stop()
+
+		rows, err := csv.NewReader(strings.NewReader(w.String())).ReadAll()
+		if err != nil {
+			t.Fatalf("reading csv: %v", err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("got %d rows, want a header and one interval", len(rows))
+		}
+		if strings.Join(rows[0], ",") != strings.Join(intervalCSVHeader, ",") {
+			t.Errorf("unexpected header %v", rows[0])
+		}
+		if rows[1][4] != "read" || rows[1][6] != "1" {
+			t.Errorf("unexpected row %v", rows[1])
+		}
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// package observer exports live operation metrics, labelled by table and operation, while a
+// workload runs. The go-metrics registry used for the summary has no labels and is only read at exit.
+package observer
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+type (
+	// Observer is notified about every operation a job performs. Implementations must be safe for
+	// concurrent use.
+	Observer interface {
+		// Start is called before an operation on table is sent. The returned context is used
+		// for the operation, which lets observers attach a span to it.
+		Start(ctx context.Context, table string, op string) context.Context
+		// Done is called with the context returned by Start when the operation finished after d,
+		// including retries. n is the number of rows it wrote or read, a scan counts as one
+		// whatever number of rows it returned. code is codes.OK if it succeeded.
+		Done(ctx context.Context, table string, op string, n int, d time.Duration, code codes.Code)
+	}
+
+	// Multi notifies every observer it holds
+	Multi []Observer
+)
+
+// Assert that Multi implements Observer
+var _ Observer = (Multi)(nil)
+
+func (m Multi) Start(ctx context.Context, table string, op string) context.Context {
+	for _, o := range m {
+		ctx = o.Start(ctx, table, op)
+	}
+
+	return ctx
+}
+
+func (m Multi) Done(ctx context.Context, table string, op string, n int, d time.Duration, code codes.Code) {
+	for _, o := range m {
+		o.Done(ctx, table, op, n, d, code)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/hashicorp/go-multierror"
+	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/attribute"
+	ocbridge "go.opentelemetry.io/otel/bridge/opencensus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/unit"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator/histogram"
+	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
+	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
+	"go.opentelemetry.io/otel/sdk/metric/selector/simple"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	grpccodes "google.golang.org/grpc/codes"
+)
+
+const (
+	// Name of the meter and tracer
+	instrumentationName = "github.com/cloudspannerecosystem/gcsb"
+
+	defaultServiceName = "gcsb"
+)
+
+var (
+	// Histogram boundaries in milliseconds
+	durationBoundaries = []float64{0.5, 1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384}
+)
+
+// Assert that OpenTelemetry implements Observer
+var _ Observer = (*OpenTelemetry)(nil)
+
+type (
+	// OpenTelemetry exports operation metrics and, if tracing is enabled, a span per sampled
+	// operation to an OTLP collector. The spanner client creates OpenCensus spans, which are bridged
+	// to OpenTelemetry so that they become children of the operation span.
+	OpenTelemetry struct {
+		controller *controller.Controller
+		exporter   *otlpmetric.Exporter
+		provider   *sdktrace.TracerProvider // nil if tracing is disabled
+		tracer     trace.Tracer
+
+		duration metric.Float64Histogram
+		rows     metric.Int64Counter
+		errors   metric.Int64Counter
+		inFlight metric.Int64UpDownCounter
+	}
+)
+
+// NewOpenTelemetry connects exporters to the configured collector and starts exporting metrics.
+// Tracing replaces the global OpenCensus tracer. Call Shutdown to flush pending data.
+func NewOpenTelemetry(ctx context.Context, cfg config.OTel) (*OpenTelemetry, error) {
+	res, err := newResource(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating resource: %s", err.Error())
+	}
+
+	headers, err := config.ParsePairs(cfg.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("parsing headers: %s", err.Error())
+	}
+
+	// Metrics
+	mexp, err := newMetricExporter(ctx, cfg, headers)
+	if err != nil {
+		return nil, fmt.Errorf("creating metric exporter: %s", err.Error())
+	}
+
+	o := &OpenTelemetry{
+		controller: controller.New(
+			processor.NewFactory(simple.NewWithHistogramDistribution(histogram.WithExplicitBoundaries(durationBoundaries)), mexp),
+			controller.WithExporter(mexp),
+			controller.WithCollectPeriod(cfg.Interval),
+			controller.WithResource(res),
+		),
+		exporter: mexp,
+		tracer:   trace.NewNoopTracerProvider().Tracer(instrumentationName),
+	}
+
+	meter := metric.Must(o.controller.Meter(instrumentationName))
+	o.duration = meter.NewFloat64Histogram("gcsb.operation.duration",
+		metric.WithDescription("Latency of successful operations, including retries"),
+		metric.WithUnit(unit.Milliseconds))
+	o.rows = meter.NewInt64Counter("gcsb.operation.rows",
+		metric.WithDescription("Rows read or written by successful operations"))
+	o.errors = meter.NewInt64Counter("gcsb.operation.errors",
+		metric.WithDescription("Rows covered by failed operations, by gRPC code"))
+	o.inFlight = meter.NewInt64UpDownCounter("gcsb.operation.in_flight",
+		metric.WithDescription("Operations currently waiting on spanner"))
+
+	err = o.controller.Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("starting metric controller: %s", err.Error())
+	}
+
+	// Traces
+	if cfg.Tracing.Enabled {
+		texp, err := newTraceExporter(ctx, cfg, headers)
+		if err != nil {
+			o.controller.Stop(ctx)
+			mexp.Shutdown(ctx)
+			return nil, fmt.Errorf("creating trace exporter: %s", err.Error())
+		}
+
+		o.provider = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(texp),
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRate))),
+		)
+		o.tracer = o.provider.Tracer(instrumentationName)
+
+		// The spanner client traces with OpenCensus. Route its spans through our tracer.
+		octrace.DefaultTracer = ocbridge.NewTracer(o.tracer)
+	}
+
+	return o, nil
+}
+
+func (o *OpenTelemetry) Start(ctx context.Context, table string, op string) context.Context {
+	attrs := attributes(table, op)
+	o.inFlight.Add(ctx, 1, attrs...)
+
+	ctx, _ = o.tracer.Start(ctx, "gcsb."+op, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindClient))
+
+	return ctx
+}
+
+func (o *OpenTelemetry) Done(ctx context.Context, table string, op string, n int, d time.Duration, code grpccodes.Code) {
+	attrs := attributes(table, op)
+	o.inFlight.Add(ctx, -1, attrs...)
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int("gcsb.rows", n), attribute.String("rpc.grpc.status_code", code.String()))
+	defer span.End()
+
+	if code != grpccodes.OK {
+		o.errors.Add(ctx, int64(n), append(attrs, attribute.String("code", code.String()))...)
+		span.SetStatus(codes.Error, code.String())
+		return
+	}
+
+	o.duration.Record(ctx, float64(d)/float64(time.Millisecond), attrs...)
+	o.rows.Add(ctx, int64(n), attrs...)
+}
+
+// Shutdown exports pending metrics and spans and stops the exporters
+func (o *OpenTelemetry) Shutdown(ctx context.Context) error {
+	var result *multierror.Error
+
+	if err := o.controller.Stop(ctx); err != nil {
+		result = multierror.Append(result, fmt.Errorf("stopping metric controller: %s", err.Error()))
+	}
+
+	if err := o.exporter.Shutdown(ctx); err != nil {
+		result = multierror.Append(result, fmt.Errorf("stopping metric exporter: %s", err.Error()))
+	}
+
+	if o.provider != nil {
+		if err := o.provider.Shutdown(ctx); err != nil {
+			result = multierror.Append(result, fmt.Errorf("stopping tracer provider: %s", err.Error()))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func attributes(table string, op string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("table", table),
+		attribute.String("operation", op),
+	}
+}
+
+// newResource describes this process. Configured attributes take precedence over
+// OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME, and service.name defaults to gcsb.
+func newResource(cfg config.OTel) (*resource.Resource, error) {
+	pairs, err := config.ParsePairs(cfg.ResourceAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(pairs))
+	for k, v := range pairs {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	// Later detectors take precedence
+	return resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceNameKey.String(defaultServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithFromEnv(),
+		resource.WithAttributes(attrs...),
+	)
+}
+
+func newMetricExporter(ctx context.Context, cfg config.OTel, headers map[string]string) (*otlpmetric.Exporter, error) {
+	if cfg.Protocol == config.OTelProtocolHTTP {
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(cfg.Endpoint), otlpmetrichttp.WithHeaders(headers)}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	}
+
+	opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(cfg.Endpoint), otlpmetricgrpc.WithHeaders(headers)}
+	if cfg.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	return otlpmetricgrpc.New(ctx, opts...)
+}
+
+func newTraceExporter(ctx context.Context, cfg config.OTel, headers map[string]string) (*otlptrace.Exporter, error) {
+	if cfg.Protocol == config.OTelProtocolHTTP {
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint), otlptracehttp.WithHeaders(headers)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint), otlptracegrpc.WithHeaders(headers)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
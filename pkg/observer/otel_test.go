@@ -0,0 +1,162 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observer
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	octrace "go.opencensus.io/trace"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is a stand-in for an OTLP/HTTP collector that keeps everything it receives
+type collector struct {
+	mu       sync.Mutex
+	metrics  []string
+	spans    []*tracepb.Span
+	resource []string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/v1/metrics":
+		var req colmetricpb.ExportMetricsServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, rm := range req.ResourceMetrics {
+			for _, kv := range rm.Resource.Attributes {
+				c.resource = append(c.resource, kv.Key+"="+kv.Value.GetStringValue())
+			}
+			for _, ilm := range rm.InstrumentationLibraryMetrics {
+				for _, m := range ilm.Metrics {
+					c.metrics = append(c.metrics, m.Name)
+				}
+			}
+		}
+	case "/v1/traces":
+		var req coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ils := range rs.InstrumentationLibrarySpans {
+				c.spans = append(c.spans, ils.Spans...)
+			}
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+}
+
+func TestOpenTelemetry(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	defer func(tracer octrace.Tracer) { octrace.DefaultTracer = tracer }(octrace.DefaultTracer)
+
+	ctx := context.Background()
+	o, err := NewOpenTelemetry(ctx, config.OTel{
+		Endpoint:           strings.TrimPrefix(srv.URL, "http://"),
+		Protocol:           config.OTelProtocolHTTP,
+		Insecure:           true,
+		Interval:           time.Hour, // Only export on shutdown
+		ResourceAttributes: []string{"deployment.environment=test"},
+		Tracing:            config.Tracing{Enabled: true, SampleRate: 1},
+	})
+	if err != nil {
+		t.Fatalf("NewOpenTelemetry: %v", err)
+	}
+
+	// The spanner client creates OpenCensus spans within the operation context
+	opCtx := o.Start(ctx, "Singers", "read")
+	_, child := octrace.StartSpan(opCtx, "cloud.google.com/go/spanner.ReadRow")
+	child.End()
+	o.Done(opCtx, "Singers", "read", 1, 5*time.Millisecond, codes.OK)
+
+	opCtx = o.Start(ctx, "Singers", "write")
+	o.Done(opCtx, "Singers", "write", 10, time.Second, codes.DeadlineExceeded)
+
+	if err := o.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	got := strings.Join(c.metrics, ",")
+	for _, want := range []string{"gcsb.operation.duration", "gcsb.operation.rows", "gcsb.operation.errors", "gcsb.operation.in_flight"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("collector did not receive metric %s, got %s", want, got)
+		}
+	}
+
+	res := strings.Join(c.resource, ",")
+	for _, want := range []string{"service.name=gcsb", "deployment.environment=test"} {
+		if !strings.Contains(res, want) {
+			t.Errorf("resource does not contain %s, got %s", want, res)
+		}
+	}
+
+	spans := make(map[string]*tracepb.Span)
+	for _, s := range c.spans {
+		spans[s.Name] = s
+	}
+
+	read, ok := spans["gcsb.read"]
+	if !ok {
+		t.Fatalf("collector did not receive the read span, got %d spans", len(c.spans))
+	}
+
+	sc, ok := spans["cloud.google.com/go/spanner.ReadRow"]
+	if !ok {
+		t.Fatal("collector did not receive the spanner span")
+	}
+	if !bytes.Equal(sc.ParentSpanId, read.SpanId) || !bytes.Equal(sc.TraceId, read.TraceId) {
+		t.Error("spanner span is not a child of the operation span")
+	}
+
+	if w, ok := spans["gcsb.write"]; !ok || w.Status.Code != tracepb.Status_STATUS_CODE_ERROR {
+		t.Error("failed write span is missing or not marked as an error")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	namespace = "gcsb"
+)
+
+// Assert that Prometheus implements Observer
+var _ Observer = (*Prometheus)(nil)
+
+type (
+	// Prometheus records operations in a prometheus registry and serves it on /metrics
+	Prometheus struct {
+		Registry *prometheus.Registry
+
+		duration   *prometheus.HistogramVec
+		operations *prometheus.CounterVec
+		errors     *prometheus.CounterVec
+		inFlight   *prometheus.GaugeVec
+		server     *http.Server
+	}
+)
+
+// NewPrometheus returns a Prometheus observer with its own registry
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{
+		Registry: prometheus.NewRegistry(),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of successful operations, including retries.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 16), // 0.5ms to ~16s
+		}, []string{"table", "operation"}),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_total",
+			Help:      "Rows read or written by successful operations. Scans count once each.",
+		}, []string{"table", "operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operation_errors_total",
+			Help:      "Rows covered by failed operations, by gRPC code. Scans count once each.",
+		}, []string{"table", "operation", "code"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "operations_in_flight",
+			Help:      "Operations currently waiting on spanner.",
+		}, []string{"table", "operation"}),
+	}
+
+	p.Registry.MustRegister(
+		p.duration,
+		p.operations,
+		p.errors,
+		p.inFlight,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+
+	return p
+}
+
+func (p *Prometheus) Start(ctx context.Context, table string, op string) context.Context {
+	p.inFlight.WithLabelValues(table, op).Inc()
+	return ctx
+}
+
+func (p *Prometheus) Done(ctx context.Context, table string, op string, n int, d time.Duration, code codes.Code) {
+	p.inFlight.WithLabelValues(table, op).Dec()
+
+	if code != codes.OK {
+		p.errors.WithLabelValues(table, op, code.String()).Add(float64(n))
+		return
+	}
+
+	p.duration.WithLabelValues(table, op).Observe(d.Seconds())
+	p.operations.WithLabelValues(table, op).Add(float64(n))
+}
+
+// Handler returns an http.Handler serving the registry in the prometheus exposition format
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.Registry, promhttp.HandlerOpts{})
+}
+
+// Serve starts serving /metrics on addr in the background. It returns once the address is bound.
+func (p *Prometheus) Serve(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", p.Handler())
+	p.server = &http.Server{Handler: mux}
+
+	go func() {
+		err := p.server.Serve(l)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("metrics server stopped: %s", err.Error())
+		}
+	}()
+
+	log.Printf("Serving prometheus metrics on http://%s/metrics", l.Addr().String())
+
+	return nil
+}
+
+// Shutdown stops the metrics server, waiting up to timeout for in-flight scrapes
+func (p *Prometheus) Shutdown(timeout time.Duration) error {
+	if p.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return p.server.Shutdown(ctx)
+}
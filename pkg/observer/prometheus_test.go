@@ -0,0 +1,92 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observer
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+func scrape(t *testing.T, p *Prometheus) string {
+	t.Helper()
+
+	srv := httptest.NewServer(p.Handler())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("scraping metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading metrics: %v", err)
+	}
+
+	return string(b)
+}
+
+func TestPrometheus(t *testing.T) {
+	p := NewPrometheus()
+	ctx := context.Background()
+
+	// Two reads finish, one is still waiting on spanner
+	for i := 0; i < 3; i++ {
+		p.Start(ctx, "Singers", "read")
+	}
+	p.Done(ctx, "Singers", "read", 1, 3*time.Millisecond, codes.OK)
+	p.Done(ctx, "Singers", "read", 1, time.Second, codes.DeadlineExceeded)
+
+	// A batch of 10 rows is written
+	p.Start(ctx, "Albums", "write")
+	p.Done(ctx, "Albums", "write", 10, 20*time.Millisecond, codes.OK)
+
+	body := scrape(t, p)
+	for _, want := range []string{
+		`gcsb_operation_duration_seconds_count{operation="read",table="Singers"} 1`,
+		`gcsb_operation_duration_seconds_count{operation="write",table="Albums"} 1`,
+		`gcsb_operations_total{operation="read",table="Singers"} 1`,
+		`gcsb_operations_total{operation="write",table="Albums"} 10`,
+		`gcsb_operation_errors_total{code="DeadlineExceeded",operation="read",table="Singers"} 1`,
+		`gcsb_operations_in_flight{operation="read",table="Singers"} 1`,
+		`gcsb_operations_in_flight{operation="write",table="Albums"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %q", want)
+		}
+	}
+}
+
+func TestPrometheusServe(t *testing.T) {
+	p := NewPrometheus()
+	if err := p.Serve("127.0.0.1:0"); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	if err := p.Shutdown(time.Second); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if err := NewPrometheus().Serve("not an address"); err == nil {
+		t.Error("Serve accepted an invalid address")
+	}
+}
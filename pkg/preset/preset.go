@@ -0,0 +1,237 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preset provides ready to run benchmarks: schemas and generator configs embedded in the
+// binary
+package preset
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/cloudspannerecosystem/gcsb/schemas"
+	"github.com/spf13/viper"
+)
+
+type (
+	// Preset is a benchmark schema together with a matching workload configuration
+	Preset struct {
+		Name        string
+		Description string
+		Schemas     []string // DDL files in package schemas, applied in order
+		Load        []string // Tables to load. Interleaved tables load their parents as well.
+		Run         []string // Tables to run against
+	}
+)
+
+var presets = []Preset{
+	{
+		Name:        "single_table",
+		Description: "One table with a single column primary key and most column types",
+		Schemas:     []string{"single_table.sql"},
+		Load:        []string{"SingleSingers"},
+		Run:         []string{"SingleSingers"},
+	},
+	{
+		Name:        "single_table_composite_key",
+		Description: "One table with a composite primary key",
+		Schemas:     []string{"single_table_composite_key.sql"},
+		Load:        []string{"SingleSingersMultiKey"},
+		Run:         []string{"SingleSingersMultiKey"},
+	},
+	{
+		Name:        "multi_table",
+		Description: "Interleaved tables with indexes and foreign keys",
+		Schemas:     []string{"multi_table.sql"},
+		Load:        []string{"Songs"},
+		Run:         []string{"Singers"},
+	},
+	{
+		Name:        "json_test",
+		Description: "One table with a JSON column",
+		Schemas:     []string{"json_test.sql"},
+		Load:        []string{"json_test"},
+		Run:         []string{"json_test"},
+	},
+	{
+		Name:        "commit_timestamp",
+		Description: "An interleaved table with a commit timestamp column",
+		Schemas:     []string{"multi_table.sql", "commit_timstamp.sql"},
+		Load:        []string{"Performances"},
+		Run:         []string{"Singers"},
+	},
+	{
+		Name:        "ycsb-a",
+		Description: "YCSB workload A: update heavy, 50% reads and 50% updates",
+		Schemas:     []string{"ycsb.sql"},
+		Load:        []string{"usertable"},
+		Run:         []string{"usertable"},
+	},
+	{
+		Name:        "ycsb-b",
+		Description: "YCSB workload B: read mostly, 95% reads and 5% updates",
+		Schemas:     []string{"ycsb.sql"},
+		Load:        []string{"usertable"},
+		Run:         []string{"usertable"},
+	},
+	{
+		Name:        "ycsb-c",
+		Description: "YCSB workload C: read only",
+		Schemas:     []string{"ycsb.sql"},
+		Load:        []string{"usertable"},
+		Run:         []string{"usertable"},
+	},
+	{
+		Name:        "ycsb-d",
+		Description: "YCSB workload D: read latest, 95% reads of recent inserts and 5% inserts",
+		Schemas:     []string{"ycsb.sql"},
+		Load:        []string{"usertable"},
+		Run:         []string{"usertable"},
+	},
+	{
+		Name:        "ycsb-e",
+		Description: "YCSB workload E: short ranges, 95% scans and 5% inserts",
+		Schemas:     []string{"ycsb.sql"},
+		Load:        []string{"usertable"},
+		Run:         []string{"usertable"},
+	},
+	{
+		Name:        "ycsb-f",
+		Description: "YCSB workload F: read-modify-write, 50% reads and 50% read-modify-writes",
+		Schemas:     []string{"ycsb.sql"},
+		Load:        []string{"usertable"},
+		Run:         []string{"usertable"},
+	},
+}
+
+// List returns all presets, sorted by name
+func List() []Preset {
+	ret := make([]Preset, len(presets))
+	copy(ret, presets)
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+
+	return ret
+}
+
+// Get returns the preset named name
+func Get(name string) (*Preset, error) {
+	for _, p := range presets {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+
+	names := make([]string, 0, len(presets))
+	for _, p := range List() {
+		names = append(names, p.Name)
+	}
+
+	return nil, fmt.Errorf("unknown preset '%s' (available: %s)", name, strings.Join(names, ", "))
+}
+
+// DDL returns the statements of the preset's schema files, separated by semicolons
+func (p *Preset) DDL() ([]byte, error) {
+	var b bytes.Buffer
+	for i, name := range p.Schemas {
+		ddl, err := fs.ReadFile(schemas.FS, name)
+		if err != nil {
+			return nil, err
+		}
+
+		// The last statement of a file may lack its semicolon
+		if i > 0 {
+			b.WriteString(";\n\n")
+		}
+		b.Write(bytes.TrimRight(ddl, " \t\r\n;"))
+	}
+	b.WriteString(";\n")
+
+	return b.Bytes(), nil
+}
+
+// Config returns a gcsb.yaml for the preset. Header is written before the generator config, it
+// should set the project, instance and database. The license header of the embedded file is
+// dropped.
+func (p *Preset) Config(header string) ([]byte, error) {
+	body, err := fs.ReadFile(schemas.FS, "presets/"+p.Name+".yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(header)
+
+	s := bufio.NewScanner(bytes.NewReader(body))
+	license := true
+	for s.Scan() {
+		line := s.Text()
+		if license {
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			license = false
+		}
+
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+
+	return b.Bytes(), s.Err()
+}
+
+// Apply merges the configuration of the preset into v. Settings of the config file, the
+// environment and flags take precedence over the preset. Apply must be called before
+// config.NewConfig, which sets the defaults.
+func (p *Preset) Apply(v *viper.Viper) error {
+	b, err := p.Config("")
+	if err != nil {
+		return err
+	}
+
+	pv := viper.New()
+	pv.SetConfigType("yaml")
+	err = pv.ReadConfig(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	settings := make(map[string]interface{})
+	for _, k := range pv.AllKeys() {
+		if v.IsSet(k) {
+			continue
+		}
+
+		// Nest the setting like it is nested in a config file
+		m := settings
+		path := strings.Split(k, ".")
+		for _, name := range path[:len(path)-1] {
+			next, ok := m[name].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				m[name] = next
+			}
+			m = next
+		}
+		m[path[len(path)-1]] = pv.Get(k)
+	}
+
+	return v.MergeConfigMap(settings)
+}
@@ -0,0 +1,167 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preset
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+	"github.com/spf13/viper"
+)
+
+func TestGet(t *testing.T) {
+	if _, err := Get("missing"); err == nil {
+		t.Error("Get of an unknown preset should fail")
+	}
+
+	names := make(map[string]bool)
+	for _, p := range List() {
+		if names[p.Name] {
+			t.Errorf("duplicate preset %s", p.Name)
+		}
+		names[p.Name] = true
+
+		if got, err := Get(p.Name); err != nil || got.Name != p.Name {
+			t.Errorf("Get(%s) = %v, %v", p.Name, got, err)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	p, err := Get("ycsb-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader([]byte("project: p\ninstance: i\ndatabase: d\noperations:\n  read: 90\n"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Apply(v); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	cfg, err := config.NewConfig(v)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	// The config file takes precedence over the preset, the preset over the defaults
+	if cfg.Operations.Read != 90 {
+		t.Errorf("operations.read = %d, want 90 from the config file", cfg.Operations.Read)
+	}
+	if cfg.Operations.Update != 5 || cfg.Operations.Write != 0 || cfg.Operations.Distribution != config.DistributionZipfian {
+		t.Errorf("operations = %+v, want the mix of the preset", cfg.Operations)
+	}
+	if len(cfg.Tables) != 1 || cfg.Tables[0].Name != "usertable" || len(cfg.Tables[0].Columns) != 11 {
+		t.Errorf("tables = %+v, want the generators of usertable", cfg.Tables)
+	}
+}
+
+// TestPresets loads and runs every preset against the in memory backend
+func TestPresets(t *testing.T) {
+	for _, p := range List() {
+		p := p
+		t.Run(p.Name, func(t *testing.T) {
+			ddl, err := p.DDL()
+			if err != nil {
+				t.Fatalf("DDL: %v", err)
+			}
+
+			ddlFile := filepath.Join(t.TempDir(), p.Name+".sql")
+			if err := ioutil.WriteFile(ddlFile, ddl, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			header := fmt.Sprintf("project: p\ninstance: i\ndatabase: d\nbackend: memory\ncreate:\n  ddl_file: %s\n", ddlFile)
+			b, err := p.Config(header)
+			if err != nil {
+				t.Fatalf("Config: %v", err)
+			}
+
+			v := viper.New()
+			v.SetConfigType("yaml")
+			if err := v.ReadConfig(bytes.NewReader(b)); err != nil {
+				t.Fatalf("reading config: %v\n%s", err, b)
+			}
+			v.Set("operations.total", 20)
+			v.Set("threads", 2)
+
+			cfg, err := config.NewConfig(v)
+			if err != nil {
+				t.Fatalf("NewConfig: %v", err)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			ctx := context.Background()
+			s, err := schema.LoadSchema(ctx, cfg)
+			if err != nil {
+				t.Fatalf("LoadSchema: %v", err)
+			}
+
+			// Every configured column exists and has a valid generator
+			for _, ct := range cfg.Tables {
+				st := s.GetTable(ct.Name)
+				if st == nil {
+					t.Fatalf("configured table %s is not in the schema", ct.Name)
+				}
+				for _, cc := range ct.Columns {
+					if st.Columns().GetColumn(cc.Name) == nil {
+						t.Errorf("configured column %s.%s is not in the schema", ct.Name, cc.Name)
+					}
+				}
+				if _, err := generator.GetDataGeneratorMapForTable(cfg, st, "load", 0); err != nil {
+					t.Errorf("generators of %s: %v", ct.Name, err)
+				}
+			}
+
+			execute := func(f func(workload.Workload) error) {
+				registry := metrics.NewRegistry()
+				wl, err := workload.NewCoreWorkload(workload.WorkloadConfig{Context: ctx, Config: cfg, Schema: s, MetricRegistry: registry})
+				if err != nil {
+					t.Fatalf("NewCoreWorkload: %v", err)
+				}
+				defer wl.Stop()
+
+				if err := f(wl); err != nil {
+					t.Fatalf("executing workload: %v", err)
+				}
+
+				registry.Each(func(name string, _ interface{}) {
+					if _, _, ok := workload.ParseErrorMetricName(name); ok {
+						t.Errorf("unexpected error metric %s", name)
+					}
+				})
+			}
+
+			execute(func(wl workload.Workload) error { return wl.Load(p.Load) })
+			for _, table := range p.Run {
+				execute(func(wl workload.Workload) error { return wl.Run(table) })
+			}
+		})
+	}
+}
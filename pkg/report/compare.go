@@ -0,0 +1,321 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/tpcc"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	StatThroughput = "throughput" // Successful operations per second
+	StatErrorRate  = "error_rate" // Failed operations / attempted operations
+)
+
+var (
+	// Configuration keys that may differ between comparable runs. Keys ending with a dot are
+	// prefixes.
+	comparableConfigKeys = []string{"seed", "report.", "metrics_addr", "otel.", "partition."}
+)
+
+type (
+	// Thresholds decide which changes between two reports are regressions
+	Thresholds struct {
+		Latency    float64 // Maximum relative increase of a latency percentile, e.g. 0.1 for 10%
+		Throughput float64 // Maximum relative decrease of a throughput
+		ErrorRate  float64 // Maximum absolute increase of the error rate, e.g. 0.001 for 0.1%
+	}
+
+	// Comparison is the difference between a baseline and a candidate report
+	Comparison struct {
+		Baseline    string   `json:"baseline"`
+		Candidate   string   `json:"candidate"`
+		Differences []string `json:"differences"` // Configuration and plan differences that make the comparison questionable
+		Deltas      []Delta  `json:"deltas"`
+	}
+
+	// Delta is the change of one statistic
+	Delta struct {
+		Metric     string   `json:"metric"`
+		Stat       string   `json:"stat"` // A percentile such as p99, throughput or error_rate
+		Baseline   float64  `json:"baseline"`
+		Candidate  float64  `json:"candidate"`
+		Change     *float64 `json:"change"`     // Relative change. Absolute for error rates. Nil when the baseline is 0
+		Regression bool     `json:"regression"` // The change exceeds its threshold
+	}
+)
+
+// Compare compares the candidate report to the baseline. Both must be reports of the same
+// phase. Configuration and plan differences are listed in Differences, it is up to the caller
+// to refuse the comparison.
+func Compare(baseline *Report, candidate *Report, th Thresholds) (*Comparison, error) {
+	if baseline.Command != candidate.Command {
+		return nil, fmt.Errorf("can not compare a %s report to a %s report", baseline.Command, candidate.Command)
+	}
+
+	c := &Comparison{
+		Differences: append(configDifferences(baseline.Config, candidate.Config), planDifferences(baseline.Plan, candidate.Plan)...),
+		Deltas:      make([]Delta, 0),
+	}
+
+	// Throughput of the whole phase and of every table
+	throughput := func(metric string, b, n float64) {
+		if b == 0 && n == 0 {
+			return
+		}
+
+		d := Delta{Metric: metric, Stat: StatThroughput, Baseline: b, Candidate: n, Change: relativeChange(b, n)}
+		d.Regression = d.Change != nil && -*d.Change > th.Throughput
+		c.Deltas = append(c.Deltas, d)
+	}
+
+	throughput("total", baseline.Throughput.Total, candidate.Throughput.Total)
+	throughput("reads", baseline.Throughput.Reads, candidate.Throughput.Reads)
+	throughput("writes", baseline.Throughput.Writes, candidate.Throughput.Writes)
+	throughput("tpmc", baseline.Throughput.TpmC, candidate.Throughput.TpmC)
+
+	ops := make(map[string]Operation)
+	for _, o := range candidate.Operations {
+		if o.Table != "" {
+			ops[o.Table+"."+o.Operation] = o
+		}
+	}
+	for _, o := range baseline.Operations {
+		if n, ok := ops[o.Table+"."+o.Operation]; ok && o.Table != "" {
+			throughput(workload.TableMetricName(o.Table, "operations."+o.Operation), o.Rate, n.Rate)
+		}
+	}
+
+	// Error rate
+	d := Delta{
+		Metric:    "total",
+		Stat:      StatErrorRate,
+		Baseline:  baseline.Throughput.ErrorRate,
+		Candidate: candidate.Throughput.ErrorRate,
+	}
+	errorRateChange := d.Candidate - d.Baseline
+	d.Change = &errorRateChange
+	d.Regression = errorRateChange > th.ErrorRate
+	c.Deltas = append(c.Deltas, d)
+
+	// Latency percentiles of operations both reports measured
+	for _, b := range baseline.Metrics {
+		if b.Type != MetricTypeTimer || !isLatencyMetric(b.Name) {
+			continue
+		}
+
+		n, ok := candidate.Metric(b.Name)
+		if !ok || n.Count == 0 || b.Count == 0 {
+			continue
+		}
+
+		for _, p := range baseline.Percentiles {
+			name := PercentileName(p)
+			bv, bok := b.Percentiles[name]
+			nv, nok := n.Percentiles[name]
+			if !bok || !nok {
+				continue
+			}
+
+			d := Delta{Metric: b.Name, Stat: name, Baseline: bv, Candidate: nv, Change: relativeChange(bv, nv)}
+			// Any latency is an unbounded increase over none
+			d.Regression = d.Change == nil || *d.Change > th.Latency
+			c.Deltas = append(c.Deltas, d)
+		}
+	}
+
+	return c, nil
+}
+
+// Regressions returns the deltas that exceed their threshold
+func (c *Comparison) Regressions() []Delta {
+	ret := make([]Delta, 0)
+	for _, d := range c.Deltas {
+		if d.Regression {
+			ret = append(ret, d)
+		}
+	}
+
+	return ret
+}
+
+// WriteTable writes the differences and deltas as ASCII tables
+func (c *Comparison) WriteTable(w io.Writer) error {
+	fmt.Fprintf(w, "Baseline: %s\nCandidate: %s\n", c.Baseline, c.Candidate)
+
+	if len(c.Differences) > 0 {
+		fmt.Fprintln(w, "The runs are configured differently:")
+		for _, d := range c.Differences {
+			fmt.Fprintf(w, "\t%s\n", d)
+		}
+	}
+
+	t := tablewriter.NewWriter(w)
+	t.SetHeader([]string{"metric", "stat", "baseline", "candidate", "change", "result"})
+	for _, d := range c.Deltas {
+		result := "ok"
+		if d.Regression {
+			result = "REGRESSION"
+		}
+
+		t.Append([]string{d.Metric, d.Stat, formatStat(d.Stat, d.Baseline), formatStat(d.Stat, d.Candidate), formatChange(d), result})
+	}
+	t.Render()
+
+	return nil
+}
+
+// WriteJSON writes the comparison as an indented JSON document
+func (c *Comparison) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(c)
+}
+
+// isLatencyMetric reports whether name is the latency of an operation, in total or of a table,
+// or of a TPC-C transaction
+func isLatencyMetric(name string) bool {
+	if _, metric, ok := workload.ParseTableMetricName(name); ok {
+		name = metric
+	}
+
+	if _, metric, ok := tpcc.ParseMetricName(name); ok {
+		return metric == "time"
+	}
+
+	return strings.HasPrefix(name, "operations.") && strings.HasSuffix(name, ".time")
+}
+
+// configDifferences lists configuration keys whose values differ
+func configDifferences(a, b map[string]interface{}) []string {
+	values := func(m map[string]interface{}) map[string]string {
+		ret := make(map[string]string)
+		flatten("", m, func(key string, value interface{}) {
+			ret[key] = formatValue(value)
+		})
+		return ret
+	}
+
+	av, bv := values(a), values(b)
+	keys := make([]string, 0, len(av)+len(bv))
+	for k := range av {
+		keys = append(keys, k)
+	}
+	for k := range bv {
+		if _, ok := av[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	ret := make([]string, 0)
+	for _, k := range keys {
+		if av[k] == bv[k] || isComparableConfigKey(k) {
+			continue
+		}
+
+		ret = append(ret, fmt.Sprintf("config %s: %q != %q", k, av[k], bv[k]))
+	}
+
+	return ret
+}
+
+func isComparableConfigKey(k string) bool {
+	for _, c := range comparableConfigKeys {
+		if k == c || (strings.HasSuffix(c, ".") && strings.HasPrefix(k, c)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// planDifferences lists targets that are missing from or planned differently in one of the plans
+func planDifferences(a, b []workload.TargetSummary) []string {
+	key := func(t workload.TargetSummary) string { return t.Phase + "." + t.Table }
+
+	bt := make(map[string]workload.TargetSummary, len(b))
+	for _, t := range b {
+		bt[key(t)] = t
+	}
+
+	ret := make([]string, 0)
+	for _, t := range a {
+		o, ok := bt[key(t)]
+		delete(bt, key(t))
+
+		switch {
+		case !ok:
+			ret = append(ret, fmt.Sprintf("plan %s: missing from candidate", key(t)))
+		case o != t:
+			ret = append(ret, fmt.Sprintf("plan %s: %+v != %+v", key(t), t, o))
+		}
+	}
+
+	for _, t := range b {
+		if _, ok := bt[key(t)]; ok {
+			ret = append(ret, fmt.Sprintf("plan %s: missing from baseline", key(t)))
+		}
+	}
+
+	return ret
+}
+
+// relativeChange returns the change of candidate relative to baseline, or nil if the baseline is
+// 0 and the candidate is not, as the change is then unbounded
+func relativeChange(baseline, candidate float64) *float64 {
+	var change float64
+	if baseline == 0 {
+		if candidate != 0 {
+			return nil
+		}
+	} else {
+		change = (candidate - baseline) / baseline
+	}
+
+	return &change
+}
+
+func formatStat(stat string, v float64) string {
+	switch stat {
+	case StatThroughput:
+		return fmt.Sprintf("%.1f/s", v)
+	case StatErrorRate:
+		return fmt.Sprintf("%.4g%%", v*100)
+	}
+
+	return time.Duration(v).String()
+}
+
+func formatChange(d Delta) string {
+	if d.Change == nil {
+		return "n/a"
+	}
+
+	if d.Stat == StatErrorRate {
+		return fmt.Sprintf("%+.4g pp", *d.Change*100)
+	}
+
+	return fmt.Sprintf("%+.1f%%", *d.Change*100)
+}
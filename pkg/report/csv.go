@@ -0,0 +1,194 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// CSV sections
+const (
+	SectionSummary     = "summary"
+	SectionConfig      = "config"
+	SectionPlan        = "plan"
+	SectionMetric      = "metric"
+	SectionError       = "error"
+	SectionOperation   = "operation"
+	SectionTransaction = "transaction"
+	SectionThroughput  = "throughput"
+	SectionAssertion   = "assertion"
+)
+
+// WriteCSV writes the report in long form with the columns section, name, field and value, so
+// that every section fits the same header. Configuration keys are flattened with dots, list
+// elements are addressed by index (e.g. tables.0.name).
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	row := func(section, name, field string, value interface{}) {
+		cw.Write([]string{section, name, field, formatValue(value)})
+	}
+
+	cw.Write([]string{"section", "name", "field", "value"})
+
+	row(SectionSummary, "", "command", r.Command)
+	if r.Workload != "" {
+		row(SectionSummary, "", "workload", r.Workload)
+	}
+	row(SectionSummary, "", "start", r.Start.Format(time.RFC3339Nano))
+	row(SectionSummary, "", "end", r.End.Format(time.RFC3339Nano))
+	row(SectionSummary, "", "elapsed_ns", int64(r.Elapsed))
+
+	flatten("", r.Config, func(key string, value interface{}) {
+		row(SectionConfig, "", key, value)
+	})
+
+	for _, t := range r.Plan {
+		name := t.Phase + "." + t.Table
+		row(SectionPlan, name, "table", t.Table)
+		row(SectionPlan, name, "phase", t.Phase)
+		row(SectionPlan, name, "operations", t.Operations)
+		row(SectionPlan, name, "read", t.Read)
+		row(SectionPlan, name, "write", t.Write)
+		row(SectionPlan, name, "update", t.Update)
+		row(SectionPlan, name, "scan", t.Scan)
+		row(SectionPlan, name, "read_modify_write", t.ReadModifyWrite)
+		row(SectionPlan, name, "distribution", t.Distribution)
+		row(SectionPlan, name, "latest", t.Latest)
+	}
+
+	for _, m := range r.Metrics {
+		row(SectionMetric, m.Name, "type", m.Type)
+		row(SectionMetric, m.Name, "count", m.Count)
+
+		switch m.Type {
+		case MetricTypeTimer:
+			row(SectionMetric, m.Name, "min_ns", m.Min)
+			row(SectionMetric, m.Name, "max_ns", m.Max)
+			row(SectionMetric, m.Name, "mean_ns", m.Mean)
+			row(SectionMetric, m.Name, "stddev_ns", m.StdDev)
+			for _, p := range r.Percentiles {
+				name := PercentileName(p)
+				row(SectionMetric, m.Name, name+"_ns", m.Percentiles[name])
+			}
+			if m.Histogram != "" {
+				row(SectionMetric, m.Name, "histogram", m.Histogram)
+			}
+		case MetricTypeMeter:
+			row(SectionMetric, m.Name, "rate", m.Rate)
+		}
+	}
+
+	for _, e := range r.Errors {
+		name := e.Operation + "." + e.Code
+		row(SectionError, name, "failed_attempts", e.FailedAttempts)
+		row(SectionError, name, "errors", e.Errors)
+	}
+
+	for _, o := range r.Operations {
+		name := o.Operation
+		if o.Table != "" {
+			name = o.Table + "." + o.Operation
+		}
+		row(SectionOperation, name, "rows", o.Rows)
+		row(SectionOperation, name, "errors", o.Errors)
+		row(SectionOperation, name, "rate", o.Rate)
+		row(SectionOperation, name, "mean_ns", o.Mean)
+		for _, p := range r.Percentiles {
+			pn := PercentileName(p)
+			row(SectionOperation, name, pn+"_ns", o.Percentiles[pn])
+		}
+	}
+
+	for _, t := range r.Transactions {
+		row(SectionTransaction, t.Transaction, "count", t.Count)
+		row(SectionTransaction, t.Transaction, "rollbacks", t.Rollbacks)
+		row(SectionTransaction, t.Transaction, "errors", t.Errors)
+		row(SectionTransaction, t.Transaction, "rate", t.Rate)
+		row(SectionTransaction, t.Transaction, "mean_ns", t.Mean)
+		for _, p := range r.Percentiles {
+			pn := PercentileName(p)
+			row(SectionTransaction, t.Transaction, pn+"_ns", t.Percentiles[pn])
+		}
+	}
+
+	row(SectionThroughput, "", "operations", r.Throughput.Operations)
+	row(SectionThroughput, "", "errors", r.Throughput.Errors)
+	row(SectionThroughput, "", "error_rate", r.Throughput.ErrorRate)
+	row(SectionThroughput, "", "reads", r.Throughput.Reads)
+	row(SectionThroughput, "", "writes", r.Throughput.Writes)
+	row(SectionThroughput, "", "total", r.Throughput.Total)
+	if len(r.Transactions) > 0 {
+		row(SectionThroughput, "", "tpmc", r.Throughput.TpmC)
+	}
+
+	for _, a := range r.Assertions {
+		row(SectionAssertion, a.Expr, "actual", a.Actual)
+		row(SectionAssertion, a.Expr, "passed", a.Passed)
+		if a.Error != "" {
+			row(SectionAssertion, a.Expr, "error", a.Error)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// flatten calls fn for every leaf of a decoded JSON document in key order
+func flatten(prefix string, v interface{}, fn func(key string, value interface{})) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			flatten(join(k), t[k], fn)
+		}
+	case []interface{}:
+		for i, e := range t {
+			flatten(join(strconv.Itoa(i)), e, fn)
+		}
+	default:
+		fn(prefix, v)
+	}
+}
+
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,354 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/histogram"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+)
+
+// Read decodes a report written by WriteJSON
+func Read(r io.Reader) (*Report, error) {
+	var rep Report
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	err := dec.Decode(&rep)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rep, nil
+}
+
+// Merge combines the reports of processes that executed parts of the same phase into one report
+// over the union of their time windows. Counts are summed and rates recomputed over the total
+// length of the union, so that gaps between the windows do not dilute them. Start and End of the
+// merged report are the outer bounds of the windows. Timers with an encoded HDR histogram are
+// merged exactly. Percentiles of other timers are the maximum of the merged reports and so only
+// an upper bound.
+//
+// Merge does not check that the reports are of the same workload, see MergeDifferences. The
+// configuration of the first report is kept without its partition. Assertions are not merged,
+// evaluate them against Registry of the merged report instead.
+func Merge(reports ...*Report) (*Report, error) {
+	if len(reports) == 0 {
+		return nil, errors.New("no reports to merge")
+	}
+
+	first := reports[0]
+	r := &Report{
+		Command:     first.Command,
+		Workload:    first.Workload,
+		Start:       first.Start,
+		End:         first.End,
+		Percentiles: first.Percentiles,
+		Config:      make(map[string]interface{}, len(first.Config)),
+		Plan:        make([]workload.TargetSummary, 0),
+		Metrics:     make([]Metric, 0),
+	}
+
+	for k, v := range first.Config {
+		if k != "partition" {
+			r.Config[k] = v
+		}
+	}
+
+	for _, rep := range reports {
+		if rep.Command != r.Command {
+			return nil, fmt.Errorf("can not merge %s and %s reports", r.Command, rep.Command)
+		}
+
+		if rep.Start.Before(r.Start) {
+			r.Start = rep.Start
+		}
+		if rep.End.After(r.End) {
+			r.End = rep.End
+		}
+	}
+	r.Elapsed = unionLength(reports)
+
+	r.Plan = mergePlans(reports)
+	r.Errors = mergeErrors(reports)
+
+	byName := make(map[string][]Metric)
+	names := make([]string, 0)
+	for _, rep := range reports {
+		for _, m := range rep.Metrics {
+			if _, ok := byName[m.Name]; !ok {
+				names = append(names, m.Name)
+			}
+			byName[m.Name] = append(byName[m.Name], m)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m, err := r.mergeMetric(byName[name])
+		if err != nil {
+			return nil, fmt.Errorf("merging %s: %s", name, err.Error())
+		}
+
+		r.Metrics = append(r.Metrics, m)
+	}
+
+	r.summarize()
+
+	return r, nil
+}
+
+// MergeDifferences lists the configuration keys and planned targets of reports that differ from
+// the first report. Keys that differ between processes of the same workload, e.g. the partition
+// or the report file, and the number of planned operations are ignored.
+func MergeDifferences(reports ...*Report) []string {
+	ret := make([]string, 0)
+	if len(reports) == 0 {
+		return ret
+	}
+
+	first := reports[0]
+	for i, rep := range reports[1:] {
+		diffs := append(configDifferences(first.Config, rep.Config), planDifferences(planTargets(first.Plan), planTargets(rep.Plan))...)
+		for _, d := range diffs {
+			ret = append(ret, fmt.Sprintf("report %d: %s", i+2, d))
+		}
+	}
+
+	return ret
+}
+
+// planTargets returns the plan without the number of operations, which are shared out among
+// processes
+func planTargets(plan []workload.TargetSummary) []workload.TargetSummary {
+	ret := make([]workload.TargetSummary, len(plan))
+	for i, t := range plan {
+		t.Operations = 0
+		ret[i] = t
+	}
+
+	return ret
+}
+
+// unionLength returns the total length of the union of the time windows of reports
+func unionLength(reports []*Report) time.Duration {
+	windows := make([]*Report, len(reports))
+	copy(windows, reports)
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+
+	var ret time.Duration
+	start, end := windows[0].Start, windows[0].End
+	for _, w := range windows[1:] {
+		if w.Start.After(end) {
+			ret += end.Sub(start)
+			start, end = w.Start, w.End
+			continue
+		}
+
+		if w.End.After(end) {
+			end = w.End
+		}
+	}
+
+	return ret + end.Sub(start)
+}
+
+// mergeMetric merges the same metric of several reports
+func (r *Report) mergeMetric(ms []Metric) (Metric, error) {
+	ret := Metric{Name: ms[0].Name, Type: ms[0].Type}
+	for _, m := range ms {
+		if m.Type != ret.Type {
+			return ret, fmt.Errorf("metric is a %s and a %s", ret.Type, m.Type)
+		}
+		ret.Count += m.Count
+	}
+
+	switch ret.Type {
+	case MetricTypeMeter:
+		ret.Rate = rate(ret.Count, r.Elapsed)
+		return ret, nil
+	case MetricTypeCounter:
+		return ret, nil
+	case MetricTypeTimer:
+	default:
+		return ret, fmt.Errorf("unknown metric type '%s'", ret.Type)
+	}
+
+	exact := true
+	for _, m := range ms {
+		if m.Histogram == "" {
+			exact = false
+		}
+	}
+
+	if exact {
+		var t *histogram.Timer
+		for _, m := range ms {
+			h, err := histogram.Decode(m.Histogram)
+			if err != nil {
+				return ret, fmt.Errorf("decoding histogram: %s", err.Error())
+			}
+
+			if t == nil {
+				t = histogram.NewTimerFrom(h)
+				continue
+			}
+
+			if dropped := t.Merge(h); dropped > 0 {
+				return ret, fmt.Errorf("%d values out of the histogram range", dropped)
+			}
+		}
+
+		m, _, err := newMetric(ret.Name, t, r.Elapsed, r.Percentiles)
+		return m, err
+	}
+
+	// Without histograms only the moments can be combined exactly
+	var sum, squares float64
+	ret.Percentiles = make(map[string]float64)
+	for i, m := range ms {
+		if m.Count == 0 {
+			continue
+		}
+
+		if i == 0 || m.Min < ret.Min {
+			ret.Min = m.Min
+		}
+		if m.Max > ret.Max {
+			ret.Max = m.Max
+		}
+
+		sum += m.Mean * float64(m.Count)
+		squares += (m.StdDev*m.StdDev + m.Mean*m.Mean) * float64(m.Count)
+
+		for k, v := range m.Percentiles {
+			if v > ret.Percentiles[k] {
+				ret.Percentiles[k] = v
+			}
+		}
+	}
+
+	if ret.Count > 0 {
+		ret.Mean = sum / float64(ret.Count)
+		ret.StdDev = math.Sqrt(math.Max(0, squares/float64(ret.Count)-ret.Mean*ret.Mean))
+	}
+
+	return ret, nil
+}
+
+// mergePlans sums the operations of targets with the same phase and table
+func mergePlans(reports []*Report) []workload.TargetSummary {
+	ret := make([]workload.TargetSummary, 0)
+	index := make(map[string]int)
+
+	for _, rep := range reports {
+		for _, t := range rep.Plan {
+			k := t.Phase + "." + t.Table
+			if i, ok := index[k]; ok {
+				ret[i].Operations += t.Operations
+				continue
+			}
+
+			index[k] = len(ret)
+			ret = append(ret, t)
+		}
+	}
+
+	return ret
+}
+
+// mergeErrors sums failures with the same operation and code
+func mergeErrors(reports []*Report) []Error {
+	ret := make([]Error, 0)
+	index := make(map[string]int)
+
+	for _, rep := range reports {
+		for _, e := range rep.Errors {
+			k := e.Operation + "." + e.Code
+			if i, ok := index[k]; ok {
+				ret[i].FailedAttempts += e.FailedAttempts
+				ret[i].Errors += e.Errors
+				continue
+			}
+
+			index[k] = len(ret)
+			ret = append(ret, e)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Operation != ret[j].Operation {
+			return ret[i].Operation < ret[j].Operation
+		}
+		return ret[i].Code < ret[j].Code
+	})
+
+	return ret
+}
+
+// Registry rebuilds a metrics registry from the report, so that assertions can be evaluated
+// against a merged report. Timers are only restored from encoded histograms.
+func (r *Report) Registry() (metrics.Registry, error) {
+	registry := metrics.NewRegistry()
+
+	for _, m := range r.Metrics {
+		var err error
+
+		switch m.Type {
+		case MetricTypeTimer:
+			if m.Histogram == "" {
+				continue
+			}
+
+			h, derr := histogram.Decode(m.Histogram)
+			if derr != nil {
+				return nil, fmt.Errorf("decoding histogram %s: %s", m.Name, derr.Error())
+			}
+			err = registry.Register(m.Name, histogram.NewTimerFrom(h))
+		case MetricTypeMeter:
+			err = registry.Register(m.Name, countMeter(m.Count))
+		case MetricTypeCounter:
+			c := metrics.NewCounter()
+			c.Inc(m.Count)
+			err = registry.Register(m.Name, c)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("registering %s: %s", m.Name, err.Error())
+		}
+	}
+
+	return registry, nil
+}
+
+// countMeter is a meter restored from a report. Only its count is known.
+type countMeter int64
+
+func (m countMeter) Count() int64            { return int64(m) }
+func (m countMeter) Mark(int64)              {}
+func (m countMeter) Rate1() float64          { return 0 }
+func (m countMeter) Rate5() float64          { return 0 }
+func (m countMeter) Rate15() float64         { return 0 }
+func (m countMeter) RateMean() float64       { return 0 }
+func (m countMeter) Snapshot() metrics.Meter { return m }
+func (m countMeter) Stop()                   {}
@@ -0,0 +1,566 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// package report collects the results of a load or run phase into a Report that can be written
+// as an ASCII table for humans or as JSON or CSV for automation.
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/assertion"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/histogram"
+	"github.com/cloudspannerecosystem/gcsb/pkg/tpcc"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	MetricTypeTimer   = "timer"
+	MetricTypeMeter   = "meter"
+	MetricTypeCounter = "counter"
+
+	redacted = "REDACTED"
+)
+
+var (
+	// Operation types in the order they are reported
+	operations = []string{
+		workload.OperationRead,
+		workload.OperationWrite,
+		workload.OperationUpdate,
+		workload.OperationScan,
+		workload.OperationReadModifyWrite,
+	}
+)
+
+type (
+	// Report is the result of a phase
+	Report struct {
+		Command      string                   `json:"command"`            // load or run
+		Workload     string                   `json:"workload,omitempty"` // Preset the configuration is based on
+		Start        time.Time                `json:"start"`
+		End          time.Time                `json:"end"`
+		Elapsed      time.Duration            `json:"elapsed_ns"`
+		Percentiles  []float64                `json:"percentiles"` // Percentiles reported for every timer
+		Config       map[string]interface{}   `json:"config"`      // Resolved configuration with secrets redacted
+		Plan         []workload.TargetSummary `json:"plan"`
+		Metrics      []Metric                 `json:"metrics"`
+		Errors       []Error                  `json:"errors"`
+		Operations   []Operation              `json:"operations"`             // Per table breakdown and totals
+		Transactions []Transaction            `json:"transactions,omitempty"` // TPC-C runs only
+		Throughput   Throughput               `json:"throughput"`
+		Assertions   []Assertion              `json:"assertions,omitempty"`
+	}
+
+	// Metric is a snapshot of a metric in the registry. Timer statistics are in nanoseconds.
+	Metric struct {
+		Name        string             `json:"name"`
+		Type        string             `json:"type"` // timer, meter or counter
+		Count       int64              `json:"count"`
+		Min         int64              `json:"min_ns,omitempty"`
+		Max         int64              `json:"max_ns,omitempty"`
+		Mean        float64            `json:"mean_ns,omitempty"`
+		StdDev      float64            `json:"stddev_ns,omitempty"`
+		Percentiles map[string]float64 `json:"percentiles_ns,omitempty"` // Keyed by percentile, e.g. p99 or p99.9
+		Rate        float64            `json:"rate,omitempty"`           // Meters only. Events per second over the phase
+		Histogram   string             `json:"histogram,omitempty"`      // HDR timers only. See histogram.Decode
+	}
+
+	// Error counts failures of an operation type with a gRPC code. Failed attempts include
+	// attempts that were retried, errors are the final outcome of operations.
+	Error struct {
+		Operation      string `json:"operation"`
+		Code           string `json:"code"`
+		FailedAttempts int64  `json:"failed_attempts"`
+		Errors         int64  `json:"errors"`
+	}
+
+	// Operation summarizes the successful operations of a type on a table. Table is empty for
+	// the totals of all tables. Latencies are in nanoseconds.
+	Operation struct {
+		Table       string             `json:"table,omitempty"`
+		Operation   string             `json:"operation"`
+		Rows        int64              `json:"rows"`   // Rows read or written. Scans count once each
+		Errors      int64              `json:"errors"` // Failed operations
+		Rate        float64            `json:"rate"`   // Rows per second
+		Mean        float64            `json:"mean_ns"`
+		Percentiles map[string]float64 `json:"percentiles_ns"`
+	}
+
+	// Transaction summarizes the transactions of a type of a TPC-C run, see package tpcc.
+	// Latencies are in nanoseconds.
+	Transaction struct {
+		Transaction string             `json:"transaction"`
+		Count       int64              `json:"count"`     // Completed transactions, including rolled back New-Orders
+		Rollbacks   int64              `json:"rollbacks"` // New-Orders rolled back on purpose
+		Errors      int64              `json:"errors"`    // Failed transactions
+		Rate        float64            `json:"rate"`      // Completed transactions per minute
+		Mean        float64            `json:"mean_ns"`
+		Percentiles map[string]float64 `json:"percentiles_ns"`
+	}
+
+	// Throughput summarizes the operations of the phase. Operations are counted per row, so a
+	// failed batch counts as many failed operations as it had mutations. TPC-C transactions
+	// count as one operation each.
+	Throughput struct {
+		Operations int64   `json:"operations"`     // Attempted operations
+		Errors     int64   `json:"errors"`         // Failed operations
+		ErrorRate  float64 `json:"error_rate"`     // Errors / operations
+		Reads      float64 `json:"reads"`          // Successful reads and scans per second
+		Writes     float64 `json:"writes"`         // Successful writes, updates and read-modify-writes per second
+		Total      float64 `json:"total"`          // Successful operations per second
+		TpmC       float64 `json:"tpmc,omitempty"` // New-Order transactions per minute of TPC-C runs
+	}
+
+	// Assertion is the outcome of an assertion
+	Assertion struct {
+		Expr   string `json:"expr"`
+		Actual string `json:"actual"`
+		Passed bool   `json:"passed"`
+		Error  string `json:"error,omitempty"`
+	}
+)
+
+// New collects a report of a phase that ran from start to end
+func New(command string, start time.Time, end time.Time, cfg *config.Config, plan []workload.TargetSummary, registry metrics.Registry) (*Report, error) {
+	c, err := configMap(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("encoding config: %s", err.Error())
+	}
+
+	if plan == nil {
+		plan = make([]workload.TargetSummary, 0)
+	}
+
+	percentiles := config.DefaultReportPercentiles
+	if cfg != nil && len(cfg.Report.Percentiles) > 0 {
+		percentiles = cfg.Report.Percentiles
+	}
+
+	r := &Report{
+		Command:     command,
+		Workload:    workloadName(cfg),
+		Percentiles: percentiles,
+		Start:       start,
+		End:         end,
+		Elapsed:     end.Sub(start),
+		Config:      c,
+		Plan:        plan,
+		Metrics:     make([]Metric, 0),
+		Errors:      collectErrors(registry),
+	}
+
+	registry.Each(func(name string, i interface{}) {
+		if err != nil {
+			return
+		}
+
+		var m Metric
+		var ok bool
+		m, ok, err = newMetric(name, i, r.Elapsed, percentiles)
+		if ok {
+			r.Metrics = append(r.Metrics, m)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(r.Metrics, func(i, j int) bool { return r.Metrics[i].Name < r.Metrics[j].Name })
+	r.summarize()
+
+	return r, nil
+}
+
+// ForWorkload collects the report of a phase executed by wl between start and end and evaluates
+// the assertions of cfg against it. Rates are computed over the execution of the plan of wl, not
+// its planning and sampling. wl may be nil for workloads that do not plan targets.
+func ForWorkload(command string, start time.Time, end time.Time, cfg *config.Config, wl workload.Workload, registry metrics.Registry) (*Report, error) {
+	start, end = workload.ExecutionWindow(wl, start, end)
+
+	var plan []workload.TargetSummary
+	if p, ok := wl.(workload.PlanSummarizer); ok {
+		plan = p.PlanSummary()
+	}
+
+	r, err := New(command, start, end, cfg, plan, registry)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Assertions) > 0 {
+		as, err := assertion.ParseAll(cfg.Assertions)
+		if err != nil {
+			return nil, fmt.Errorf("parsing assertions: %s", err.Error())
+		}
+		r.AddAssertions(assertion.Evaluate(as, r.Snapshot(registry)))
+	}
+
+	return r, nil
+}
+
+// workloadName returns the preset cfg is based on, if any
+func workloadName(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+
+	return cfg.Workload
+}
+
+// summarize computes the operations breakdown and throughput from metrics and errors
+func (r *Report) summarize() {
+	r.Operations = r.collectOperations()
+
+	r.Throughput = Throughput{}
+	for _, e := range r.Errors {
+		r.Throughput.Errors += e.Errors
+	}
+
+	// Scans count as reads, updates and read-modify-writes as writes
+	var reads, writes int64
+	for _, op := range []string{workload.OperationRead, workload.OperationScan} {
+		if m, ok := r.Metric("operations." + op + ".rate"); ok {
+			reads += m.Count
+		}
+	}
+	for _, op := range []string{workload.OperationWrite, workload.OperationUpdate, workload.OperationReadModifyWrite} {
+		if m, ok := r.Metric("operations." + op + ".rate"); ok {
+			writes += m.Count
+		}
+	}
+
+	r.Transactions = r.collectTransactions()
+	var transactions int64
+	for _, t := range r.Transactions {
+		transactions += t.Count
+		if t.Transaction == tpcc.NewOrder {
+			r.Throughput.TpmC = t.Rate
+		}
+	}
+
+	r.Throughput.Operations = reads + writes + transactions + r.Throughput.Errors
+	if r.Throughput.Operations > 0 {
+		r.Throughput.ErrorRate = float64(r.Throughput.Errors) / float64(r.Throughput.Operations)
+	}
+	r.Throughput.Reads = rate(reads, r.Elapsed)
+	r.Throughput.Writes = rate(writes, r.Elapsed)
+	r.Throughput.Total = rate(reads+writes+transactions, r.Elapsed)
+}
+
+// Metric returns the metric with the given name
+func (r *Report) Metric(name string) (Metric, bool) {
+	for _, m := range r.Metrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+
+	return Metric{}, false
+}
+
+// Snapshot returns the state assertions are evaluated against
+func (r *Report) Snapshot(registry metrics.Registry) assertion.Snapshot {
+	return assertion.Snapshot{
+		Registry:   registry,
+		Elapsed:    r.Elapsed,
+		Operations: r.Throughput.Operations,
+		Errors:     r.Throughput.Errors,
+	}
+}
+
+// AddAssertions records the outcome of assertions
+func (r *Report) AddAssertions(results []assertion.Result) {
+	for _, res := range results {
+		a := Assertion{Expr: res.Assertion.Expr, Actual: res.Actual, Passed: res.Passed}
+		if res.Err != nil {
+			a.Error = res.Err.Error()
+		}
+
+		r.Assertions = append(r.Assertions, a)
+	}
+}
+
+// Write writes the report in one of the config.ReportFormat* formats
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case config.ReportFormatJSON:
+		return r.WriteJSON(w)
+	case config.ReportFormatCSV:
+		return r.WriteCSV(w)
+	case config.ReportFormatTable:
+		return r.WriteTable(w)
+	}
+
+	return fmt.Errorf("unknown report format '%s'", format)
+}
+
+// WriteJSON writes the report as an indented JSON document
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// collectErrors returns the failures recorded in the registry, sorted by operation and code
+func collectErrors(registry metrics.Registry) []Error {
+	rows := make(map[string]*Error)
+	get := func(op, code string) *Error {
+		k := op + "." + code
+		if rows[k] == nil {
+			rows[k] = &Error{Operation: op, Code: code}
+		}
+		return rows[k]
+	}
+
+	registry.Each(func(name string, i interface{}) {
+		c, ok := i.(metrics.Counter)
+		if !ok {
+			return
+		}
+
+		if op, code, ok := workload.ParseErrorMetricName(name); ok {
+			get(op, code).Errors += c.Count()
+		}
+
+		if op, code, ok := workload.ParseAttemptErrorMetricName(name); ok {
+			get(op, code).FailedAttempts += c.Count()
+		}
+	})
+
+	ret := make([]Error, 0, len(rows))
+	for _, e := range rows {
+		ret = append(ret, *e)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Operation != ret[j].Operation {
+			return ret[i].Operation < ret[j].Operation
+		}
+		return ret[i].Code < ret[j].Code
+	})
+
+	return ret
+}
+
+// collectOperations breaks every operation type down by table. Every operation type is followed by
+// the totals of all tables.
+func (r *Report) collectOperations() []Operation {
+	byName := make(map[string]Metric, len(r.Metrics))
+	for _, m := range r.Metrics {
+		byName[m.Name] = m
+	}
+
+	// Tables with metrics and their errors, keyed by table and operation
+	tables := make(map[string]bool)
+	errors := make(map[string]int64)
+	for _, m := range r.Metrics {
+		table, metric, ok := workload.ParseTableMetricName(m.Name)
+		if !ok {
+			continue
+		}
+		tables[table] = true
+
+		if m.Type == MetricTypeCounter {
+			if op, _, ok := workload.ParseErrorMetricName(metric); ok {
+				errors[table+"."+op] += m.Count
+			}
+		}
+	}
+
+	names := make([]string, 0, len(tables))
+	for t := range tables {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+
+	totalErrors := make(map[string]int64)
+	for _, e := range r.Errors {
+		totalErrors[e.Operation] += e.Errors
+	}
+
+	ret := make([]Operation, 0)
+	for _, op := range operations {
+		prefix := "operations." + op + "."
+
+		var n int
+		for _, t := range names {
+			o := r.newOperation(byName, t, op, workload.TableMetricName(t, prefix), errors[t+"."+op])
+			if o.Rows > 0 || o.Errors > 0 {
+				ret = append(ret, o)
+				n++
+			}
+		}
+
+		o := r.newOperation(byName, "", op, prefix, totalErrors[op])
+		if n > 0 || o.Rows > 0 || o.Errors > 0 {
+			ret = append(ret, o)
+		}
+	}
+
+	return ret
+}
+
+// collectTransactions summarizes the transaction types of a TPC-C run in the order of the mix
+func (r *Report) collectTransactions() []Transaction {
+	errors := make(map[string]int64)
+	for _, e := range r.Errors {
+		errors[e.Operation] += e.Errors
+	}
+
+	ret := make([]Transaction, 0)
+	for _, txn := range tpcc.Transactions {
+		t := Transaction{Transaction: txn, Errors: errors[txn], Percentiles: make(map[string]float64, len(r.Percentiles))}
+
+		timer, ok := r.Metric(tpcc.MetricName(txn, "time"))
+		if !ok {
+			continue
+		}
+
+		t.Count = timer.Count
+		t.Rate = rate(t.Count, r.Elapsed) * 60
+		t.Mean = timer.Mean
+		for k, v := range timer.Percentiles {
+			t.Percentiles[k] = v
+		}
+
+		if m, ok := r.Metric(tpcc.MetricName(txn, "rollbacks")); ok {
+			t.Rollbacks = m.Count
+		}
+
+		ret = append(ret, t)
+	}
+
+	return ret
+}
+
+// newOperation summarizes the <prefix>time timer and <prefix>rate meter
+func (r *Report) newOperation(byName map[string]Metric, table string, op string, prefix string, errors int64) Operation {
+	o := Operation{
+		Table:       table,
+		Operation:   op,
+		Errors:      errors,
+		Rows:        byName[prefix+"rate"].Count,
+		Percentiles: make(map[string]float64, len(r.Percentiles)),
+	}
+	o.Rate = rate(o.Rows, r.Elapsed)
+
+	if t, ok := byName[prefix+"time"]; ok {
+		o.Mean = t.Mean
+		for k, v := range t.Percentiles {
+			o.Percentiles[k] = v
+		}
+	}
+
+	return o
+}
+
+func newMetric(name string, i interface{}, elapsed time.Duration, percentiles []float64) (Metric, bool, error) {
+	m := Metric{Name: name}
+
+	switch t := i.(type) {
+	case metrics.Timer:
+		s := t.Snapshot()
+		m.Type = MetricTypeTimer
+		m.Count = s.Count()
+		m.Min = s.Min()
+		m.Max = s.Max()
+		m.Mean = s.Mean()
+		m.StdDev = s.StdDev()
+
+		qs := make([]float64, len(percentiles))
+		for i, p := range percentiles {
+			qs[i] = p / 100
+		}
+
+		m.Percentiles = make(map[string]float64, len(percentiles))
+		for i, v := range s.Percentiles(qs) {
+			m.Percentiles[PercentileName(percentiles[i])] = v
+		}
+
+		if h, ok := s.(*histogram.Timer); ok {
+			enc, err := histogram.Encode(h.Histogram())
+			if err != nil {
+				return m, false, fmt.Errorf("encoding histogram %s: %s", name, err.Error())
+			}
+			m.Histogram = enc
+		}
+	case metrics.Meter:
+		m.Type = MetricTypeMeter
+		m.Count = t.Count()
+		m.Rate = rate(m.Count, elapsed)
+	case metrics.Counter:
+		m.Type = MetricTypeCounter
+		m.Count = t.Count()
+	default:
+		return m, false, nil
+	}
+
+	return m, true, nil
+}
+
+// PercentileName names a percentile such as 99.9 as p99.9
+func PercentileName(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// configMap encodes the configuration as a generic map keyed like the configuration file
+func configMap(cfg *config.Config) (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+	if cfg == nil {
+		return ret, nil
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep numbers as written rather than converting them to float64
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err = dec.Decode(&ret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Headers usually carry credentials for the collector
+	if o, ok := ret["otel"].(map[string]interface{}); ok {
+		if hs, ok := o["headers"].([]interface{}); ok {
+			for i, h := range hs {
+				k := strings.SplitN(fmt.Sprint(h), "=", 2)[0]
+				hs[i] = strings.TrimSpace(k) + "=" + redacted
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+func rate(n int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(n) / elapsed.Seconds()
+}
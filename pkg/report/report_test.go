@@ -0,0 +1,584 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/assertion"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/histogram"
+	"github.com/cloudspannerecosystem/gcsb/pkg/tpcc"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestReport() (*Report, metrics.Registry) {
+	registry := metrics.NewRegistry()
+
+	tmr := metrics.GetOrRegisterTimer("operations.read.time", registry)
+	for i := 1; i <= 100; i++ {
+		tmr.Update(time.Duration(i) * time.Millisecond)
+	}
+
+	metrics.GetOrRegisterMeter("operations.read.rate", registry).Mark(90)
+	metrics.GetOrRegisterMeter("operations.write.rate", registry).Mark(0)
+	metrics.GetOrRegisterCounter(workload.ErrorMetricName(workload.OperationRead, codes.Unavailable), registry).Inc(10)
+	metrics.GetOrRegisterCounter(workload.AttemptErrorMetricName(workload.OperationRead, codes.Unavailable), registry).Inc(25)
+
+	cfg := &config.Config{
+		Project: "p",
+		Threads: 10,
+		OTel:    config.OTel{Headers: []string{"authorization=Bearer secret"}},
+		Report:  config.Report{Format: config.ReportFormatJSON},
+	}
+
+	plan := []workload.TargetSummary{{Table: "Singers", Phase: "RUN", Operations: 100, Read: 50, Write: 50}}
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	r, err := New("run", start, start.Add(10*time.Second), cfg, plan, registry)
+	if err != nil {
+		panic(err)
+	}
+
+	return r, registry
+}
+
+func TestNew(t *testing.T) {
+	Convey("New", t, func() {
+		r, registry := newTestReport()
+
+		So(r.Elapsed, ShouldEqual, 10*time.Second)
+		So(r.Plan, ShouldHaveLength, 1)
+
+		Convey("Metrics", func() {
+			names := make([]string, 0)
+			for _, m := range r.Metrics {
+				names = append(names, m.Name)
+			}
+			So(names, ShouldResemble, []string{
+				"operations.read.attempt_errors.Unavailable",
+				"operations.read.errors.Unavailable",
+				"operations.read.rate",
+				"operations.read.time",
+				"operations.write.rate",
+			})
+
+			tmr := r.Metrics[3]
+			So(tmr.Type, ShouldEqual, MetricTypeTimer)
+			So(tmr.Count, ShouldEqual, 100)
+			So(tmr.Min, ShouldEqual, int64(time.Millisecond))
+			So(tmr.Max, ShouldEqual, int64(100*time.Millisecond))
+			So(tmr.Percentiles, ShouldContainKey, "p99.9")
+			So(tmr.Percentiles["p50"], ShouldAlmostEqual, float64(50500*time.Microsecond))
+
+			So(r.Metrics[2].Type, ShouldEqual, MetricTypeMeter)
+			So(r.Metrics[2].Rate, ShouldEqual, 9)
+		})
+
+		Convey("Errors and throughput", func() {
+			So(r.Errors, ShouldResemble, []Error{{Operation: "read", Code: "Unavailable", FailedAttempts: 25, Errors: 10}})
+			So(r.Throughput.Operations, ShouldEqual, 100)
+			So(r.Throughput.Errors, ShouldEqual, 10)
+			So(r.Throughput.ErrorRate, ShouldEqual, 0.1)
+			So(r.Throughput.Reads, ShouldEqual, 9)
+			So(r.Throughput.Total, ShouldEqual, 9)
+
+			s := r.Snapshot(registry)
+			So(s.Operations, ShouldEqual, 100)
+			So(s.Errors, ShouldEqual, 10)
+			So(s.Elapsed, ShouldEqual, 10*time.Second)
+		})
+
+		Convey("Config", func() {
+			So(r.Config["project"], ShouldEqual, "p")
+			So(r.Config["threads"], ShouldEqual, json.Number("10"))
+
+			otel := r.Config["otel"].(map[string]interface{})
+			So(otel["headers"], ShouldResemble, []interface{}{"authorization=REDACTED"})
+		})
+
+		Convey("HDR timers", func() {
+			registry := metrics.NewRegistry()
+			tmr := histogram.GetOrRegisterTimer("operations.write.time", registry, config.Histogram{})
+			for i := 1; i <= 10000; i++ {
+				tmr.Update(time.Duration(i) * time.Microsecond)
+			}
+
+			cfg := &config.Config{Report: config.Report{Percentiles: []float64{99.99}}}
+			r, err := New("run", time.Now(), time.Now(), cfg, nil, registry)
+			So(err, ShouldBeNil)
+			So(r.Percentiles, ShouldResemble, []float64{99.99})
+			So(r.Metrics[0].Percentiles, ShouldResemble, map[string]float64{"p99.99": tmr.Percentile(0.9999)})
+
+			// The encoded histogram restores the exact distribution
+			h, err := histogram.Decode(r.Metrics[0].Histogram)
+			So(err, ShouldBeNil)
+			So(h.Equals(tmr.(*histogram.Timer).Histogram()), ShouldBeTrue)
+		})
+
+		Convey("Operations by table", func() {
+			registry := metrics.NewRegistry()
+			for _, t := range []struct {
+				table string
+				rows  int64
+				d     time.Duration
+			}{
+				{"Albums", 30, 100 * time.Millisecond},
+				{"Singers", 70, time.Millisecond},
+			} {
+				tm := workload.NewTableMetrics(registry, t.table, config.Histogram{})
+				tm.RecordWrite(t.d, int(t.rows))
+				histogram.GetOrRegisterTimer("operations.write.time", registry, config.Histogram{}).Update(t.d)
+				metrics.GetOrRegisterMeter("operations.write.rate", registry).Mark(t.rows)
+			}
+			tm := workload.NewTableMetrics(registry, "Albums", config.Histogram{})
+			tm.RecordError(workload.OperationWrite, 5, status.Error(codes.Aborted, "aborted"))
+			metrics.GetOrRegisterCounter(workload.ErrorMetricName(workload.OperationWrite, codes.Aborted), registry).Inc(5)
+
+			start := time.Now()
+			r, err := New("load", start, start.Add(10*time.Second), nil, nil, registry)
+			So(err, ShouldBeNil)
+			So(r.Operations, ShouldHaveLength, 3)
+
+			albums, singers, total := r.Operations[0], r.Operations[1], r.Operations[2]
+			So(albums.Table, ShouldEqual, "Albums")
+			So(albums.Rows, ShouldEqual, 30)
+			So(albums.Errors, ShouldEqual, 5)
+			So(albums.Rate, ShouldEqual, 3)
+			So(albums.Percentiles["p99"], ShouldBeGreaterThan, float64(99*time.Millisecond))
+
+			So(singers.Table, ShouldEqual, "Singers")
+			So(singers.Errors, ShouldEqual, 0)
+			So(singers.Percentiles["p99"], ShouldBeLessThan, float64(2*time.Millisecond))
+
+			So(total.Table, ShouldEqual, "")
+			So(total.Operation, ShouldEqual, workload.OperationWrite)
+			So(total.Rows, ShouldEqual, 100)
+			So(total.Errors, ShouldEqual, 5)
+
+			// Table errors are not counted twice in the totals
+			So(r.Throughput.Errors, ShouldEqual, 5)
+
+			buf := &bytes.Buffer{}
+			So(r.WriteTable(buf), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, "Albums")
+			So(buf.String(), ShouldContainSubstring, "(total)")
+			So(buf.String(), ShouldNotContainSubstring, "tables.Albums.operations.write.time")
+		})
+
+		Convey("Updates, scans and read-modify-writes", func() {
+			registry := metrics.NewRegistry()
+			tm := workload.NewTableMetrics(registry, "usertable", config.Histogram{})
+			for i := 0; i < 10; i++ {
+				tm.RecordUpdate(time.Millisecond)
+				tm.RecordReadModifyWrite(2 * time.Millisecond)
+			}
+			for i := 0; i < 20; i++ {
+				tm.RecordScan(3 * time.Millisecond)
+			}
+			metrics.GetOrRegisterMeter("operations.read.rate", registry).Mark(30)
+			metrics.GetOrRegisterMeter("operations.update.rate", registry).Mark(10)
+			metrics.GetOrRegisterMeter("operations.scan.rate", registry).Mark(20)
+			metrics.GetOrRegisterMeter("operations.read_modify_write.rate", registry).Mark(10)
+
+			start := time.Now()
+			r, err := New("run", start, start.Add(10*time.Second), nil, nil, registry)
+			So(err, ShouldBeNil)
+
+			ops := make(map[string]Operation)
+			for _, o := range r.Operations {
+				ops[o.Table+"."+o.Operation] = o
+			}
+			So(ops["usertable."+workload.OperationUpdate].Rows, ShouldEqual, 10)
+			So(ops["usertable."+workload.OperationScan].Rows, ShouldEqual, 20)
+			So(ops["usertable."+workload.OperationScan].Percentiles["p50"], ShouldBeGreaterThan, float64(2*time.Millisecond))
+			So(ops["usertable."+workload.OperationReadModifyWrite].Rows, ShouldEqual, 10)
+			So(ops["."+workload.OperationScan].Rows, ShouldEqual, 20)
+			So(ops, ShouldNotContainKey, "usertable."+workload.OperationWrite)
+
+			So(r.Throughput.Reads, ShouldEqual, 5)
+			So(r.Throughput.Writes, ShouldEqual, 2)
+			So(r.Throughput.Total, ShouldEqual, 7)
+		})
+
+		Convey("TPC-C transactions", func() {
+			registry := metrics.NewRegistry()
+			for i := 0; i < 120; i++ {
+				histogram.GetOrRegisterTimer(tpcc.MetricName(tpcc.NewOrder, "time"), registry, config.Histogram{}).Update(10 * time.Millisecond)
+			}
+			for i := 0; i < 30; i++ {
+				histogram.GetOrRegisterTimer(tpcc.MetricName(tpcc.Payment, "time"), registry, config.Histogram{}).Update(5 * time.Millisecond)
+			}
+			metrics.GetOrRegisterCounter(tpcc.MetricName(tpcc.NewOrder, "rollbacks"), registry).Inc(2)
+			metrics.GetOrRegisterCounter(workload.ErrorMetricName(tpcc.Payment, codes.Aborted), registry).Inc(3)
+
+			start := time.Now()
+			r, err := New("run", start, start.Add(time.Minute), nil, nil, registry)
+			So(err, ShouldBeNil)
+			So(r.Transactions, ShouldHaveLength, 2)
+
+			newOrder, payment := r.Transactions[0], r.Transactions[1]
+			So(newOrder.Transaction, ShouldEqual, tpcc.NewOrder)
+			So(newOrder.Count, ShouldEqual, 120)
+			So(newOrder.Rollbacks, ShouldEqual, 2)
+			So(newOrder.Rate, ShouldEqual, 120)
+			So(payment.Errors, ShouldEqual, 3)
+
+			So(r.Throughput.TpmC, ShouldEqual, 120)
+			So(r.Throughput.Operations, ShouldEqual, 153)
+			So(r.Throughput.Errors, ShouldEqual, 3)
+
+			buf := &bytes.Buffer{}
+			So(r.WriteTable(buf), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, "tpmC: 120.0")
+			So(buf.String(), ShouldNotContainSubstring, "transactions.new_order.time")
+		})
+
+		Convey("Assertions", func() {
+			as, err := assertion.ParseAll([]string{"error_rate < 1%", "operations.read.time p99 < 1s"})
+			So(err, ShouldBeNil)
+
+			r.AddAssertions(assertion.Evaluate(as, r.Snapshot(registry)))
+			So(r.Assertions, ShouldHaveLength, 2)
+			So(r.Assertions[0].Passed, ShouldBeFalse)
+			So(r.Assertions[1].Passed, ShouldBeTrue)
+		})
+	})
+}
+
+// executedWorkload is a workload that executed a plan between start and end
+type executedWorkload struct {
+	workload.Workload
+	start, end time.Time
+	plan       []workload.TargetSummary
+}
+
+func (w executedWorkload) ExecutionWindow() (time.Time, time.Time) { return w.start, w.end }
+func (w executedWorkload) PlanSummary() []workload.TargetSummary   { return w.plan }
+
+func TestForWorkload(t *testing.T) {
+	Convey("ForWorkload", t, func() {
+		registry := metrics.NewRegistry()
+		metrics.GetOrRegisterMeter("operations.read.rate", registry).Mark(100)
+
+		start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		wl := executedWorkload{
+			start: start.Add(5 * time.Second),
+			end:   start.Add(15 * time.Second),
+			plan:  []workload.TargetSummary{{Table: "Singers", Phase: "RUN", Operations: 100}},
+		}
+		cfg := &config.Config{Assertions: []string{"operations.read.rate > 5/s"}}
+
+		Convey("Rates are computed over the execution of the plan", func() {
+			r, err := ForWorkload("run", start, start.Add(20*time.Second), cfg, wl, registry)
+			So(err, ShouldBeNil)
+			So(r.Elapsed, ShouldEqual, 10*time.Second)
+			So(r.Plan, ShouldResemble, wl.plan)
+			So(r.Assertions, ShouldHaveLength, 1)
+			So(r.Assertions[0].Passed, ShouldBeTrue)
+		})
+
+		Convey("Workloads without a plan cover the whole phase", func() {
+			r, err := ForWorkload("run", start, start.Add(20*time.Second), cfg, nil, registry)
+			So(err, ShouldBeNil)
+			So(r.Elapsed, ShouldEqual, 20*time.Second)
+			So(r.Plan, ShouldBeEmpty)
+			So(r.Assertions[0].Passed, ShouldBeFalse)
+		})
+
+		Convey("Invalid assertions are an error", func() {
+			cfg.Assertions = []string{"operations.read.rate >"}
+			_, err := ForWorkload("run", start, start.Add(20*time.Second), cfg, wl, registry)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestWrite(t *testing.T) {
+	Convey("Write", t, func() {
+		r, _ := newTestReport()
+		buf := &bytes.Buffer{}
+
+		Convey("JSON", func() {
+			So(r.Write(buf, config.ReportFormatJSON), ShouldBeNil)
+
+			var doc map[string]interface{}
+			So(json.Unmarshal(buf.Bytes(), &doc), ShouldBeNil)
+			So(doc["command"], ShouldEqual, "run")
+			So(doc["start"], ShouldEqual, "2022-01-01T00:00:00Z")
+			So(doc["elapsed_ns"], ShouldEqual, float64(10*time.Second))
+			So(doc["plan"], ShouldHaveLength, 1)
+			So(doc["throughput"].(map[string]interface{})["error_rate"], ShouldEqual, 0.1)
+			So(doc, ShouldNotContainKey, "assertions")
+		})
+
+		Convey("CSV", func() {
+			So(r.Write(buf, config.ReportFormatCSV), ShouldBeNil)
+
+			rows, err := csv.NewReader(buf).ReadAll()
+			So(err, ShouldBeNil)
+			So(rows[0], ShouldResemble, []string{"section", "name", "field", "value"})
+			So(rows, ShouldContain, []string{"summary", "", "command", "run"})
+			So(rows, ShouldContain, []string{"config", "", "threads", "10"})
+			So(rows, ShouldContain, []string{"config", "", "otel.headers.0", "authorization=REDACTED"})
+			So(rows, ShouldContain, []string{"plan", "RUN.Singers", "operations", "100"})
+			So(rows, ShouldContain, []string{"metric", "operations.read.time", "max_ns", "100000000"})
+			So(rows, ShouldContain, []string{"metric", "operations.read.rate", "rate", "9"})
+			So(rows, ShouldContain, []string{"error", "read.Unavailable", "failed_attempts", "25"})
+			So(rows, ShouldContain, []string{"operation", "read", "rows", "90"})
+			So(rows, ShouldContain, []string{"operation", "read", "errors", "10"})
+			So(rows, ShouldContain, []string{"throughput", "", "error_rate", "0.1"})
+		})
+
+		Convey("Table", func() {
+			So(r.Write(buf, config.ReportFormatTable), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, "operations.read.time")
+			So(buf.String(), ShouldContainSubstring, "Unavailable")
+			So(strings.Contains(buf.String(), "No failed operations"), ShouldBeFalse)
+		})
+
+		Convey("Unknown format", func() {
+			So(r.Write(buf, "xml"), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestMerge(t *testing.T) {
+	Convey("Merge", t, func() {
+		start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		cfg := &config.Config{Partition: config.Partition{Count: 2}}
+
+		// Two processes each execute half of the operations. The same operations recorded by
+		// one process give the expected result.
+		all := metrics.NewRegistry()
+		parts := []metrics.Registry{metrics.NewRegistry(), metrics.NewRegistry()}
+		for i := 1; i <= 1000; i++ {
+			for _, r := range []metrics.Registry{all, parts[i%2]} {
+				histogram.GetOrRegisterTimer("operations.write.time", r, config.Histogram{}).Update(time.Duration(i) * time.Millisecond)
+				metrics.GetOrRegisterMeter("operations.write.rate", r).Mark(1)
+			}
+		}
+		metrics.GetOrRegisterCounter(workload.ErrorMetricName(workload.OperationWrite, codes.Aborted), parts[0]).Inc(3)
+		metrics.GetOrRegisterCounter(workload.ErrorMetricName(workload.OperationWrite, codes.Aborted), parts[1]).Inc(2)
+		metrics.GetOrRegisterTimer("run", parts[0]).Update(time.Second)
+		metrics.GetOrRegisterTimer("run", parts[1]).Update(3 * time.Second)
+
+		plan := []workload.TargetSummary{{Table: "Singers", Phase: "LOAD", Operations: 500}}
+		a, err := New("load", start, start.Add(10*time.Second), cfg, plan, parts[0])
+		So(err, ShouldBeNil)
+		b, err := New("load", start.Add(5*time.Second), start.Add(20*time.Second), cfg, plan, parts[1])
+		So(err, ShouldBeNil)
+		want, err := New("load", start, start.Add(20*time.Second), nil, nil, all)
+		So(err, ShouldBeNil)
+
+		// Reports travel as JSON
+		buf := &bytes.Buffer{}
+		So(b.WriteJSON(buf), ShouldBeNil)
+		b, err = Read(buf)
+		So(err, ShouldBeNil)
+
+		m, err := Merge(a, b)
+		So(err, ShouldBeNil)
+		So(m.Start, ShouldEqual, start)
+		So(m.Elapsed, ShouldEqual, 20*time.Second)
+		So(m.Plan, ShouldResemble, []workload.TargetSummary{{Table: "Singers", Phase: "LOAD", Operations: 1000}})
+		So(m.Config, ShouldNotContainKey, "partition")
+
+		Convey("Histograms are merged exactly", func() {
+			got, _ := m.Metric("operations.write.time")
+			exp, _ := want.Metric("operations.write.time")
+			So(got.Count, ShouldEqual, 1000)
+			So(got.Percentiles, ShouldResemble, exp.Percentiles)
+			So(got.Max, ShouldEqual, exp.Max)
+			So(got.Histogram, ShouldNotBeEmpty)
+		})
+
+		Convey("Counts are summed and rates recomputed", func() {
+			meter, _ := m.Metric("operations.write.rate")
+			So(meter.Count, ShouldEqual, 1000)
+			So(meter.Rate, ShouldEqual, 50)
+
+			So(m.Errors, ShouldResemble, []Error{{Operation: "write", Code: "Aborted", Errors: 5}})
+			So(m.Throughput.Operations, ShouldEqual, 1005)
+			So(m.Throughput.Writes, ShouldEqual, 50)
+		})
+
+		Convey("Timers without histograms are bounded", func() {
+			run, _ := m.Metric("run")
+			So(run.Count, ShouldEqual, 2)
+			So(run.Min, ShouldEqual, int64(time.Second))
+			So(run.Max, ShouldEqual, int64(3*time.Second))
+			So(run.Mean, ShouldEqual, float64(2*time.Second))
+			So(run.Percentiles["p99"], ShouldEqual, float64(3*time.Second))
+		})
+
+		Convey("Assertions are evaluated against the merged registry", func() {
+			registry, err := m.Registry()
+			So(err, ShouldBeNil)
+
+			as, err := assertion.ParseAll([]string{"operations.write.time p50 < 600ms", "operations.write.rate > 40/s"})
+			So(err, ShouldBeNil)
+
+			m.AddAssertions(assertion.Evaluate(as, m.Snapshot(registry)))
+			So(m.Assertions[0].Passed, ShouldBeTrue)
+			So(m.Assertions[1].Passed, ShouldBeTrue)
+		})
+
+		Convey("Phases can not be mixed", func() {
+			b.Command = "run"
+			_, err := Merge(a, b)
+			So(err, ShouldNotBeNil)
+
+			_, err = Merge()
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Rates are computed over the union of the windows", func() {
+			c, err := New("load", start.Add(30*time.Second), start.Add(40*time.Second), cfg, plan, metrics.NewRegistry())
+			So(err, ShouldBeNil)
+
+			m, err := Merge(a, b, c)
+			So(err, ShouldBeNil)
+			So(m.Start, ShouldEqual, start)
+			So(m.End, ShouldEqual, start.Add(40*time.Second))
+			So(m.Elapsed, ShouldEqual, 30*time.Second)
+
+			meter, _ := m.Metric("operations.write.rate")
+			So(meter.Rate, ShouldAlmostEqual, 1000.0/30)
+		})
+
+		Convey("Reports of different workloads are told apart", func() {
+			other := &config.Config{Operations: config.Operations{Total: 10}}
+			c, err := New("load", start, start.Add(10*time.Second), other, plan, metrics.NewRegistry())
+			So(err, ShouldBeNil)
+			So(MergeDifferences(a, b), ShouldBeEmpty)
+			So(MergeDifferences(a, c), ShouldResemble, []string{`report 2: config operations.total: "0" != "10"`})
+		})
+	})
+}
+
+func TestCompare(t *testing.T) {
+	Convey("Compare", t, func() {
+		start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		// newRun reports 1000 writes of 1ms .. 1000ms scaled by slowdown over elapsed
+		newRun := func(cfg *config.Config, slowdown float64, elapsed time.Duration, errors int64) *Report {
+			registry := metrics.NewRegistry()
+			for i := 1; i <= 1000; i++ {
+				d := time.Duration(float64(i) * slowdown * float64(time.Millisecond))
+				histogram.GetOrRegisterTimer("operations.write.time", registry, config.Histogram{}).Update(d)
+				workload.NewTableMetrics(registry, "Singers", config.Histogram{}).RecordWrite(d, 1)
+				metrics.GetOrRegisterMeter("operations.write.rate", registry).Mark(1)
+			}
+			metrics.GetOrRegisterCounter(workload.ErrorMetricName(workload.OperationWrite, codes.Aborted), registry).Inc(errors)
+
+			plan := []workload.TargetSummary{{Table: "Singers", Phase: "LOAD", Operations: 1000}}
+			r, err := New("load", start, start.Add(elapsed), cfg, plan, registry)
+			So(err, ShouldBeNil)
+			return r
+		}
+
+		th := Thresholds{Latency: 0.1, Throughput: 0.1, ErrorRate: 0.001}
+		base := newRun(&config.Config{Threads: 10, Seed: 1}, 1, 10*time.Second, 0)
+
+		Convey("Identical runs do not regress", func() {
+			c, err := Compare(base, newRun(&config.Config{Threads: 10, Seed: 2}, 1, 10*time.Second, 0), th)
+			So(err, ShouldBeNil)
+			So(c.Differences, ShouldBeEmpty)
+			So(c.Regressions(), ShouldBeEmpty)
+			So(c.Deltas, ShouldNotBeEmpty)
+		})
+
+		Convey("Slower latencies, lower throughput and more errors regress", func() {
+			c, err := Compare(base, newRun(&config.Config{Threads: 10}, 1.5, 20*time.Second, 10), th)
+			So(err, ShouldBeNil)
+
+			stats := make(map[string]Delta)
+			for _, d := range c.Regressions() {
+				stats[d.Metric+" "+d.Stat] = d
+			}
+			So(stats, ShouldContainKey, "operations.write.time p99")
+			So(stats, ShouldContainKey, "tables.Singers.operations.write.time p50")
+			So(stats, ShouldContainKey, "total throughput")
+			So(stats, ShouldContainKey, "tables.Singers.operations.write throughput")
+			So(stats, ShouldContainKey, "total error_rate")
+			So(*stats["total throughput"].Change, ShouldAlmostEqual, -0.5, 0.01)
+
+			// Improvements are not regressions
+			c, err = Compare(base, newRun(&config.Config{Threads: 10}, 0.5, 5*time.Second, 0), th)
+			So(err, ShouldBeNil)
+			So(c.Regressions(), ShouldBeEmpty)
+		})
+
+		Convey("Changes from a baseline of 0 have no relative change", func() {
+			for i, m := range base.Metrics {
+				if m.Name == "operations.write.time" {
+					base.Metrics[i].Percentiles["p50"] = 0
+				}
+			}
+			n := newRun(&config.Config{Threads: 10, Seed: 2}, 1, 10*time.Second, 0)
+			n.Throughput.Reads = 10
+
+			c, err := Compare(base, n, th)
+			So(err, ShouldBeNil)
+
+			stats := make(map[string]Delta)
+			for _, d := range c.Deltas {
+				stats[d.Metric+" "+d.Stat] = d
+			}
+			So(stats["reads throughput"].Change, ShouldBeNil)
+			So(stats["reads throughput"].Regression, ShouldBeFalse)
+			So(stats["operations.write.time p50"].Change, ShouldBeNil)
+			So(stats["operations.write.time p50"].Regression, ShouldBeTrue)
+
+			buf := &bytes.Buffer{}
+			So(c.WriteJSON(buf), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, `"change": null`)
+			So(c.WriteTable(buf), ShouldBeNil)
+		})
+
+		Convey("Configuration and plan differences are listed", func() {
+			n := newRun(&config.Config{Threads: 20}, 1, 10*time.Second, 0)
+			n.Plan[0].Operations = 2000
+
+			c, err := Compare(base, n, th)
+			So(err, ShouldBeNil)
+			So(c.Differences, ShouldHaveLength, 2)
+			So(c.Differences[0], ShouldContainSubstring, "threads")
+			So(c.Differences[1], ShouldContainSubstring, "LOAD.Singers")
+
+			buf := &bytes.Buffer{}
+			So(c.WriteTable(buf), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, "configured differently")
+		})
+
+		Convey("Phases can not be compared", func() {
+			n := newRun(nil, 1, 10*time.Second, 0)
+			n.Command = "run"
+
+			_, err := Compare(base, n, th)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
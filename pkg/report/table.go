@@ -0,0 +1,159 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/tpcc"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/olekukonko/tablewriter"
+)
+
+// WriteTable writes the workload, timers, operations by table, TPC-C transactions, errors and throughput as
+// ASCII tables. Timers of single tables and transactions are left out of the timers table as they are part of
+// the breakdowns.
+func (r *Report) WriteTable(w io.Writer) error {
+	if r.Workload != "" {
+		fmt.Fprintf(w, "Workload: %s\n", r.Workload)
+	}
+
+	header := []string{"metric", "count", "min", "max", "mean", "stddev"}
+	for _, p := range r.Percentiles {
+		header = append(header, PercentileName(p))
+	}
+
+	t := tablewriter.NewWriter(w)
+	t.SetHeader(header)
+
+	for _, m := range r.Metrics {
+		if m.Type != MetricTypeTimer {
+			continue
+		}
+		if _, _, ok := workload.ParseTableMetricName(m.Name); ok {
+			continue
+		}
+		if _, _, ok := tpcc.ParseMetricName(m.Name); ok {
+			continue
+		}
+
+		l := []string{
+			m.Name,
+			fmt.Sprintf("%d", m.Count),
+			time.Duration(m.Min).String(),
+			time.Duration(m.Max).String(),
+			time.Duration(m.Mean).String(),
+			time.Duration(m.StdDev).String(),
+		}
+		for _, p := range r.Percentiles {
+			l = append(l, time.Duration(m.Percentiles[PercentileName(p)]).String())
+		}
+
+		t.Append(l)
+	}
+	t.Render()
+
+	if len(r.Operations) > 0 {
+		header = []string{"table", "operation", "rows", "errors", "rows/s", "mean"}
+		for _, p := range r.Percentiles {
+			header = append(header, PercentileName(p))
+		}
+
+		t = tablewriter.NewWriter(w)
+		t.SetHeader(header)
+		for _, o := range r.Operations {
+			table := o.Table
+			if table == "" {
+				table = "(total)"
+			}
+
+			l := []string{
+				table,
+				o.Operation,
+				fmt.Sprintf("%d", o.Rows),
+				fmt.Sprintf("%d", o.Errors),
+				fmt.Sprintf("%.1f", o.Rate),
+				time.Duration(o.Mean).String(),
+			}
+			for _, p := range r.Percentiles {
+				l = append(l, time.Duration(o.Percentiles[PercentileName(p)]).String())
+			}
+
+			t.Append(l)
+		}
+		t.Render()
+	}
+
+	if len(r.Transactions) > 0 {
+		header = []string{"transaction", "count", "rollbacks", "errors", "per minute", "mean"}
+		for _, p := range r.Percentiles {
+			header = append(header, PercentileName(p))
+		}
+
+		t = tablewriter.NewWriter(w)
+		t.SetHeader(header)
+		for _, tx := range r.Transactions {
+			l := []string{
+				tx.Transaction,
+				fmt.Sprintf("%d", tx.Count),
+				fmt.Sprintf("%d", tx.Rollbacks),
+				fmt.Sprintf("%d", tx.Errors),
+				fmt.Sprintf("%.1f", tx.Rate),
+				time.Duration(tx.Mean).String(),
+			}
+			for _, p := range r.Percentiles {
+				l = append(l, time.Duration(tx.Percentiles[PercentileName(p)]).String())
+			}
+
+			t.Append(l)
+		}
+		t.Render()
+
+		fmt.Fprintf(w, "tpmC: %.1f\n", r.Throughput.TpmC)
+	}
+
+	if len(r.Errors) == 0 {
+		fmt.Fprintln(w, "No failed operations")
+	} else {
+		var attempts, errors int64
+
+		t = tablewriter.NewWriter(w)
+		t.SetHeader([]string{"operation", "code", "failed attempts", "errors"})
+		for _, e := range r.Errors {
+			t.Append([]string{e.Operation, e.Code, fmt.Sprintf("%d", e.FailedAttempts), fmt.Sprintf("%d", e.Errors)})
+			attempts += e.FailedAttempts
+			errors += e.Errors
+		}
+		t.SetFooter([]string{"", "total", fmt.Sprintf("%d", attempts), fmt.Sprintf("%d", errors)})
+		t.Render()
+	}
+
+	t = tablewriter.NewWriter(w)
+	t.SetHeader([]string{"elapsed", "operations", "errors", "error rate", "reads/s", "writes/s", "total/s"})
+	t.Append([]string{
+		r.Elapsed.Round(time.Millisecond).String(),
+		fmt.Sprintf("%d", r.Throughput.Operations),
+		fmt.Sprintf("%d", r.Throughput.Errors),
+		fmt.Sprintf("%.4g%%", r.Throughput.ErrorRate*100),
+		fmt.Sprintf("%.1f", r.Throughput.Reads),
+		fmt.Sprintf("%.1f", r.Throughput.Writes),
+		fmt.Sprintf("%.1f", r.Throughput.Total),
+	})
+	t.Render()
+
+	return nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+	"github.com/olekukonko/tablewriter"
+)
+
+// CSV sections of phases. The sections of phase reports are those of package report.
+const (
+	SectionPhase = "phase"
+	SectionCheck = "check"
+)
+
+type (
+	// Report is the result of a scenario
+	Report struct {
+		Scenario string        `json:"scenario,omitempty"`
+		Start    time.Time     `json:"start"`
+		End      time.Time     `json:"end"`
+		Elapsed  time.Duration `json:"elapsed_ns"`
+		Phases   []PhaseReport `json:"phases"`
+	}
+
+	// PhaseReport is the result of a phase. Load and run phases have a report, verify phases
+	// have a check per table.
+	PhaseReport struct {
+		Name    string         `json:"name"`
+		Type    string         `json:"type"`
+		Tables  []string       `json:"tables,omitempty"`
+		Start   time.Time      `json:"start"`
+		End     time.Time      `json:"end"`
+		Elapsed time.Duration  `json:"elapsed_ns"`
+		Skipped bool           `json:"skipped,omitempty"` // An earlier phase failed
+		Error   string         `json:"error,omitempty"`
+		Checks  []Check        `json:"checks,omitempty"`
+		Report  *report.Report `json:"report,omitempty"`
+	}
+
+	// Check compares the number of rows of a table with the expected count
+	Check struct {
+		Table    string `json:"table"`
+		Expected int64  `json:"expected"`
+		Rows     int64  `json:"rows"`
+		Passed   bool   `json:"passed"`
+	}
+)
+
+// Failed reports whether a phase failed, was skipped or did not meet its assertions or row counts
+func (r *Report) Failed() bool {
+	for _, p := range r.Phases {
+		if p.Failed() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Failed reports whether the phase failed, was skipped or did not meet its assertions or row
+// counts
+func (p *PhaseReport) Failed() bool {
+	if p.Skipped || p.Error != "" {
+		return true
+	}
+
+	for _, c := range p.Checks {
+		if !c.Passed {
+			return true
+		}
+	}
+
+	if p.Report != nil {
+		for _, a := range p.Report.Assertions {
+			if !a.Passed {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Result is PASS, FAIL or SKIPPED
+func (p *PhaseReport) Result() string {
+	switch {
+	case p.Skipped:
+		return "SKIPPED"
+	case p.Failed():
+		return "FAIL"
+	}
+
+	return "PASS"
+}
+
+// Write writes the report in one of the config.ReportFormat* formats
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case config.ReportFormatJSON:
+		return r.WriteJSON(w)
+	case config.ReportFormatCSV:
+		return r.WriteCSV(w)
+	case config.ReportFormatTable:
+		return r.WriteTable(w)
+	}
+
+	return fmt.Errorf("unknown report format '%s'", format)
+}
+
+// WriteJSON writes the report as an indented JSON document
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// WriteTable writes an overview of the phases followed by a section per executed phase with its
+// error, row counts, report and assertions
+func (r *Report) WriteTable(w io.Writer) error {
+	if r.Scenario != "" {
+		fmt.Fprintf(w, "Scenario: %s\n", r.Scenario)
+	}
+
+	t := tablewriter.NewWriter(w)
+	t.SetHeader([]string{"phase", "type", "tables", "elapsed", "result"})
+	for _, p := range r.Phases {
+		t.Append([]string{p.Name, p.Type, strings.Join(p.Tables, ", "), p.Elapsed.String(), p.Result()})
+	}
+	t.Render()
+
+	for _, p := range r.Phases {
+		if p.Skipped {
+			continue
+		}
+
+		fmt.Fprintf(w, "\nPhase %s (%s)\n", p.Name, p.Type)
+		if p.Error != "" {
+			fmt.Fprintf(w, "Error: %s\n", p.Error)
+		}
+
+		if len(p.Checks) > 0 {
+			t := tablewriter.NewWriter(w)
+			t.SetHeader([]string{"table", "expected", "rows", "result"})
+			for _, c := range p.Checks {
+				result := "PASS"
+				if !c.Passed {
+					result = "FAIL"
+				}
+
+				t.Append([]string{c.Table, strconv.FormatInt(c.Expected, 10), strconv.FormatInt(c.Rows, 10), result})
+			}
+			t.Render()
+		}
+
+		if p.Report == nil {
+			continue
+		}
+
+		err := p.Report.WriteTable(w)
+		if err != nil {
+			return err
+		}
+
+		if len(p.Report.Assertions) > 0 {
+			t := tablewriter.NewWriter(w)
+			t.SetHeader([]string{"assertion", "actual", "result"})
+			for _, a := range p.Report.Assertions {
+				actual, result := a.Actual, "PASS"
+				if a.Error != "" {
+					actual = a.Error
+				}
+				if !a.Passed {
+					result = "FAIL"
+				}
+
+				t.Append([]string{a.Expr, actual, result})
+			}
+			t.Render()
+		}
+	}
+
+	return nil
+}
+
+// WriteCSV writes the report in the long form of package report with a leading phase column.
+// Every phase has a phase section, verify phases a check section and load and run phases the
+// sections of their report.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"phase", "section", "name", "field", "value"})
+	for _, p := range r.Phases {
+		row := func(section, name, field, value string) {
+			cw.Write([]string{p.Name, section, name, field, value})
+		}
+
+		row(SectionPhase, "", "type", p.Type)
+		row(SectionPhase, "", "tables", strings.Join(p.Tables, " "))
+		row(SectionPhase, "", "start", p.Start.Format(time.RFC3339Nano))
+		row(SectionPhase, "", "end", p.End.Format(time.RFC3339Nano))
+		row(SectionPhase, "", "elapsed_ns", strconv.FormatInt(int64(p.Elapsed), 10))
+		row(SectionPhase, "", "result", p.Result())
+		if p.Error != "" {
+			row(SectionPhase, "", "error", p.Error)
+		}
+
+		for _, c := range p.Checks {
+			row(SectionCheck, c.Table, "expected", strconv.FormatInt(c.Expected, 10))
+			row(SectionCheck, c.Table, "rows", strconv.FormatInt(c.Rows, 10))
+			row(SectionCheck, c.Table, "passed", strconv.FormatBool(c.Passed))
+		}
+
+		if p.Report == nil {
+			continue
+		}
+
+		// Prefix the rows of the phase report, leaving out its header
+		var b bytes.Buffer
+		err := p.Report.WriteCSV(&b)
+		if err != nil {
+			return err
+		}
+
+		records, err := csv.NewReader(&b).ReadAll()
+		if err != nil {
+			return err
+		}
+
+		for _, rec := range records[1:] {
+			cw.Write(append([]string{p.Name}, rec...))
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,418 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/admin"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/cloudspannerecosystem/gcsb/pkg/preset"
+	"github.com/cloudspannerecosystem/gcsb/pkg/report"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+	"github.com/spf13/viper"
+	"google.golang.org/api/iterator"
+)
+
+type (
+	// Runner executes scenarios
+	Runner struct {
+		Context context.Context
+		Base    *viper.Viper                                         // Configuration the scenario overrides, e.g. of the config file and flags (optional)
+		Observe func(cfg *config.Config) (observer.Observer, func()) // Starts observing a load or run phase, returns a function stopping it (optional)
+	}
+
+	// Step is a phase with its resolved configuration
+	Step struct {
+		Phase  Phase
+		Config *config.Config
+	}
+)
+
+// Resolve validates the scenario and resolves the configuration of each phase. Tables of load
+// and run phases default to those of their workload preset, verify phases list the tables of
+// their rows. All phases must connect to the same database.
+func (r *Runner) Resolve(s *Scenario) ([]Step, error) {
+	err := s.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	steps := make([]Step, 0, len(s.Phases))
+	for _, p := range s.Phases {
+		cfg, err := r.config(s, &p)
+		if err != nil {
+			return nil, fmt.Errorf("phase '%s': %s", p.Name, err.Error())
+		}
+
+		if len(steps) > 0 && connection(cfg) != connection(steps[0].Config) {
+			return nil, fmt.Errorf("phase '%s' connects to another database than phase '%s' (%s)", p.Name, steps[0].Phase.Name, connection(cfg))
+		}
+
+		steps = append(steps, Step{Phase: p, Config: cfg})
+	}
+
+	return steps, nil
+}
+
+// config resolves the configuration of phase p and fills in the tables of its preset
+func (r *Runner) config(s *Scenario, p *Phase) (*config.Config, error) {
+	v := viper.New()
+	if r.Base != nil {
+		err := v.MergeConfigMap(settings(r.Base))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := v.MergeConfigMap(s.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.MergeConfigMap(p.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Workload != "" {
+		v.Set("workload", p.Workload)
+	}
+	if p.Duration > 0 {
+		v.Set("max_execution_time", p.Duration)
+	}
+
+	// Verify phases count the rows of the tables they expect rows of
+	if p.Type == PhaseVerify {
+		p.Tables = make([]string, 0, len(p.Rows))
+		for t := range p.Rows {
+			p.Tables = append(p.Tables, t)
+		}
+		sort.Strings(p.Tables)
+	}
+
+	workloadPhase := p.Type == PhaseLoad || p.Type == PhaseRun
+	if name := v.GetString("workload"); name != "" && workloadPhase {
+		pr, err := preset.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		err = pr.Apply(v)
+		if err != nil {
+			return nil, fmt.Errorf("applying workload %s: %s", pr.Name, err.Error())
+		}
+
+		if len(p.Tables) <= 0 && p.Type == PhaseLoad {
+			p.Tables = pr.Load
+		}
+		if len(p.Tables) <= 0 && p.Type == PhaseRun {
+			p.Tables = pr.Run
+		}
+	}
+
+	if workloadPhase && len(p.Tables) <= 0 {
+		return nil, fmt.Errorf("missing tables")
+	}
+	if p.Type == PhaseRun && len(p.Tables) > 1 {
+		return nil, fmt.Errorf("run phases execute against a single table")
+	}
+
+	cfg, err := config.NewConfig(v)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// settings returns the settings that are set in v, leaving out defaults and flags that were
+// not changed, nested like they are nested in a config file
+func settings(v *viper.Viper) map[string]interface{} {
+	ret := make(map[string]interface{})
+	for _, k := range v.AllKeys() {
+		if !v.IsSet(k) {
+			continue
+		}
+
+		m := ret
+		path := strings.Split(k, ".")
+		for _, name := range path[:len(path)-1] {
+			next, ok := m[name].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				m[name] = next
+			}
+			m = next
+		}
+		m[path[len(path)-1]] = v.Get(k)
+	}
+
+	return ret
+}
+
+// connection describes the database of a configuration. The in memory backend is identified by
+// the DDL it is created from.
+func connection(cfg *config.Config) string {
+	if cfg.Backend == backend.Memory {
+		return fmt.Sprintf("backend: %s, ddl_file: %s, schema_file: %s", cfg.Backend, cfg.Create.DDLFile, cfg.SchemaFile)
+	}
+
+	return fmt.Sprintf("backend: %s, database: %s, emulator_host: %s, schema_file: %s", cfg.Backend, cfg.DB(), cfg.EmulatorHost, cfg.SchemaFile)
+}
+
+// Run executes the phases of the scenario in order on one connection. A phase that fails skips
+// the phases after it, except those that are always executed. Failed assertions and row counts
+// do not stop the scenario, see Report.Failed. Errors of phases are part of the report, the
+// returned error means the scenario could not start.
+func (r *Runner) Run(s *Scenario) (*Report, error) {
+	steps, err := r.Resolve(s)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// The first phase creates the database and connection, the others share them
+	first := steps[0].Config
+	if first.Create.Database && first.Backend != backend.Memory {
+		log.Println("Creating instance and database if missing")
+		err = admin.CreateDatabase(ctx, first)
+		if err != nil {
+			return nil, fmt.Errorf("creating database: %s", err.Error())
+		}
+	}
+
+	client, err := first.Client(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %s", err.Error())
+	}
+	defer client.Close()
+
+	for _, st := range steps[1:] {
+		st.Config.UseClient(client)
+	}
+
+	sch, err := schema.LoadSchema(ctx, first)
+	if err != nil {
+		return nil, fmt.Errorf("loading schema: %s", err.Error())
+	}
+
+	rep := &Report{
+		Scenario: s.Name,
+		Start:    time.Now(),
+		Phases:   make([]PhaseReport, 0, len(steps)),
+	}
+
+	var failed bool
+	for _, st := range steps {
+		pr := PhaseReport{
+			Name:   st.Phase.Name,
+			Type:   st.Phase.Type,
+			Tables: st.Phase.Tables,
+		}
+
+		if failed && !st.Phase.Always {
+			log.Printf("Skipping phase %s", pr.Name)
+			pr.Skipped = true
+			rep.Phases = append(rep.Phases, pr)
+			continue
+		}
+
+		log.Printf("Executing phase %s (%s)", pr.Name, pr.Type)
+		pr.Start = time.Now()
+		err := r.execute(ctx, st, client, sch, &pr)
+		pr.End = time.Now()
+		pr.Elapsed = pr.End.Sub(pr.Start)
+		if err != nil {
+			log.Printf("Phase %s failed: %s", pr.Name, err.Error())
+			pr.Error = err.Error()
+			failed = true
+		}
+
+		rep.Phases = append(rep.Phases, pr)
+	}
+
+	rep.End = time.Now()
+	rep.Elapsed = rep.End.Sub(rep.Start)
+
+	return rep, nil
+}
+
+// execute executes a phase and records its results in pr
+func (r *Runner) execute(ctx context.Context, st Step, client backend.Client, sch schema.Schema, pr *PhaseReport) error {
+	var err error
+
+	switch st.Phase.Type {
+	case PhaseLoad, PhaseRun:
+		pr.Report, err = r.executeWorkload(ctx, st, sch)
+	case PhaseVerify:
+		pr.Checks, err = verifyRows(ctx, client, sch, st.Phase.Rows)
+	case PhaseDelete:
+		err = deleteRows(ctx, client, st.Config.Backend, sch, st.Phase.Tables)
+	default:
+		err = fmt.Errorf("unknown phase type '%s'", st.Phase.Type)
+	}
+
+	return err
+}
+
+// executeWorkload loads tables or runs against a table with a new workload. The report covers
+// what completed even if the workload failed.
+func (r *Runner) executeWorkload(ctx context.Context, st Step, sch schema.Schema) (*report.Report, error) {
+	cfg := st.Config
+	registry := metrics.NewRegistry()
+
+	var obs observer.Observer
+	if r.Observe != nil {
+		var stop func()
+		obs, stop = r.Observe(cfg)
+		defer stop()
+	}
+
+	wl, err := workload.NewCoreWorkload(workload.WorkloadConfig{
+		Context:        ctx,
+		Config:         cfg,
+		Schema:         sch,
+		MetricRegistry: registry,
+		Observer:       obs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating workload: %s", err.Error())
+	}
+	defer wl.Stop()
+
+	// measure the phase
+	runTimer := metrics.GetOrRegisterTimer("run", registry)
+
+	start := time.Now()
+	runTimer.Time(func() {
+		if st.Phase.Type == PhaseLoad {
+			err = wl.Load(st.Phase.Tables)
+		} else {
+			err = wl.Run(st.Phase.Tables[0])
+		}
+	})
+	end := time.Now()
+
+	rep, rerr := report.ForWorkload(st.Phase.Type, start, end, cfg, wl, registry)
+	if rerr != nil {
+		return nil, fmt.Errorf("creating report: %s", rerr.Error())
+	}
+
+	return rep, err
+}
+
+// verifyRows counts the rows of the tables in alphabetical order and compares them with the
+// expected counts
+func verifyRows(ctx context.Context, client backend.Client, sch schema.Schema, rows map[string]int64) ([]Check, error) {
+	tables := make([]string, 0, len(rows))
+	for t := range rows {
+		if sch.GetTable(t) == nil {
+			return nil, fmt.Errorf("table '%s' missing from schema", t)
+		}
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+
+	checks := make([]Check, 0, len(tables))
+	for _, t := range tables {
+		n, err := countRows(ctx, client, t)
+		if err != nil {
+			return checks, fmt.Errorf("counting rows of %s: %s", t, err.Error())
+		}
+
+		c := Check{Table: t, Expected: rows[t], Rows: n, Passed: n == rows[t]}
+		log.Printf("Table %s has %d rows (expected %d)", t, c.Rows, c.Expected)
+		checks = append(checks, c)
+	}
+
+	return checks, nil
+}
+
+// countRows returns the number of rows of a table
+func countRows(ctx context.Context, client backend.Client, table string) (int64, error) {
+	iter := client.Single().Query(ctx, spanner.Statement{SQL: fmt.Sprintf("SELECT COUNT(*) FROM `%s`", table)})
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err = row.Columns(&n)
+
+	return n, err
+}
+
+// deleteRows deletes all rows of the tables. Interleaved children are emptied before their
+// parents, tables of the same depth in the given order. Cloud Spanner deletes with partitioned
+// DML, as a single transaction can not delete more rows than fit its mutation limit. The memory
+// backend does not support partitioned DML and deletes with a mutation instead.
+func deleteRows(ctx context.Context, client backend.Client, backendName string, sch schema.Schema, tables []string) error {
+	targets := make([]schema.Table, 0, len(tables))
+	for _, name := range tables {
+		t := sch.GetTable(name)
+		if t == nil {
+			return fmt.Errorf("table '%s' missing from schema", name)
+		}
+		if t.IsView() {
+			return fmt.Errorf("can not delete rows of view '%s'", name)
+		}
+		targets = append(targets, t)
+	}
+
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Depth() > targets[j].Depth()
+	})
+
+	for _, t := range targets {
+		log.Printf("Deleting all rows of %s", t.Name())
+
+		var err error
+		if backendName == backend.Memory {
+			_, err = client.Apply(ctx, []*spanner.Mutation{spanner.Delete(t.Name(), spanner.AllKeys())})
+		} else {
+			_, err = client.PartitionedUpdate(ctx, spanner.Statement{SQL: fmt.Sprintf("DELETE FROM `%s` WHERE true", t.Name())})
+		}
+		if err != nil {
+			return fmt.Errorf("deleting rows of %s: %s", t.Name(), err.Error())
+		}
+	}
+
+	return nil
+}
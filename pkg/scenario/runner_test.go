@@ -0,0 +1,221 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/spf13/viper"
+)
+
+func TestResolve(t *testing.T) {
+	base := viper.New()
+	base.Set("backend", backend.Memory)
+	base.Set("create.ddl_file", "../../schemas/ycsb.sql")
+	base.Set("threads", 3)
+	base.Set("operations.read", 10)
+
+	s := &Scenario{
+		Config: map[string]interface{}{"threads": 5},
+		Phases: []Phase{
+			{
+				Name:     "run",
+				Type:     PhaseRun,
+				Workload: "ycsb-c",
+				Duration: time.Minute,
+				Config:   map[string]interface{}{"operations": map[interface{}]interface{}{"total": 7}},
+			},
+			{Name: "verify", Type: PhaseVerify, Rows: map[string]int64{"usertable": 0}},
+		},
+	}
+
+	steps, err := (&Runner{Base: base}).Resolve(s)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	// The phase overrides the scenario, which overrides the base, which overrides the preset
+	cfg := steps[0].Config
+	if cfg.Threads != 5 {
+		t.Errorf("threads = %d, want 5 of the scenario", cfg.Threads)
+	}
+	if cfg.Operations.Total != 7 {
+		t.Errorf("operations.total = %d, want 7 of the phase", cfg.Operations.Total)
+	}
+	if cfg.Operations.Read != 10 {
+		t.Errorf("operations.read = %d, want 10 of the base", cfg.Operations.Read)
+	}
+	if cfg.Operations.SampleSize != 100 {
+		t.Errorf("operations.sample_size = %g, want 100 of the preset", cfg.Operations.SampleSize)
+	}
+	if cfg.MaxExecutionTime != time.Minute {
+		t.Errorf("max_execution_time = %s, want the duration of the phase", cfg.MaxExecutionTime)
+	}
+	if tables := steps[0].Phase.Tables; len(tables) != 1 || tables[0] != "usertable" {
+		t.Errorf("tables = %v, want the run table of the preset", tables)
+	}
+
+	// Phases may not switch databases
+	s.Phases[1].Config = map[string]interface{}{"create": map[string]interface{}{"ddl_file": "../../schemas/single_table.sql"}}
+	_, err = (&Runner{Base: base}).Resolve(s)
+	if err == nil || !strings.Contains(err.Error(), "another database") {
+		t.Errorf("Resolve() = %v, want an error about another database", err)
+	}
+}
+
+func TestRunMemory(t *testing.T) {
+	s, err := Parse(strings.NewReader(`
+name: memory
+config:
+  backend: memory
+  create:
+    ddl_file: ../../schemas/ycsb.sql
+  threads: 4
+  seed: 7
+  operations:
+    total: 100
+phases:
+  - name: load
+    type: load
+    workload: ycsb-c
+  - name: read
+    type: run
+    workload: ycsb-c
+    config:
+      assertions:
+        - error_rate < 0.1%
+  - name: update
+    type: run
+    workload: ycsb-a
+    duration: 200ms
+    config:
+      operations:
+        total: 100000000
+  - name: loaded
+    type: verify
+    rows:
+      usertable: 100
+  - name: missing
+    type: run
+    tables: [missing]
+  - name: skipped
+    type: verify
+    rows:
+      usertable: 100
+  - name: cleanup
+    type: delete
+    tables: [usertable]
+    always: true
+  - name: empty
+    type: verify
+    rows:
+      usertable: 0
+    always: true
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	rep, err := (&Runner{Context: context.Background()}).Run(s)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := map[string]string{
+		"load":    "PASS",
+		"read":    "PASS",
+		"update":  "PASS",
+		"loaded":  "PASS",
+		"missing": "FAIL",
+		"skipped": "SKIPPED",
+		"cleanup": "PASS",
+		"empty":   "PASS",
+	}
+	if len(rep.Phases) != len(want) {
+		t.Fatalf("report has %d phases, want %d", len(rep.Phases), len(want))
+	}
+	for _, p := range rep.Phases {
+		if got := p.Result(); got != want[p.Name] {
+			t.Errorf("phase %s: %s (error: %q), want %s", p.Name, got, p.Error, want[p.Name])
+		}
+	}
+	if !rep.Failed() {
+		t.Error("scenario with a failed phase did not fail")
+	}
+
+	// Every workload phase reports on its own
+	for _, p := range rep.Phases[:3] {
+		if p.Report == nil {
+			t.Fatalf("phase %s has no report", p.Name)
+		}
+		if p.Report.Throughput.Operations == 0 {
+			t.Errorf("phase %s executed no operations", p.Name)
+		}
+		for _, e := range p.Report.Errors {
+			t.Errorf("phase %s: %d errors of %s (%s)", p.Name, e.Errors, e.Operation, e.Code)
+		}
+	}
+	if n := len(rep.Phases[0].Report.Plan); n != 1 || rep.Phases[0].Report.Plan[0].Phase != "LOAD" {
+		t.Errorf("load plan = %+v, want one LOAD target", rep.Phases[0].Report.Plan)
+	}
+	if len(rep.Phases[1].Report.Assertions) != 1 || !rep.Phases[1].Report.Assertions[0].Passed {
+		t.Errorf("assertions of the read phase = %+v, want one passed assertion", rep.Phases[1].Report.Assertions)
+	}
+	if n := rep.Phases[2].Report.Throughput.Operations; n >= 100000000 {
+		t.Errorf("update phase executed %d operations, want it to stop after its duration", n)
+	}
+
+	var plan []workload.TargetSummary
+	for _, p := range rep.Phases[:3] {
+		plan = append(plan, p.Report.Plan...)
+	}
+	if len(plan) != 3 {
+		t.Errorf("phases planned %d targets, want 3", len(plan))
+	}
+
+	// The csv report prefixes the rows of every phase with its name
+	var b bytes.Buffer
+	if err := rep.WriteCSV(&b); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	records, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatalf("reading csv: %v", err)
+	}
+	phases := make(map[string]bool)
+	for _, rec := range records[1:] {
+		phases[rec[0]] = true
+	}
+	for name := range want {
+		if !phases[name] {
+			t.Errorf("csv report has no rows of phase %s", name)
+		}
+	}
+
+	b.Reset()
+	if err := rep.WriteTable(&b); err != nil {
+		t.Fatalf("WriteTable: %v", err)
+	}
+	if !strings.Contains(b.String(), "Phase update (run)") {
+		t.Errorf("table report lacks a section of phase update:\n%s", b.String())
+	}
+}
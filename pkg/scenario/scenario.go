@@ -0,0 +1,172 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scenario executes the phases of a benchmark, such as loading tables, running one or
+// more operation mixes, verifying row counts and deleting the data, one after another in a
+// single process. Every phase is configured by overriding the configuration of the scenario and
+// reports on its own.
+package scenario
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/preset"
+	"github.com/hashicorp/go-multierror"
+	"gopkg.in/yaml.v2"
+)
+
+// Phase types
+const (
+	PhaseLoad   = "load"   // Load tables, see workload.Workload
+	PhaseRun    = "run"    // Run the operation mix against a table
+	PhaseVerify = "verify" // Compare the number of rows of tables with expected counts
+	PhaseDelete = "delete" // Delete all rows of tables
+)
+
+type (
+	// Scenario is an ordered list of phases executed against one database
+	Scenario struct {
+		Name   string                 `yaml:"name"`
+		Config map[string]interface{} `yaml:"config"` // Overrides of the configuration of every phase
+		Phases []Phase                `yaml:"phases"`
+	}
+
+	// Phase is a step of a scenario. The settings in Config override those of the scenario,
+	// which override the configuration file, environment and flags. A workload preset applies
+	// below all of them.
+	Phase struct {
+		Name     string                 `yaml:"name"`     // Defaults to the type and position of the phase, e.g. run-2
+		Type     string                 `yaml:"type"`     // load, run, verify or delete
+		Tables   []string               `yaml:"tables"`   // Tables to load or delete, or the table to run against. Resolved for verify phases
+		Workload string                 `yaml:"workload"` // Preset of the phase. It knows the tables to load and run against
+		Duration time.Duration          `yaml:"duration"` // Load and run phases stop after this long (0 runs all operations)
+		Rows     map[string]int64       `yaml:"rows"`     // Expected number of rows by table of verify phases
+		Always   bool                   `yaml:"always"`   // Execute the phase even if an earlier phase failed, e.g. to clean up
+		Config   map[string]interface{} `yaml:"config"`   // Overrides of the configuration of this phase
+	}
+)
+
+// Read reads a scenario from a YAML file
+func Read(path string) (*Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse reads a scenario from YAML. Unknown keys are an error so that typos do not go unnoticed.
+// Phases without a name are named after their type and position.
+func Parse(r io.Reader) (*Scenario, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scenario{}
+	err = yaml.UnmarshalStrict(b, s)
+	if err != nil {
+		return nil, fmt.Errorf("parsing scenario: %s", err.Error())
+	}
+
+	for i := range s.Phases {
+		if s.Phases[i].Name == "" {
+			s.Phases[i].Name = fmt.Sprintf("%s-%d", s.Phases[i].Type, i+1)
+		}
+	}
+
+	return s, nil
+}
+
+// Validate checks the phases of the scenario. Tables of load and run phases may come from a
+// workload preset, they are checked once the configuration of the phase is resolved.
+func (s *Scenario) Validate() error {
+	var result *multierror.Error
+
+	if len(s.Phases) <= 0 {
+		result = multierror.Append(result, fmt.Errorf("scenario has no phases"))
+	}
+
+	names := make(map[string]bool, len(s.Phases))
+	for _, p := range s.Phases {
+		if names[p.Name] {
+			result = multierror.Append(result, fmt.Errorf("phase name '%s' is not unique", p.Name))
+		}
+		names[p.Name] = true
+
+		err := p.Validate()
+		if err != nil {
+			result = multierror.Append(result, fmt.Errorf("phase '%s': %s", p.Name, err.Error()))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// Validate checks the settings of the phase against its type
+func (p *Phase) Validate() error {
+	var result *multierror.Error
+
+	switch p.Type {
+	case PhaseLoad, PhaseRun:
+		if p.Type == PhaseRun && len(p.Tables) > 1 {
+			result = multierror.Append(result, fmt.Errorf("run phases execute against a single table"))
+		}
+		if p.Duration < 0 {
+			result = multierror.Append(result, fmt.Errorf("duration must be >= 0"))
+		}
+		if len(p.Rows) > 0 {
+			result = multierror.Append(result, fmt.Errorf("rows are only expected by verify phases"))
+		}
+		if p.Workload != "" {
+			_, err := preset.Get(p.Workload)
+			if err != nil {
+				result = multierror.Append(result, err)
+			}
+		}
+	case PhaseVerify:
+		if len(p.Rows) <= 0 {
+			result = multierror.Append(result, fmt.Errorf("missing expected rows"))
+		}
+		if len(p.Tables) > 0 {
+			result = multierror.Append(result, fmt.Errorf("verify phases check the tables of rows"))
+		}
+	case PhaseDelete:
+		if len(p.Tables) <= 0 {
+			result = multierror.Append(result, fmt.Errorf("missing tables to delete"))
+		}
+		if len(p.Rows) > 0 {
+			result = multierror.Append(result, fmt.Errorf("rows are only expected by verify phases"))
+		}
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown type '%s' (load, run, verify, delete)", p.Type))
+	}
+
+	if p.Type == PhaseVerify || p.Type == PhaseDelete {
+		if p.Duration != 0 {
+			result = multierror.Append(result, fmt.Errorf("duration only applies to load and run phases"))
+		}
+		if p.Workload != "" {
+			result = multierror.Append(result, fmt.Errorf("workload only applies to load and run phases"))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	s, err := Parse(strings.NewReader(`
+name: nightly
+config:
+  threads: 4
+phases:
+  - type: load
+    tables: [usertable]
+  - name: mix-a
+    type: run
+    workload: ycsb-a
+    duration: 10m
+    config:
+      operations:
+        total: 1000
+  - type: verify
+    rows:
+      usertable: 1000
+  - type: delete
+    tables: [usertable]
+    always: true
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	names := []string{"load-1", "mix-a", "verify-3", "delete-4"}
+	for i, p := range s.Phases {
+		if p.Name != names[i] {
+			t.Errorf("phase %d is named %s, want %s", i, p.Name, names[i])
+		}
+	}
+	if d := s.Phases[1].Duration; d != 10*time.Minute {
+		t.Errorf("duration = %s, want 10m", d)
+	}
+	if n := s.Phases[2].Rows["usertable"]; n != 1000 {
+		t.Errorf("expected rows = %d, want 1000", n)
+	}
+	if !s.Phases[3].Always {
+		t.Error("delete phase is not always executed")
+	}
+
+	_, err = Parse(strings.NewReader("phases:\n  - type: run\n    table: usertable\n"))
+	if err == nil {
+		t.Error("Parse accepted an unknown key")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc   string
+		phases []Phase
+		want   string // Part of the error, empty if valid
+	}{
+		{
+			desc: "valid",
+			phases: []Phase{
+				{Name: "load", Type: PhaseLoad, Tables: []string{"a", "b"}},
+				{Name: "run", Type: PhaseRun, Workload: "ycsb-b"},
+			},
+		},
+		{
+			desc: "no phases",
+			want: "no phases",
+		},
+		{
+			desc: "duplicate names",
+			phases: []Phase{
+				{Name: "run", Type: PhaseRun, Tables: []string{"a"}},
+				{Name: "run", Type: PhaseRun, Tables: []string{"a"}},
+			},
+			want: "not unique",
+		},
+		{
+			desc:   "unknown type",
+			phases: []Phase{{Name: "x", Type: "truncate"}},
+			want:   "unknown type",
+		},
+		{
+			desc:   "run against several tables",
+			phases: []Phase{{Name: "x", Type: PhaseRun, Tables: []string{"a", "b"}}},
+			want:   "single table",
+		},
+		{
+			desc:   "unknown workload",
+			phases: []Phase{{Name: "x", Type: PhaseRun, Workload: "ycsb-z"}},
+			want:   "unknown preset",
+		},
+		{
+			desc:   "verify without rows",
+			phases: []Phase{{Name: "x", Type: PhaseVerify}},
+			want:   "missing expected rows",
+		},
+		{
+			desc:   "delete with a duration",
+			phases: []Phase{{Name: "x", Type: PhaseDelete, Duration: time.Second}},
+			want:   "duration only applies",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			err := (&Scenario{Phases: test.phases}).Validate()
+			switch {
+			case test.want == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)):
+				t.Errorf("Validate() = %v, want an error containing %q", err, test.want)
+			}
+		})
+	}
+}
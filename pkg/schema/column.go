@@ -19,6 +19,7 @@ import (
 
 	"cloud.google.com/go/spanner"
 	"cloud.google.com/go/spanner/spansql"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema/information"
 )
 
@@ -89,7 +90,7 @@ func NewColumnFromSchema(x information.Column) Column {
 	return c
 }
 
-func LoadColumns(ctx context.Context, client *spanner.Client, t Table) error {
+func LoadColumns(ctx context.Context, client backend.Client, t Table) error {
 	iter := client.Single().Query(ctx, information.GetColumnsQuery(t.Name()))
 	defer iter.Stop()
 
@@ -25,6 +25,7 @@ type (
 		ColumnIterator
 		Columns() []Column
 		AddColumn(Column)
+		GetColumn(string) Column
 		ColumnNames() []string
 		PrimaryKeys() Columns
 		Len() int
@@ -72,6 +73,16 @@ func (c *columns) AddColumn(x Column) {
 	c.columns = append(c.columns, x)
 }
 
+func (c *columns) GetColumn(x string) Column {
+	for _, col := range c.columns {
+		if col.Name() == x {
+			return col
+		}
+	}
+
+	return nil
+}
+
 func (c *columns) ColumnNames() []string {
 	ret := make([]string, 0)
 	for _, col := range c.columns {
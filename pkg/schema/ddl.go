@@ -0,0 +1,262 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
+)
+
+// LoadSchemaFile builds a schema from the statements of a DDL file, see LoadSchemaFromDDL
+func LoadSchemaFile(path string) (Schema, error) {
+	ddl, err := backend.ParseDDLFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema file: %s", err.Error())
+	}
+
+	return LoadSchemaFromDDL(ddl)
+}
+
+// LoadSchemaFromDDL builds a schema from the tables, views, indexes and foreign keys created by
+// ddl, as if it had been applied to a database and loaded from its information schema. Statements
+// dropping objects are not supported.
+func LoadSchemaFromDDL(ddl *spansql.DDL) (Schema, error) {
+	s := NewSchema()
+
+	for _, stmt := range ddl.List {
+		var err error
+
+		switch st := stmt.(type) {
+		case *spansql.CreateTable:
+			err = createTable(s, st)
+		case *spansql.CreateIndex:
+			t := s.GetTable(string(st.Table))
+			if t == nil {
+				err = fmt.Errorf("index '%s' on unknown table '%s'", st.Name, st.Table)
+				break
+			}
+
+			t.AddIndex(NewIndexFromDDL(st))
+		case *spansql.CreateView:
+			err = createView(s, st)
+		case *spansql.AlterTable:
+			err = alterTable(s, st)
+		case *spansql.AlterDatabase:
+			// Database options do not affect the schema
+		default:
+			err = errors.New("unsupported statement")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s%s: %s", ddl.Filename, stmt.Pos(), err.Error())
+		}
+	}
+
+	// Traverse the schema to setup parent/child relationships
+	err := s.Traverse()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func createTable(s Schema, x *spansql.CreateTable) error {
+	if s.GetTable(string(x.Name)) != nil {
+		return fmt.Errorf("duplicate table '%s'", x.Name)
+	}
+
+	t := NewTableFromDDL(x)
+	s.AddTable(t)
+
+	// Foreign keys may reference the table itself
+	for _, tc := range x.Constraints {
+		err := addConstraint(s, t, tc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createView(s Schema, x *spansql.CreateView) error {
+	if t := s.GetTable(string(x.Name)); t != nil {
+		if x.OrReplace && t.IsView() {
+			return nil
+		}
+
+		return fmt.Errorf("duplicate table or view '%s'", x.Name)
+	}
+
+	s.AddTable(NewViewFromDDL(x))
+
+	return nil
+}
+
+func alterTable(s Schema, x *spansql.AlterTable) error {
+	t := s.GetTable(string(x.Name))
+	if t == nil {
+		return fmt.Errorf("unknown table '%s'", x.Name)
+	}
+
+	switch alt := x.Alteration.(type) {
+	case spansql.AddColumn:
+		if t.Columns().GetColumn(string(alt.Def.Name)) != nil {
+			return fmt.Errorf("duplicate column '%s' in table '%s'", alt.Def.Name, x.Name)
+		}
+
+		t.AddColumn(NewColumnFromDDL(alt.Def, int64(t.Columns().Len()+1), false))
+	case spansql.AddConstraint:
+		return addConstraint(s, t, alt.Constraint)
+	case spansql.AlterColumn:
+		c := t.Columns().GetColumn(string(alt.Name))
+		if c == nil {
+			return fmt.Errorf("unknown column '%s' in table '%s'", alt.Name, x.Name)
+		}
+
+		switch ca := alt.Alteration.(type) {
+		case spansql.SetColumnType:
+			c.SetSpannerType(ca.Type.SQL())
+			c.SetNullable(nullable(ca.NotNull))
+		case spansql.SetColumnOptions:
+			if ca.Options.AllowCommitTimestamp != nil {
+				c.SetAllowCommitTimestamp(*ca.Options.AllowCommitTimestamp)
+			}
+		}
+	case spansql.SetOnDelete, spansql.AddRowDeletionPolicy, spansql.ReplaceRowDeletionPolicy, spansql.DropRowDeletionPolicy:
+		// Not part of the schema model
+	default:
+		return fmt.Errorf("unsupported alteration of table '%s'", x.Name)
+	}
+
+	return nil
+}
+
+// addConstraint adds the foreign keys of a table. Check constraints are ignored.
+func addConstraint(s Schema, t Table, x spansql.TableConstraint) error {
+	fk, ok := x.Constraint.(spansql.ForeignKey)
+	if !ok {
+		return nil
+	}
+
+	if s.GetTable(string(fk.RefTable)) == nil {
+		return fmt.Errorf("foreign key of table '%s' references unknown table '%s'", t.Name(), fk.RefTable)
+	}
+
+	t.AddForeignKey(NewForeignKeyFromDDL(x.Name, fk))
+
+	return nil
+}
+
+// NewTableFromDDL returns the table created by a CREATE TABLE statement
+func NewTableFromDDL(x *spansql.CreateTable) Table {
+	t := NewTable()
+	t.SetName(string(x.Name))
+	t.SetType("BASE TABLE")
+	t.SetSpanenrState("COMMITTED")
+	if x.Interleave != nil {
+		t.SetParentName(string(x.Interleave.Parent))
+	}
+
+	pkeys := make(map[spansql.ID]bool, len(x.PrimaryKey))
+	for _, k := range x.PrimaryKey {
+		pkeys[k.Column] = true
+	}
+
+	for i, cd := range x.Columns {
+		t.AddColumn(NewColumnFromDDL(cd, int64(i+1), pkeys[cd.Name]))
+	}
+
+	return t
+}
+
+// NewViewFromDDL returns the view created by a CREATE VIEW statement. The columns of a view are
+// not known without evaluating its query, so it has none.
+func NewViewFromDDL(x *spansql.CreateView) Table {
+	t := NewTable()
+	t.SetName(string(x.Name))
+	t.SetType("VIEW")
+
+	return t
+}
+
+// NewColumnFromDDL returns the column defined by a column definition
+func NewColumnFromDDL(x spansql.ColumnDef, position int64, pkey bool) Column {
+	c := NewColumn()
+	c.SetName(string(x.Name))
+	c.SetPosition(position)
+	c.SetNullable(nullable(x.NotNull))
+	c.SetSpannerType(x.Type.SQL())
+	c.SetSpannerState("COMMITTED")
+	c.SetPrimaryKey(pkey)
+
+	if x.Generated != nil {
+		c.SetIsGenerated(true)
+		c.SetGenerationExpression(x.Generated.SQL())
+		c.SetIsStored("YES")
+	}
+
+	if x.Options.AllowCommitTimestamp != nil {
+		c.SetAllowCommitTimestamp(*x.Options.AllowCommitTimestamp)
+	}
+
+	return c
+}
+
+// NewIndexFromDDL returns the index created by a CREATE INDEX statement
+func NewIndexFromDDL(x *spansql.CreateIndex) Index {
+	i := NewIndex()
+
+	i.SetIndexName(string(x.Name))
+	i.SetIsUnique(x.Unique)
+	i.SetIsNullFiltered(x.NullFiltered)
+	i.SetIndexState("READ_WRITE")
+
+	return i
+}
+
+// NewForeignKeyFromDDL returns a FOREIGN KEY constraint. Unnamed constraints have an empty name.
+func NewForeignKeyFromDDL(name spansql.ID, x spansql.ForeignKey) ForeignKey {
+	f := NewForeignKey()
+
+	f.SetName(string(name))
+	f.SetColumnNames(idNames(x.Columns))
+	f.SetReferencedTable(string(x.RefTable))
+	f.SetReferencedColumnNames(idNames(x.RefColumns))
+
+	return f
+}
+
+// nullable returns the IS_NULLABLE value of the information schema
+func nullable(notNull bool) string {
+	if notNull {
+		return "NO"
+	}
+
+	return "YES"
+}
+
+func idNames(ids []spansql.ID) []string {
+	ret := make([]string, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, string(id))
+	}
+
+	return ret
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoadSchemaFromDDL(t *testing.T) {
+	Convey("LoadSchemaFromDDL", t, func() {
+		ddl, err := backend.ParseDDLFile("../../schemas/multi_table.sql")
+		So(err, ShouldBeNil)
+
+		s, err := LoadSchemaFromDDL(ddl)
+		So(err, ShouldBeNil)
+		So(s.Tables().Len(), ShouldEqual, 5)
+
+		singers := s.GetTable("Singers")
+		So(singers, ShouldNotBeNil)
+		So(singers.IsApex(), ShouldBeTrue)
+		So(singers.ColumnNames(), ShouldResemble, []string{"SingerId", "FirstName", "LastName", "BirthDate", "ByteField"})
+		So(singers.PrimaryKeyNames(), ShouldResemble, []string{"SingerId"})
+
+		id := singers.Columns().Columns()[0]
+		So(id.Nullable(), ShouldEqual, "NO")
+		So(id.SpannerType(), ShouldEqual, "INT64")
+		So(singers.Columns().Columns()[4].SpannerType(), ShouldEqual, "BYTES(1025)")
+
+		songs := s.GetTable("Songs")
+		So(songs, ShouldNotBeNil)
+		So(songs.ParentName(), ShouldEqual, "Albums")
+		So(songs.PrimaryKeyNames(), ShouldResemble, []string{"SingerId", "AlbumId", "TrackId"})
+		So(songs.GetApex().Name(), ShouldEqual, "Singers")
+		So(s.GetTable("Albums").Child().Name(), ShouldEqual, "Songs")
+
+		So(singers.Indexes().Indexes(), ShouldHaveLength, 1)
+		So(singers.Indexes().Indexes()[0].IndexName(), ShouldEqual, "SingersByFirstLastName")
+
+		concerts := s.GetTable("Concerts")
+		So(concerts, ShouldNotBeNil)
+		So(concerts.ForeignKeys(), ShouldHaveLength, 1)
+		fk := concerts.ForeignKeys()[0]
+		So(fk.Name(), ShouldEqual, "FKConcertsSingerId")
+		So(fk.ColumnNames(), ShouldResemble, []string{"SingerId"})
+		So(fk.ReferencedTable(), ShouldEqual, "Singers")
+		So(fk.ReferencedColumnNames(), ShouldResemble, []string{"SingerId"})
+
+		Convey("Column options, generated columns, views and alterations", func() {
+			ddl, err := spansql.ParseDDL("test.sql", `
+CREATE TABLE Accounts (
+  AccountId INT64 NOT NULL,
+  Balance   FLOAT64,
+  Doubled   FLOAT64 AS (Balance * 2) STORED,
+  Updated   TIMESTAMP OPTIONS (allow_commit_timestamp = true),
+) PRIMARY KEY (AccountId);
+
+CREATE TABLE Transfers (
+  TransferId INT64 NOT NULL,
+  Source     INT64 NOT NULL,
+) PRIMARY KEY (TransferId);
+
+CREATE UNIQUE NULL_FILTERED INDEX TransfersBySource ON Transfers(Source);
+
+ALTER TABLE Transfers ADD COLUMN Target INT64;
+ALTER TABLE Transfers ALTER COLUMN Target INT64 NOT NULL;
+ALTER TABLE Transfers ADD CONSTRAINT FKTarget FOREIGN KEY (Target) REFERENCES Accounts (AccountId);
+ALTER TABLE Transfers ADD FOREIGN KEY (Source) REFERENCES Accounts (AccountId);
+
+CREATE VIEW Balances SQL SECURITY INVOKER AS SELECT AccountId, Balance FROM Accounts;
+`)
+			So(err, ShouldBeNil)
+
+			s, err := LoadSchemaFromDDL(ddl)
+			So(err, ShouldBeNil)
+			So(s.Tables().Len(), ShouldEqual, 3)
+
+			cols := s.GetTable("Accounts").Columns()
+			So(cols.GetColumn("Doubled").IsGenerated(), ShouldBeTrue)
+			So(cols.GetColumn("Doubled").GenerationExpression(), ShouldEqual, "(Balance)*(2)")
+			So(cols.GetColumn("Updated").AllowCommitTimestamp(), ShouldBeTrue)
+			So(cols.GetColumn("Balance").AllowCommitTimestamp(), ShouldBeFalse)
+
+			transfers := s.GetTable("Transfers")
+			So(transfers.ColumnNames(), ShouldResemble, []string{"TransferId", "Source", "Target"})
+			So(transfers.Columns().GetColumn("Target").Position(), ShouldEqual, 3)
+			So(transfers.Columns().GetColumn("Target").Nullable(), ShouldEqual, "NO")
+			So(transfers.ForeignKeys(), ShouldHaveLength, 2)
+			So(transfers.ForeignKeys()[0].Name(), ShouldEqual, "FKTarget")
+			So(transfers.ForeignKeys()[1].Name(), ShouldBeEmpty)
+
+			idx := transfers.Indexes().Indexes()[0]
+			So(idx.IsUnique(), ShouldBeTrue)
+			So(idx.IsNullFiltered(), ShouldBeTrue)
+
+			view := s.GetTable("Balances")
+			So(view.IsView(), ShouldBeTrue)
+			So(view.Columns().Len(), ShouldEqual, 0)
+		})
+
+		Convey("Foreign key to an unknown table", func() {
+			ddl, err := spansql.ParseDDL("", "CREATE TABLE A (Id INT64, FOREIGN KEY (Id) REFERENCES B (Id)) PRIMARY KEY (Id)")
+			So(err, ShouldBeNil)
+
+			_, err = LoadSchemaFromDDL(ddl)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Drop statements", func() {
+			ddl, err := spansql.ParseDDL("", "CREATE TABLE A (Id INT64) PRIMARY KEY (Id); DROP TABLE A")
+			So(err, ShouldBeNil)
+
+			_, err = LoadSchemaFromDDL(ddl)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("LoadSchemaFile", func() {
+			s, err := LoadSchemaFile("../../schemas/single_table.sql")
+			So(err, ShouldBeNil)
+			So(s.GetTable("SingleSingers"), ShouldNotBeNil)
+
+			_, err = LoadSchemaFile("missing.sql")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Index on an unknown table", func() {
+			ddl, err := spansql.ParseDDL("", "CREATE INDEX ByName ON Missing(Name)")
+			So(err, ShouldBeNil)
+
+			_, err = LoadSchemaFromDDL(ddl)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+type (
+	// ForeignKey is a FOREIGN KEY constraint of a table. Foreign keys are only known to schemas
+	// loaded from DDL.
+	ForeignKey interface {
+		SetName(string)
+		Name() string
+		SetColumnNames([]string)
+		ColumnNames() []string
+		SetReferencedTable(string)
+		ReferencedTable() string
+		SetReferencedColumnNames([]string)
+		ReferencedColumnNames() []string
+	}
+
+	foreignKey struct {
+		name              string
+		columns           []string
+		referencedTable   string
+		referencedColumns []string
+	}
+)
+
+func NewForeignKey() ForeignKey {
+	return &foreignKey{}
+}
+
+func (f *foreignKey) SetName(x string) {
+	f.name = x
+}
+
+func (f *foreignKey) Name() string {
+	return f.name
+}
+
+func (f *foreignKey) SetColumnNames(x []string) {
+	f.columns = x
+}
+
+func (f *foreignKey) ColumnNames() []string {
+	return f.columns
+}
+
+func (f *foreignKey) SetReferencedTable(x string) {
+	f.referencedTable = x
+}
+
+func (f *foreignKey) ReferencedTable() string {
+	return f.referencedTable
+}
+
+func (f *foreignKey) SetReferencedColumnNames(x []string) {
+	f.referencedColumns = x
+}
+
+func (f *foreignKey) ReferencedColumnNames() []string {
+	return f.referencedColumns
+}
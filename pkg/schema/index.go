@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema/information"
 )
 
@@ -56,7 +57,7 @@ func NewIndexFromSchema(x information.Index) Index {
 	return i
 }
 
-func LoadIndexes(ctx context.Context, client *spanner.Client, t Table) error {
+func LoadIndexes(ctx context.Context, client backend.Client, t Table) error {
 	iter := client.Single().Query(ctx, information.GetIndexesQuery(t.Name()))
 	defer iter.Stop()
 	err := iter.Do(func(row *spanner.Row) error {
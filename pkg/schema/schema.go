@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 )
 
@@ -46,6 +47,16 @@ func NewSchema() Schema {
 }
 
 func LoadSchema(ctx context.Context, cfg *config.Config) (Schema, error) {
+	// Planning does not need a connection when the schema is kept in a DDL file. The in memory
+	// backend has no information schema, read the ddl it was created from.
+	path := cfg.SchemaFile
+	if path == "" && cfg.Backend == backend.Memory {
+		path = cfg.Create.DDLFile
+	}
+	if path != "" {
+		return LoadSchemaFile(path)
+	}
+
 	client, err := cfg.Client(ctx)
 	if err != nil {
 		return nil, err
@@ -78,6 +78,10 @@ func TestSchema(t *testing.T) {
 			// get apex on apex returns itself
 			So(t1.GetApex().Name(), ShouldEqual, t1.Name())
 
+			// Depth counts the parents
+			So(t1.Depth(), ShouldEqual, 0)
+			So(t3.Depth(), ShouldEqual, 2)
+
 			// GetAllRelationNames
 			relativeNames := t3.GetAllRelationNames()
 			So(relativeNames, ShouldHaveLength, 3)
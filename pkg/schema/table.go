@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema/information"
 )
 
@@ -46,7 +47,10 @@ type (
 
 		AddColumn(Column)
 		AddIndex(Index)
+		AddForeignKey(ForeignKey)
 		Columns() Columns
+		Indexes() Indexes
+		ForeignKeys() []ForeignKey
 		ColumnNames() []string
 
 		PrimaryKeys() Columns
@@ -54,6 +58,8 @@ type (
 		PointInsertStatement() (string, error)
 		PointReadStatement(...string) (string, error)
 		TableSample(float64) (string, error)
+		TableSampleRows(int) (string, error)
+		PrimaryKeyQuery() (string, error)
 
 		IsView() bool
 		// IsInterleaved will return true if the table has a parent or child
@@ -64,6 +70,8 @@ type (
 		IsBottom() bool
 		// GetApex will return the top level parent or nil if it does not exist
 		GetApex() Table
+		// Depth will return the number of interleaved parents of the table
+		Depth() int
 		GetAllRelationNames() []string
 	}
 
@@ -77,6 +85,7 @@ type (
 		spannerState string
 		columns      Columns
 		indexes      Indexes
+		foreignKeys  []ForeignKey
 	}
 )
 
@@ -87,7 +96,7 @@ func NewTable() Table {
 	}
 }
 
-func LoadTable(ctx context.Context, client *spanner.Client, s Schema, t string) error {
+func LoadTable(ctx context.Context, client backend.Client, s Schema, t string) error {
 	iter := client.Single().Query(ctx, information.GetTableQuery(t))
 	defer iter.Stop()
 	err := iter.Do(func(row *spanner.Row) error {
@@ -109,7 +118,7 @@ func LoadTable(ctx context.Context, client *spanner.Client, s Schema, t string)
 	return nil
 }
 
-func LoadTables(ctx context.Context, client *spanner.Client, s Schema) error {
+func LoadTables(ctx context.Context, client backend.Client, s Schema) error {
 	iter := client.Single().Query(ctx, information.ListTablesQuery())
 	defer iter.Stop()
 
@@ -226,10 +235,22 @@ func (t *table) AddIndex(x Index) {
 	t.indexes.AddIndex(x)
 }
 
+func (t *table) AddForeignKey(x ForeignKey) {
+	t.foreignKeys = append(t.foreignKeys, x)
+}
+
 func (t *table) Columns() Columns {
 	return t.columns
 }
 
+func (t *table) Indexes() Indexes {
+	return t.indexes
+}
+
+func (t *table) ForeignKeys() []ForeignKey {
+	return t.foreignKeys
+}
+
 func (t *table) PointInsertStatement() (string, error) {
 	var b strings.Builder
 
@@ -289,6 +310,35 @@ func (t *table) TableSample(x float64) (string, error) {
 	return b.String(), nil
 }
 
+func (t *table) TableSampleRows(x int) (string, error) {
+	pkeys := t.PrimaryKeyNames()
+
+	if len(pkeys) <= 0 {
+		return "", errors.New("no primary keys associated with table")
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "SELECT %s FROM %s TABLESAMPLE RESERVOIR (%d ROWS)", strings.Join(pkeys, ", "), t.Name(), x)
+
+	return b.String(), nil
+}
+
+// PrimaryKeyQuery returns a statement selecting every primary key in the table. It is root partitionable.
+func (t *table) PrimaryKeyQuery() (string, error) {
+	pkeys := t.PrimaryKeyNames()
+
+	if len(pkeys) <= 0 {
+		return "", errors.New("no primary keys associated with table")
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "SELECT %s FROM %s", strings.Join(pkeys, ", "), t.Name())
+
+	return b.String(), nil
+}
+
 func (t *table) PrimaryKeys() Columns {
 	return t.columns.PrimaryKeys()
 }
@@ -356,6 +406,15 @@ func (t *table) GetApex() Table {
 	return last
 }
 
+func (t *table) Depth() int {
+	d := 0
+	for p := t.Parent(); p != nil; p = p.Parent() {
+		d++
+	}
+
+	return d
+}
+
 func (t *table) GetAllRelationNames() []string {
 	apex := t.GetApex()
 	ret := []string{apex.Name()}
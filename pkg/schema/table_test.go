@@ -62,5 +62,34 @@ func TestTable(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(stmt, ShouldEqual, "SELECT foo, bar, baz FROM test WHERE foo = @foo AND bar = @bar")
 		})
+
+		Convey("Sample statements", func() {
+			t := NewTable()
+			t.SetName("test")
+
+			c1 := NewColumn()
+			c1.SetName("foo")
+			c1.SetPrimaryKey(true)
+			c2 := NewColumn()
+			c2.SetName("bar")
+			c2.SetPrimaryKey(true)
+			c3 := NewColumn()
+			c3.SetName("baz")
+
+			t.AddColumn(c1)
+			t.AddColumn(c2)
+			t.AddColumn(c3)
+
+			stmt, err := t.TableSampleRows(100)
+			So(err, ShouldBeNil)
+			So(stmt, ShouldEqual, "SELECT foo, bar FROM test TABLESAMPLE RESERVOIR (100 ROWS)")
+
+			stmt, err = t.PrimaryKeyQuery()
+			So(err, ShouldBeNil)
+			So(stmt, ShouldEqual, "SELECT foo, bar FROM test")
+
+			_, err = NewTable().PrimaryKeyQuery()
+			So(err, ShouldNotBeNil)
+		})
 	})
 }
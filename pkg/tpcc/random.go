@@ -0,0 +1,152 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpcc
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	numeric      = "0123456789"
+)
+
+var (
+	// Syllables of customer last names
+	syllables = []string{"BAR", "OUGHT", "ABLE", "PRI", "PRES", "ESE", "ANTI", "CALLY", "ATION", "EING"}
+
+	// C constants of NURand. The constant of last names differs between load and run by a
+	// delta the specification allows, so that runs do not pick exactly the popular names of
+	// the load.
+	loadC = nurandC{last: 157, id: 259, item: 7911}
+	runC  = nurandC{last: 223, id: 259, item: 7911}
+)
+
+type (
+	// nurandC holds the C constants of the three NURand uses
+	nurandC struct {
+		last int // A = 255, customer last names
+		id   int // A = 1023, customer ids
+		item int // A = 8191, item ids
+	}
+
+	// random generates the values of the specification
+	random struct {
+		*rand.Rand
+		c nurandC
+	}
+)
+
+func newRandom(src rand.Source, c nurandC) *random {
+	return &random{Rand: rand.New(src), c: c}
+}
+
+// between returns a uniform value in [min, max]
+func (r *random) between(min int, max int) int {
+	return min + r.Intn(max-min+1)
+}
+
+// nurand returns a non-uniform value in [x, y]
+func (r *random) nurand(a int, c int, x int, y int) int {
+	return (((r.between(0, a) | r.between(x, y)) + c) % (y - x + 1)) + x
+}
+
+// customerID returns a customer id of a district with n customers
+func (r *random) customerID(n int) int {
+	return r.nurand(1023, r.c.id, 1, n)
+}
+
+// itemID returns an item id of a catalog of n items
+func (r *random) itemID(n int) int {
+	return r.nurand(8191, r.c.item, 1, n)
+}
+
+// lastName returns the last name of a customer to look up in a district with n customers
+func (r *random) lastName(n int) string {
+	return lastName(r.nurand(255, r.c.last, 0, maxLastName(n)))
+}
+
+// astring returns a random alphanumeric string with a length in [min, max]
+func (r *random) astring(min int, max int) string {
+	return r.chars(alphanumeric, r.between(min, max))
+}
+
+// nstring returns a random numeric string with a length in [min, max]
+func (r *random) nstring(min int, max int) string {
+	return r.chars(numeric, r.between(min, max))
+}
+
+func (r *random) chars(set string, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = set[r.Intn(len(set))]
+	}
+
+	return string(b)
+}
+
+// zip returns a zip code
+func (r *random) zip() string {
+	return r.nstring(4, 4) + "11111"
+}
+
+// data returns item or stock data, a tenth of which contains ORIGINAL
+func (r *random) data() string {
+	s := r.astring(26, 50)
+	if r.Intn(10) != 0 {
+		return s
+	}
+
+	i := r.Intn(len(s) - len("ORIGINAL") + 1)
+	return s[:i] + "ORIGINAL" + s[i+len("ORIGINAL"):]
+}
+
+// amount returns an amount in [min, max] with two decimals
+func (r *random) amount(min float64, max float64) float64 {
+	return float64(r.between(int(min*100), int(max*100))) / 100
+}
+
+// wait returns a think time with a mean of mean, truncated at ten times the mean
+func (r *random) wait(mean time.Duration) time.Duration {
+	d := time.Duration(-math.Log(1-r.Float64()) * float64(mean))
+	if d > 10*mean {
+		return 10 * mean
+	}
+
+	return d
+}
+
+// lastName returns the name made of the syllables of the digits of n
+func lastName(n int) string {
+	var b strings.Builder
+	b.WriteString(syllables[n/100])
+	b.WriteString(syllables[n/10%10])
+	b.WriteString(syllables[n%10])
+
+	return b.String()
+}
+
+// maxLastName returns the largest last name number of a district with n customers. The first
+// 1000 customers are named after their id, so smaller districts only have the first n names.
+func maxLastName(n int) int {
+	if n < 1000 {
+		return n - 1
+	}
+
+	return 999
+}
@@ -0,0 +1,276 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/seed"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/selector"
+	"github.com/cloudspannerecosystem/gcsb/pkg/histogram"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	// Keying times and mean think times of the transaction types
+	keyingTimes = map[string]time.Duration{
+		NewOrder:    18 * time.Second,
+		Payment:     3 * time.Second,
+		OrderStatus: 2 * time.Second,
+		Delivery:    2 * time.Second,
+		StockLevel:  2 * time.Second,
+	}
+	thinkTimes = map[string]time.Duration{
+		NewOrder:    12 * time.Second,
+		Payment:     12 * time.Second,
+		OrderStatus: 10 * time.Second,
+		Delivery:    5 * time.Second,
+		StockLevel:  5 * time.Second,
+	}
+)
+
+type (
+	// terminal runs transactions against a home warehouse and district
+	terminal struct {
+		w         *Workload
+		r         *random
+		wID       int64
+		dID       int64
+		selector  selector.Selector
+		timers    map[string]metrics.Timer
+		rollbacks metrics.Counter
+	}
+)
+
+// Run runs the transaction mix on a terminal per thread until tpcc.duration has passed or,
+// without a duration, operations.total transactions completed. Terminals are spread over the
+// warehouses and their districts. The table argument is ignored.
+func (w *Workload) Run(_ string) error {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if w.Config.TPCC.Duration > 0 {
+		ctx, cancel = context.WithTimeout(w.Context, w.Config.TPCC.Duration)
+	} else {
+		ctx, cancel = context.WithCancel(w.Context)
+	}
+	defer cancel()
+
+	// Without a duration, terminals take transactions from a shared budget
+	remaining := int64(w.Config.Operations.Total)
+	next := func() bool {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		return w.Config.TPCC.Duration > 0 || atomic.AddInt64(&remaining, -1) >= 0
+	}
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+
+	for i := 0; i < w.Config.Threads; i++ {
+		t, err := w.newTerminal(i)
+		if err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := t.run(ctx, next); err != nil {
+				once.Do(func() {
+					first = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if first == nil && w.Context.Err() != nil {
+		return w.Context.Err()
+	}
+
+	return first
+}
+
+// newTerminal returns the terminal with index i
+func (w *Workload) newTerminal(i int) (*terminal, error) {
+	r := newRandom(seed.Source(w.Config.Seed, "tpcc", "terminal", seed.Worker(i)), runC)
+
+	choices := make([]selector.WeightedChoice, len(Transactions))
+	for i, txn := range Transactions {
+		choices[i] = selector.NewWeightedChoice(txn, mix[txn])
+	}
+
+	s, err := selector.NewWeightedRandomSelector(r.Rand, choices...)
+	if err != nil {
+		return nil, fmt.Errorf("creating transaction selector: %s", err.Error())
+	}
+
+	timers := make(map[string]metrics.Timer, len(Transactions))
+	for _, txn := range Transactions {
+		timers[txn] = histogram.GetOrRegisterTimer(MetricName(txn, "time"), w.MetricRegistry, w.Config.Histogram)
+	}
+
+	warehouses := w.Config.TPCC.Warehouses
+	return &terminal{
+		w:         w,
+		r:         r,
+		wID:       int64(i%warehouses + 1),
+		dID:       int64(i/warehouses%Districts + 1),
+		selector:  s,
+		timers:    timers,
+		rollbacks: metrics.GetOrRegisterCounter(MetricName(NewOrder, "rollbacks"), w.MetricRegistry),
+	}, nil
+}
+
+// run runs transactions as long as next returns true. It returns an error if the workload
+// must stop.
+func (t *terminal) run(ctx context.Context, next func() bool) error {
+	keying := t.w.Config.TPCC.Keying
+
+	for next() {
+		txn := t.selector.Select().Item().(string)
+		if keying && !sleep(ctx, keyingTimes[txn]) {
+			return nil
+		}
+
+		octx := t.observeStart(ctx, txn)
+		start := time.Now()
+		err := t.execute(octx, txn)
+		d := time.Since(start)
+
+		// The run ended while the transaction was in flight
+		if ctx.Err() != nil {
+			t.observeDone(octx, txn, d, ctx.Err())
+			return nil
+		}
+
+		if errors.Is(err, errRollback) {
+			t.rollbacks.Inc(1)
+			err = nil
+		}
+
+		t.observeDone(octx, txn, d, err)
+		if err == nil {
+			t.timers[txn].Update(d)
+		}
+
+		err = t.checkError(txn, err)
+		if err != nil {
+			return err
+		}
+
+		if keying && !sleep(ctx, t.r.wait(thinkTimes[txn])) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// execute runs a transaction of type txn
+func (t *terminal) execute(ctx context.Context, txn string) error {
+	switch txn {
+	case NewOrder:
+		return t.newOrder(ctx)
+	case Payment:
+		return t.payment(ctx)
+	case OrderStatus:
+		return t.orderStatus(ctx)
+	case Delivery:
+		return t.delivery(ctx)
+	case StockLevel:
+		return t.stockLevel(ctx)
+	}
+
+	return fmt.Errorf("unknown transaction type '%s'", txn)
+}
+
+// checkError records the outcome of a transaction in the error budget. Like the core
+// workload, it returns an error if the budget is exceeded or the error is fatal.
+func (t *terminal) checkError(txn string, err error) error {
+	budgetErr := t.w.ErrorBudget.Record(txn, 1, err)
+	if budgetErr != nil {
+		return budgetErr
+	}
+
+	if err != nil {
+		log.Printf("%s transaction failed: %s", txn, err.Error())
+
+		switch workload.ErrorCode(err) {
+		case codes.Unauthenticated, codes.Canceled:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// otherWarehouse returns a warehouse other than the home warehouse
+func (t *terminal) otherWarehouse() int64 {
+	w := int64(t.r.between(1, t.w.Config.TPCC.Warehouses-1))
+	if w >= t.wID {
+		w++
+	}
+
+	return w
+}
+
+func (t *terminal) observeStart(ctx context.Context, txn string) context.Context {
+	if t.w.Observer == nil {
+		return ctx
+	}
+
+	return t.w.Observer.Start(ctx, "", txn)
+}
+
+func (t *terminal) observeDone(ctx context.Context, txn string, d time.Duration, err error) {
+	if t.w.Observer == nil {
+		return
+	}
+
+	code := codes.OK
+	if err != nil {
+		code = workload.ErrorCode(err)
+	}
+
+	t.w.Observer.Done(ctx, "", txn, 1, d, code)
+}
+
+// sleep waits for d. It returns false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpcc
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	// Rows per batch of the load
+	loadBatchSize = 1000
+
+	// Items per load job
+	itemChunk = 10000
+)
+
+// Columns of the tables in the order of the load
+var (
+	warehouseColumns = []string{"w_id", "w_name", "w_street_1", "w_street_2", "w_city", "w_state", "w_zip", "w_tax", "w_ytd"}
+	districtColumns  = []string{"w_id", "d_id", "d_name", "d_street_1", "d_street_2", "d_city", "d_state", "d_zip", "d_tax", "d_ytd", "d_next_o_id"}
+	customerColumns  = []string{"w_id", "d_id", "c_id", "c_first", "c_middle", "c_last", "c_street_1", "c_street_2", "c_city", "c_state", "c_zip",
+		"c_phone", "c_since", "c_credit", "c_credit_lim", "c_discount", "c_balance", "c_ytd_payment", "c_payment_cnt", "c_delivery_cnt", "c_data"}
+	historyColumns   = []string{"w_id", "d_id", "c_id", "h_id", "h_d_id", "h_w_id", "h_date", "h_amount", "h_data"}
+	orderColumns     = []string{"w_id", "d_id", "o_id", "o_c_id", "o_entry_d", "o_carrier_id", "o_ol_cnt", "o_all_local"}
+	newOrderColumns  = []string{"w_id", "d_id", "o_id"}
+	orderLineColumns = []string{"w_id", "d_id", "o_id", "ol_number", "ol_i_id", "ol_supply_w_id", "ol_delivery_d", "ol_quantity", "ol_amount", "ol_dist_info"}
+	itemColumns      = []string{"i_id", "i_im_id", "i_name", "i_price", "i_data"}
+	stockColumns     = []string{"w_id", "s_i_id", "s_quantity", "s_dist_01", "s_dist_02", "s_dist_03", "s_dist_04", "s_dist_05", "s_dist_06",
+		"s_dist_07", "s_dist_08", "s_dist_09", "s_dist_10", "s_ytd", "s_order_cnt", "s_remote_cnt", "s_data"}
+)
+
+// newHistoryID returns the id of a history row. History has no natural key.
+func newHistoryID(r *random) string {
+	id, err := uuid.NewRandomFromReader(r)
+	if err != nil {
+		// Reads of a math/rand generator do not fail
+		panic(err)
+	}
+
+	return id.String()
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tpcc implements the TPC-C benchmark: a database of warehouses, their districts,
+// customers, orders and stock (schemas/tpcc.sql), and terminals running the five transaction
+// types of the specification in its standard mix. Results are reported as tpmC, the New-Order
+// transactions completed per minute, and the latency of every transaction type.
+//
+// It follows the data generation and transaction profiles of the specification, but is not a
+// compliant implementation: there is no audit, the database may be scaled down with
+// tpcc.items and tpcc.customers, and keying and think times are off unless tpcc.keying is set.
+package tpcc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/cloudspannerecosystem/gcsb/schemas"
+)
+
+const (
+	// Transaction types, also used as operation names in metrics
+	NewOrder    = "new_order"
+	Payment     = "payment"
+	OrderStatus = "order_status"
+	Delivery    = "delivery"
+	StockLevel  = "stock_level"
+
+	// Districts per warehouse
+	Districts = 10
+
+	// File of the schema in package schemas
+	SchemaFile = "tpcc.sql"
+
+	// Prefix of metrics scoped to a transaction type
+	metricPrefix = "transactions."
+)
+
+var (
+	// Transactions are the transaction types in the order of the standard mix
+	Transactions = []string{NewOrder, Payment, OrderStatus, Delivery, StockLevel}
+
+	// mix weighs the transaction types like the minimum percentages of the specification, with
+	// the remainder going to New-Order
+	mix = map[string]uint{
+		NewOrder:    45,
+		Payment:     43,
+		OrderStatus: 4,
+		Delivery:    4,
+		StockLevel:  4,
+	}
+)
+
+// Schema returns the DDL of the TPC-C tables
+func Schema() ([]byte, error) {
+	return schemas.FS.ReadFile(SchemaFile)
+}
+
+// WriteSchema writes the DDL of the TPC-C tables to a temporary file and returns its path. The
+// in memory backend is created from a file.
+func WriteSchema() (string, error) {
+	b, err := Schema()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := ioutil.TempFile("", "gcsb-tpcc-*.sql")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	_, err = f.Write(b)
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("writing schema: %s", err.Error())
+	}
+
+	return f.Name(), nil
+}
+
+// MetricName returns the name of a metric of a transaction type. Transactions are timed in
+// transactions.<txn>.time, rolled back New-Orders are counted in transactions.new_order.rollbacks.
+func MetricName(txn string, name string) string {
+	return metricPrefix + txn + "." + name
+}
+
+// ParseMetricName splits a name returned by MetricName
+func ParseMetricName(name string) (txn string, metric string, ok bool) {
+	if !strings.HasPrefix(name, metricPrefix) {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(name, metricPrefix), ".", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,303 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRandom(t *testing.T) {
+	r := newRandom(rand.NewSource(1), runC)
+
+	for i := 0; i < 10000; i++ {
+		if id := r.customerID(3000); id < 1 || id > 3000 {
+			t.Fatalf("customerID(3000) = %d", id)
+		}
+		if id := r.itemID(100); id < 1 || id > 100 {
+			t.Fatalf("itemID(100) = %d", id)
+		}
+		if d := r.data(); len(d) < 26 || len(d) > 50 {
+			t.Fatalf("data() = %q", d)
+		}
+	}
+
+	if got := lastName(371); got != "PRICALLYOUGHT" {
+		t.Errorf("lastName(371) = %s, want PRICALLYOUGHT", got)
+	}
+
+	// Small districts only have the names of their first customers
+	names := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		names[lastName(i)] = true
+	}
+	for i := 0; i < 1000; i++ {
+		if n := r.lastName(30); !names[n] {
+			t.Fatalf("lastName(30) = %s, which no customer has", n)
+		}
+	}
+
+	original := 0
+	for i := 0; i < 1000; i++ {
+		if strings.Contains(r.data(), "ORIGINAL") {
+			original++
+		}
+	}
+	if original < 50 || original > 150 {
+		t.Errorf("%d of 1000 data values contain ORIGINAL, want about 100", original)
+	}
+}
+
+func TestParseMetricName(t *testing.T) {
+	txn, metric, ok := ParseMetricName(MetricName(NewOrder, "time"))
+	if !ok || txn != NewOrder || metric != "time" {
+		t.Errorf("ParseMetricName = %s, %s, %t", txn, metric, ok)
+	}
+
+	if _, _, ok := ParseMetricName("operations.read.time"); ok {
+		t.Error("parsed operations.read.time")
+	}
+}
+
+func TestWorkloadMemory(t *testing.T) {
+	v := viper.New()
+	v.Set("backend", backend.Memory)
+	v.Set("create.ddl_file", "../../schemas/tpcc.sql")
+	v.Set("threads", 4)
+	v.Set("seed", 7)
+	v.Set("operations.total", 300)
+	v.Set("tpcc.warehouses", 2)
+	v.Set("tpcc.items", 100)
+	v.Set("tpcc.customers", 30)
+
+	cfg, err := config.NewConfig(v)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	ctx := context.Background()
+	execute := func(f func(workload.Workload) error) metrics.Registry {
+		registry := metrics.NewRegistry()
+		wl, err := NewWorkload(workload.WorkloadConfig{Context: ctx, Config: cfg, MetricRegistry: registry})
+		if err != nil {
+			t.Fatalf("NewWorkload: %v", err)
+		}
+		defer wl.Stop()
+
+		if err := f(wl); err != nil {
+			t.Fatalf("executing workload: %v", err)
+		}
+
+		registry.Each(func(name string, _ interface{}) {
+			if _, _, ok := workload.ParseErrorMetricName(name); ok {
+				t.Errorf("unexpected error metric %s", name)
+			}
+		})
+
+		return registry
+	}
+
+	load := execute(func(wl workload.Workload) error { return wl.Load(nil) })
+	want := map[string]int64{
+		"item":      100,
+		"warehouse": 2,
+		"stock":     200,
+		"district":  20,
+		"customer":  600,
+		"history":   600,
+		"orders":    600,
+		"new_order": 180, // The newest 9 orders of every district
+	}
+	for table, rows := range want {
+		if n := metrics.GetOrRegisterMeter(workload.TableMetricName(table, "operations.write.rate"), load).Count(); n != rows {
+			t.Errorf("loaded %d rows into %s, want %d", n, table, rows)
+		}
+	}
+
+	run := execute(func(wl workload.Workload) error { return wl.Run("") })
+	var total int64
+	for _, txn := range Transactions {
+		n := metrics.GetOrRegisterTimer(MetricName(txn, "time"), run).Count()
+		if n == 0 {
+			t.Errorf("no %s transactions", txn)
+		}
+		total += n
+	}
+	if total != 300 {
+		t.Errorf("ran %d transactions, want 300", total)
+	}
+
+	client, err := cfg.Client(ctx)
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	checkConsistency(t, ctx, client)
+}
+
+// codeObserver records the codes of finished transactions
+type codeObserver struct {
+	mu    sync.Mutex
+	codes []codes.Code
+}
+
+func (o *codeObserver) Start(ctx context.Context, table string, op string) context.Context {
+	return ctx
+}
+
+func (o *codeObserver) Done(ctx context.Context, table string, op string, n int, d time.Duration, code codes.Code) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.codes = append(o.codes, code)
+}
+
+func TestTerminalCanceled(t *testing.T) {
+	v := viper.New()
+	v.Set("backend", backend.Memory)
+	v.Set("create.ddl_file", "../../schemas/tpcc.sql")
+	v.Set("seed", 7)
+
+	cfg, err := config.NewConfig(v)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	obs := &codeObserver{}
+	wl, err := NewWorkload(workload.WorkloadConfig{Context: context.Background(), Config: cfg, MetricRegistry: metrics.NewRegistry(), Observer: obs})
+	if err != nil {
+		t.Fatalf("NewWorkload: %v", err)
+	}
+	defer wl.Stop()
+
+	term, err := wl.(*Workload).newTerminal(0)
+	if err != nil {
+		t.Fatalf("newTerminal: %v", err)
+	}
+
+	// A transaction in flight when the run ends is observed as canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	once := true
+	next := func() bool {
+		ret := once
+		once = false
+		return ret
+	}
+	if err := term.run(ctx, next); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(obs.codes) != 1 || obs.codes[0] != codes.Canceled {
+		t.Errorf("observed %v, want one canceled transaction", obs.codes)
+	}
+
+	// Context errors returned before a request is sent map to their gRPC codes
+	term.observeDone(context.Background(), NewOrder, time.Millisecond, context.DeadlineExceeded)
+	if len(obs.codes) != 2 || obs.codes[1] != codes.DeadlineExceeded {
+		t.Errorf("observed %v, want a transaction past its deadline", obs.codes)
+	}
+
+	if err := term.checkError(NewOrder, fmt.Errorf("new order: %w", context.Canceled)); err == nil {
+		t.Errorf("checkError did not stop on a canceled transaction")
+	}
+}
+
+// checkConsistency checks the first consistency conditions of the specification
+func checkConsistency(t *testing.T, ctx context.Context, client backend.Client) {
+	type district struct {
+		ytd               float64
+		next              int64
+		maxOrder, maxNew  int64
+		minNew, newOrders int64
+	}
+
+	read := func(table string, columns []string, f func(r *spanner.Row) error) {
+		if err := client.Single().Read(ctx, table, spanner.AllKeys(), columns).Do(f); err != nil {
+			t.Fatalf("reading %s: %v", table, err)
+		}
+	}
+
+	districts := make(map[[2]int64]*district)
+	ytd := make(map[int64]float64)
+	read("district", []string{"w_id", "d_id", "d_ytd", "d_next_o_id"}, func(r *spanner.Row) error {
+		var k [2]int64
+		d := &district{minNew: math.MaxInt64}
+		districts[k] = d
+		err := r.Columns(&k[0], &k[1], &d.ytd, &d.next)
+		districts[k] = d
+		ytd[k[0]] += d.ytd
+		return err
+	})
+
+	read("warehouse", []string{"w_id", "w_ytd"}, func(r *spanner.Row) error {
+		var w int64
+		var y float64
+		err := r.Columns(&w, &y)
+		if math.Abs(y-ytd[w]) > 0.01 {
+			t.Errorf("w_ytd of warehouse %d is %.2f, its districts sum up to %.2f", w, y, ytd[w])
+		}
+		return err
+	})
+
+	read("orders", []string{"w_id", "d_id", "o_id"}, func(r *spanner.Row) error {
+		var k [2]int64
+		var o int64
+		err := r.Columns(&k[0], &k[1], &o)
+		if d := districts[k]; o > d.maxOrder {
+			d.maxOrder = o
+		}
+		return err
+	})
+
+	read("new_order", []string{"w_id", "d_id", "o_id"}, func(r *spanner.Row) error {
+		var k [2]int64
+		var o int64
+		err := r.Columns(&k[0], &k[1], &o)
+		d := districts[k]
+		d.newOrders++
+		if o > d.maxNew {
+			d.maxNew = o
+		}
+		if o < d.minNew {
+			d.minNew = o
+		}
+		return err
+	})
+
+	for k, d := range districts {
+		if d.next-1 != d.maxOrder {
+			t.Errorf("d_next_o_id of district %v is %d, the newest order is %d", k, d.next, d.maxOrder)
+		}
+		if d.newOrders > 0 && (d.maxNew != d.maxOrder || d.maxNew-d.minNew+1 != d.newOrders) {
+			t.Errorf("new orders of district %v are %d to %d (%d rows), the newest order is %d", k, d.minNew, d.maxNew, d.newOrders, d.maxOrder)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+var (
+	// errRollback is returned by New-Order transactions that name an unused item. They are
+	// rolled back on purpose and count as completed.
+	errRollback = errors.New("new order rolled back")
+)
+
+type (
+	// reader is the part of read only and read write transactions used by the transactions
+	reader interface {
+		ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error)
+		Read(ctx context.Context, table string, keys spanner.KeySet, columns []string) *spanner.RowIterator
+		Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
+	}
+
+	// orderLine is a line of a new order
+	orderLine struct {
+		item     int64
+		supply   int64
+		quantity int64
+	}
+
+	// stock is the stock of an item in a warehouse
+	stock struct {
+		quantity int64
+		ytd      int64
+		orders   int64
+		remote   int64
+		dist     string
+	}
+)
+
+// newOrder enters an order of 5 to 15 items for a customer of a district of the home
+// warehouse. An item is supplied by another warehouse with a probability of 1%.
+func (t *terminal) newOrder(ctx context.Context) error {
+	cfg := t.w.Config.TPCC
+	dID := int64(t.r.between(1, Districts))
+	cID := int64(t.r.customerID(cfg.Customers))
+
+	lines := make([]orderLine, t.r.between(5, 15))
+	seen := make(map[int64]bool, len(lines))
+	allLocal := int64(1)
+	for i := range lines {
+		l := orderLine{item: int64(t.r.itemID(cfg.Items)), supply: t.wID, quantity: int64(t.r.between(1, 10))}
+		for seen[l.item] {
+			l.item = int64(t.r.itemID(cfg.Items))
+		}
+		seen[l.item] = true
+
+		if cfg.Warehouses > 1 && t.r.between(1, 100) == 1 {
+			l.supply = t.otherWarehouse()
+			allLocal = 0
+		}
+
+		lines[i] = l
+	}
+
+	// 1% of the orders are rolled back when the unused item is not found
+	if t.r.between(1, 100) == 1 {
+		lines[len(lines)-1].item = int64(cfg.Items + 1)
+	}
+
+	return t.w.readWrite(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		var wTax, dTax, discount float64
+		var next int64
+		var last, credit string
+
+		err := readRow(ctx, txn, "warehouse", spanner.Key{t.wID}, []string{"w_tax"}, &wTax)
+		if err != nil {
+			return err
+		}
+
+		err = readRow(ctx, txn, "district", spanner.Key{t.wID, dID}, []string{"d_tax", "d_next_o_id"}, &dTax, &next)
+		if err != nil {
+			return err
+		}
+
+		err = readRow(ctx, txn, "customer", spanner.Key{t.wID, dID, cID}, []string{"c_discount", "c_last", "c_credit"}, &discount, &last, &credit)
+		if err != nil {
+			return err
+		}
+
+		itemKeys := make([]spanner.KeySet, len(lines))
+		stockKeys := make([]spanner.KeySet, len(lines))
+		for i, l := range lines {
+			itemKeys[i] = spanner.Key{l.item}
+			stockKeys[i] = spanner.Key{l.supply, l.item}
+		}
+
+		prices := make(map[int64]float64, len(lines))
+		err = txn.Read(ctx, "item", spanner.KeySets(itemKeys...), []string{"i_id", "i_price"}).Do(func(r *spanner.Row) error {
+			var id int64
+			var price float64
+			err := r.Columns(&id, &price)
+			prices[id] = price
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(prices) < len(lines) {
+			return errRollback
+		}
+
+		dist := fmt.Sprintf("s_dist_%02d", dID)
+		stocks := make(map[string]*stock, len(lines))
+		columns := []string{"w_id", "s_i_id", "s_quantity", "s_ytd", "s_order_cnt", "s_remote_cnt", dist}
+		err = txn.Read(ctx, "stock", spanner.KeySets(stockKeys...), columns).Do(func(r *spanner.Row) error {
+			var wID, iID int64
+			s := &stock{}
+			err := r.Columns(&wID, &iID, &s.quantity, &s.ytd, &s.orders, &s.remote, &s.dist)
+			stocks[spanner.Key{wID, iID}.String()] = s
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		ms := []*spanner.Mutation{
+			spanner.Update("district", []string{"w_id", "d_id", "d_next_o_id"}, []interface{}{t.wID, dID, next + 1}),
+			spanner.Insert("orders", orderColumns, []interface{}{t.wID, dID, next, cID, now, nil, len(lines), allLocal}),
+			spanner.Insert("new_order", newOrderColumns, []interface{}{t.wID, dID, next}),
+		}
+
+		for i, l := range lines {
+			s, ok := stocks[spanner.Key{l.supply, l.item}.String()]
+			if !ok {
+				return fmt.Errorf("no stock of item %d in warehouse %d", l.item, l.supply)
+			}
+
+			s.quantity -= l.quantity
+			if s.quantity < 10 {
+				s.quantity += 91
+			}
+			s.ytd += l.quantity
+			s.orders++
+			if l.supply != t.wID {
+				s.remote++
+			}
+
+			ms = append(ms,
+				spanner.Update("stock", []string{"w_id", "s_i_id", "s_quantity", "s_ytd", "s_order_cnt", "s_remote_cnt"},
+					[]interface{}{l.supply, l.item, s.quantity, s.ytd, s.orders, s.remote}),
+				spanner.Insert("order_line", orderLineColumns,
+					[]interface{}{t.wID, dID, next, i + 1, l.item, l.supply, nil, l.quantity, float64(l.quantity) * prices[l.item], s.dist}),
+			)
+		}
+
+		return txn.BufferWrite(ms)
+	})
+}
+
+// payment records a payment of a customer, selected by last name 60% of the time, at a
+// district of the home warehouse. 15% of the customers belong to another warehouse.
+func (t *terminal) payment(ctx context.Context) error {
+	cfg := t.w.Config.TPCC
+	dID := int64(t.r.between(1, Districts))
+	cW, cD := t.wID, dID
+	if cfg.Warehouses > 1 && t.r.between(1, 100) > 85 {
+		cW, cD = t.otherWarehouse(), int64(t.r.between(1, Districts))
+	}
+
+	byName := t.r.between(1, 100) <= 60
+	var last string
+	var cID int64
+	if byName {
+		last = t.r.lastName(cfg.Customers)
+	} else {
+		cID = int64(t.r.customerID(cfg.Customers))
+	}
+
+	amount := t.r.amount(1, 5000)
+	hID := newHistoryID(t.r)
+
+	return t.w.readWrite(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		var wName, dName, credit, data string
+		var wYTD, dYTD, balance, paid float64
+		var payments int64
+
+		err := readRow(ctx, txn, "warehouse", spanner.Key{t.wID}, []string{"w_name", "w_ytd"}, &wName, &wYTD)
+		if err != nil {
+			return err
+		}
+
+		err = readRow(ctx, txn, "district", spanner.Key{t.wID, dID}, []string{"d_name", "d_ytd"}, &dName, &dYTD)
+		if err != nil {
+			return err
+		}
+
+		id := cID
+		if byName {
+			id, err = customerByName(ctx, txn, cW, cD, last)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = readRow(ctx, txn, "customer", spanner.Key{cW, cD, id}, []string{"c_balance", "c_ytd_payment", "c_payment_cnt", "c_credit", "c_data"},
+			&balance, &paid, &payments, &credit, &data)
+		if err != nil {
+			return err
+		}
+
+		// Customers with bad credit keep a log of their payments
+		customerColumns := []string{"w_id", "d_id", "c_id", "c_balance", "c_ytd_payment", "c_payment_cnt"}
+		customerValues := []interface{}{cW, cD, id, balance - amount, paid + amount, payments + 1}
+		if credit == "BC" {
+			data = fmt.Sprintf("%d %d %d %d %d %.2f|%s", id, cD, cW, dID, t.wID, amount, data)
+			if len(data) > 500 {
+				data = data[:500]
+			}
+
+			customerColumns = append(customerColumns, "c_data")
+			customerValues = append(customerValues, data)
+		}
+
+		return txn.BufferWrite([]*spanner.Mutation{
+			spanner.Update("warehouse", []string{"w_id", "w_ytd"}, []interface{}{t.wID, wYTD + amount}),
+			spanner.Update("district", []string{"w_id", "d_id", "d_ytd"}, []interface{}{t.wID, dID, dYTD + amount}),
+			spanner.Update("customer", customerColumns, customerValues),
+			spanner.Insert("history", historyColumns, []interface{}{cW, cD, id, hID, dID, t.wID, time.Now(), amount, wName + "    " + dName}),
+		})
+	})
+}
+
+// orderStatus reads the latest order of a customer, selected by last name 60% of the time
+func (t *terminal) orderStatus(ctx context.Context) error {
+	cfg := t.w.Config.TPCC
+	dID := int64(t.r.between(1, Districts))
+
+	byName := t.r.between(1, 100) <= 60
+	var last string
+	var cID int64
+	if byName {
+		last = t.r.lastName(cfg.Customers)
+	} else {
+		cID = int64(t.r.customerID(cfg.Customers))
+	}
+
+	txn := t.w.client.ReadOnlyTransaction()
+	defer txn.Close()
+
+	var err error
+	if byName {
+		cID, err = customerByName(ctx, txn, t.wID, dID, last)
+		if err != nil {
+			return err
+		}
+	}
+
+	var first, middle string
+	var balance float64
+	err = readRow(ctx, txn, "customer", spanner.Key{t.wID, dID, cID}, []string{"c_first", "c_middle", "c_last", "c_balance"}, &first, &middle, &last, &balance)
+	if err != nil {
+		return err
+	}
+
+	var oID int64
+	var entered time.Time
+	var carrier spanner.NullInt64
+	found := false
+	err = txn.Query(ctx, spanner.Statement{
+		SQL: `SELECT o_id, o_entry_d, o_carrier_id FROM orders@{FORCE_INDEX=orders_by_customer}
+			WHERE w_id = @w_id AND d_id = @d_id AND o_c_id = @c_id ORDER BY o_id DESC LIMIT 1`,
+		Params: map[string]interface{}{"w_id": t.wID, "d_id": dID, "c_id": cID},
+	}).Do(func(r *spanner.Row) error {
+		found = true
+		return r.Columns(&oID, &entered, &carrier)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("customer %d of district %d of warehouse %d has no orders", cID, dID, t.wID)
+	}
+
+	return txn.Read(ctx, "order_line", spanner.Key{t.wID, dID, oID}.AsPrefix(),
+		[]string{"ol_i_id", "ol_supply_w_id", "ol_quantity", "ol_amount", "ol_delivery_d"}).Do(func(r *spanner.Row) error {
+		var item, supply, quantity int64
+		var amount float64
+		var delivered spanner.NullTime
+		return r.Columns(&item, &supply, &quantity, &amount, &delivered)
+	})
+}
+
+// delivery delivers the oldest undelivered order of every district of the home warehouse.
+// Every district is delivered in a transaction of its own, districts without undelivered
+// orders are skipped.
+func (t *terminal) delivery(ctx context.Context) error {
+	carrier := int64(t.r.between(1, 10))
+
+	for dID := int64(1); dID <= Districts; dID++ {
+		err := t.w.readWrite(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+			var oID int64
+			found := false
+			err := txn.ReadWithOptions(ctx, "new_order", spanner.Key{t.wID, dID}.AsPrefix(), []string{"o_id"},
+				&spanner.ReadOptions{Limit: 1}).Do(func(r *spanner.Row) error {
+				found = true
+				return r.Columns(&oID)
+			})
+			if err != nil || !found {
+				return err
+			}
+
+			var cID int64
+			err = readRow(ctx, txn, "orders", spanner.Key{t.wID, dID, oID}, []string{"o_c_id"}, &cID)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			ms := []*spanner.Mutation{
+				spanner.Delete("new_order", spanner.Key{t.wID, dID, oID}),
+				spanner.Update("orders", []string{"w_id", "d_id", "o_id", "o_carrier_id"}, []interface{}{t.wID, dID, oID, carrier}),
+			}
+
+			var total float64
+			err = txn.Read(ctx, "order_line", spanner.Key{t.wID, dID, oID}.AsPrefix(), []string{"ol_number", "ol_amount"}).Do(func(r *spanner.Row) error {
+				var n int64
+				var amount float64
+				err := r.Columns(&n, &amount)
+				total += amount
+				ms = append(ms, spanner.Update("order_line", []string{"w_id", "d_id", "o_id", "ol_number", "ol_delivery_d"},
+					[]interface{}{t.wID, dID, oID, n, now}))
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			var balance float64
+			var deliveries int64
+			err = readRow(ctx, txn, "customer", spanner.Key{t.wID, dID, cID}, []string{"c_balance", "c_delivery_cnt"}, &balance, &deliveries)
+			if err != nil {
+				return err
+			}
+
+			ms = append(ms, spanner.Update("customer", []string{"w_id", "d_id", "c_id", "c_balance", "c_delivery_cnt"},
+				[]interface{}{t.wID, dID, cID, balance + total, deliveries + 1}))
+
+			return txn.BufferWrite(ms)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stockLevel counts the items of the last 20 orders of the terminals district whose stock is
+// below a threshold
+func (t *terminal) stockLevel(ctx context.Context) error {
+	threshold := int64(t.r.between(10, 20))
+
+	txn := t.w.client.ReadOnlyTransaction()
+	defer txn.Close()
+
+	var next int64
+	err := readRow(ctx, txn, "district", spanner.Key{t.wID, t.dID}, []string{"d_next_o_id"}, &next)
+	if err != nil {
+		return err
+	}
+
+	items := make(map[int64]bool)
+	orders := spanner.KeyRange{Start: spanner.Key{t.wID, t.dID, next - 20}, End: spanner.Key{t.wID, t.dID, next}, Kind: spanner.ClosedOpen}
+	err = txn.Read(ctx, "order_line", orders, []string{"ol_i_id"}).Do(func(r *spanner.Row) error {
+		var item int64
+		err := r.Columns(&item)
+		items[item] = true
+		return err
+	})
+	if err != nil || len(items) == 0 {
+		return err
+	}
+
+	keys := make([]spanner.KeySet, 0, len(items))
+	for item := range items {
+		keys = append(keys, spanner.Key{t.wID, item})
+	}
+
+	var low int
+	return txn.Read(ctx, "stock", spanner.KeySets(keys...), []string{"s_quantity"}).Do(func(r *spanner.Row) error {
+		var quantity int64
+		err := r.Columns(&quantity)
+		if quantity < threshold {
+			low++
+		}
+		return err
+	})
+}
+
+// customerByName returns the id of the customer in the middle of the customers with a last
+// name, ordered by first name
+func customerByName(ctx context.Context, txn reader, wID int64, dID int64, last string) (int64, error) {
+	ids := make([]int64, 0)
+	err := txn.Query(ctx, spanner.Statement{
+		SQL: `SELECT c_id FROM customer@{FORCE_INDEX=customer_by_last}
+			WHERE w_id = @w_id AND d_id = @d_id AND c_last = @c_last ORDER BY c_first`,
+		Params: map[string]interface{}{"w_id": wID, "d_id": dID, "c_last": last},
+	}).Do(func(r *spanner.Row) error {
+		var id int64
+		err := r.Columns(&id)
+		ids = append(ids, id)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("no customer named %s in district %d of warehouse %d", last, dID, wID)
+	}
+
+	return ids[(len(ids)-1)/2], nil
+}
+
+// readRow reads the columns of the row with key into dest
+func readRow(ctx context.Context, txn reader, table string, key spanner.Key, columns []string, dest ...interface{}) error {
+	row, err := txn.ReadRow(ctx, table, key, columns)
+	if err != nil {
+		return err
+	}
+
+	return row.Columns(dest...)
+}
@@ -0,0 +1,356 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/seed"
+	"github.com/cloudspannerecosystem/gcsb/pkg/histogram"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/cloudspannerecosystem/gcsb/pkg/workload"
+	"github.com/rcrowley/go-metrics"
+)
+
+var (
+	// Assert that Workload implements workload.Workload
+	_ workload.Workload = (*Workload)(nil)
+)
+
+type (
+	// Workload loads the TPC-C tables and runs the transaction mix against them
+	Workload struct {
+		Context        context.Context
+		Config         *config.Config
+		MetricRegistry metrics.Registry
+		Observer       observer.Observer // Notified about every transaction (optional)
+		ErrorBudget    *workload.ErrorBudget
+
+		client backend.Client
+		mu     sync.Mutex // Serializes read write transactions on the in memory backend
+	}
+)
+
+// NewWorkload is the workload.Constructor of TPC-C. The schema of the workload config is not
+// used, the tables are those of schemas/tpcc.sql.
+func NewWorkload(cfg workload.WorkloadConfig) (workload.Workload, error) {
+	if cfg.MetricRegistry == nil {
+		return nil, errors.New("missing metrics registry")
+	}
+
+	if cfg.Config == nil {
+		return nil, errors.New("missing config")
+	}
+
+	client, err := cfg.Config.Client(cfg.Context)
+	if err != nil {
+		return nil, fmt.Errorf("creating spanner client: %s", err.Error())
+	}
+
+	return &Workload{
+		Context:        cfg.Context,
+		Config:         cfg.Config,
+		MetricRegistry: cfg.MetricRegistry,
+		Observer:       cfg.Observer,
+		ErrorBudget:    workload.NewErrorBudget(cfg.MetricRegistry, cfg.Config.Operations.MaxErrors, cfg.Config.Operations.MaxErrorRate),
+		client:         client,
+	}, nil
+}
+
+// Load populates empty tables with the item catalog and tpcc.warehouses warehouses, using a
+// thread per warehouse. TPC-C always loads all of its tables, so tables must be empty.
+func (w *Workload) Load(tables []string) error {
+	if len(tables) > 0 {
+		return errors.New("the tpcc workload loads all of its tables")
+	}
+
+	ctx, cancel := context.WithCancel(w.Context)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+
+	jobs := make(chan func(context.Context) error)
+	for i := 0; i < w.Config.Threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if err := job(ctx); err != nil {
+					once.Do(func() {
+						first = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	// The catalog is loaded in chunks next to the warehouses
+	for start := 1; start <= w.Config.TPCC.Items; start += itemChunk {
+		start := start
+		if !w.submit(ctx, jobs, func(ctx context.Context) error { return w.loadItems(ctx, start) }) {
+			break
+		}
+	}
+
+	for wID := 1; wID <= w.Config.TPCC.Warehouses; wID++ {
+		wID := wID
+		if !w.submit(ctx, jobs, func(ctx context.Context) error { return w.loadWarehouse(ctx, wID) }) {
+			break
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if first == nil && w.Context.Err() != nil {
+		return w.Context.Err()
+	}
+
+	return first
+}
+
+// submit hands job to a thread. It returns false if the load was canceled.
+func (w *Workload) submit(ctx context.Context, jobs chan<- func(context.Context) error, job func(context.Context) error) bool {
+	select {
+	case jobs <- job:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Stop is a no-op, Load and Run return once their threads are done
+func (w *Workload) Stop() error {
+	return nil
+}
+
+// loadItems loads the items of the chunk starting at id start
+func (w *Workload) loadItems(ctx context.Context, start int) error {
+	r := newRandom(seed.Source(w.Config.Seed, "tpcc", "items", strconv.Itoa(start)), loadC)
+	b := w.newBatch(ctx)
+
+	end := start + itemChunk - 1
+	if end > w.Config.TPCC.Items {
+		end = w.Config.TPCC.Items
+	}
+
+	for i := start; i <= end; i++ {
+		err := b.insert("item", itemColumns, i, r.between(1, 10000), r.astring(14, 24), r.amount(1, 100), r.data())
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.flush()
+}
+
+// loadWarehouse loads a warehouse with its stock and districts
+func (w *Workload) loadWarehouse(ctx context.Context, wID int) error {
+	r := newRandom(seed.Source(w.Config.Seed, "tpcc", "warehouse", strconv.Itoa(wID)), loadC)
+	b := w.newBatch(ctx)
+	now := time.Now()
+
+	err := b.insert("warehouse", warehouseColumns, wID, r.astring(6, 10), r.astring(10, 20), r.astring(10, 20),
+		r.astring(10, 20), r.astring(2, 2), r.zip(), r.amount(0, 0.2), 300000.0)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= w.Config.TPCC.Items; i++ {
+		values := []interface{}{wID, i, r.between(10, 100)}
+		for d := 0; d < Districts; d++ {
+			values = append(values, r.astring(24, 24))
+		}
+		values = append(values, 0, 0, 0, r.data())
+
+		err = b.insert("stock", stockColumns, values...)
+		if err != nil {
+			return err
+		}
+	}
+
+	customers := w.Config.TPCC.Customers
+	for dID := 1; dID <= Districts; dID++ {
+		err = b.insert("district", districtColumns, wID, dID, r.astring(6, 10), r.astring(10, 20), r.astring(10, 20),
+			r.astring(10, 20), r.astring(2, 2), r.zip(), r.amount(0, 0.2), 30000.0, customers+1)
+		if err != nil {
+			return err
+		}
+
+		for cID := 1; cID <= customers; cID++ {
+			name := cID - 1
+			if cID > 1000 {
+				name = r.nurand(255, r.c.last, 0, 999)
+			}
+
+			credit := "GC"
+			if r.Intn(10) == 0 {
+				credit = "BC"
+			}
+
+			err = b.insert("customer", customerColumns, wID, dID, cID, r.astring(8, 16), "OE", lastName(name),
+				r.astring(10, 20), r.astring(10, 20), r.astring(10, 20), r.astring(2, 2), r.zip(), r.nstring(16, 16),
+				now, credit, 50000.0, r.amount(0, 0.5), -10.0, 10.0, 1, 0, r.astring(300, 500))
+			if err != nil {
+				return err
+			}
+		}
+
+		for cID := 1; cID <= customers; cID++ {
+			err = b.insert("history", historyColumns, wID, dID, cID, newHistoryID(r), dID, wID, now, 10.0, r.astring(12, 24))
+			if err != nil {
+				return err
+			}
+		}
+
+		// Every customer placed one of the initial orders, the newest 30% are undelivered
+		owners := r.Perm(customers)
+		undelivered := customers - customers*3/10 + 1
+		lines := make([]int, customers+1)
+		for oID := 1; oID <= customers; oID++ {
+			var carrier interface{}
+			if oID < undelivered {
+				carrier = r.between(1, 10)
+			}
+
+			lines[oID] = r.between(5, 15)
+			err = b.insert("orders", orderColumns, wID, dID, oID, owners[oID-1]+1, now, carrier, lines[oID], 1)
+			if err != nil {
+				return err
+			}
+		}
+
+		for oID := 1; oID <= customers; oID++ {
+			for n := 1; n <= lines[oID]; n++ {
+				delivery, amount := interface{}(now), 0.0
+				if oID >= undelivered {
+					delivery, amount = nil, r.amount(0.01, 9999.99)
+				}
+
+				err = b.insert("order_line", orderLineColumns, wID, dID, oID, n, r.between(1, w.Config.TPCC.Items), wID,
+					delivery, 5, amount, r.astring(24, 24))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for oID := undelivered; oID <= customers; oID++ {
+			err = b.insert("new_order", newOrderColumns, wID, dID, oID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return b.flush()
+}
+
+// readWrite runs f in a read write transaction. The in memory backend does not isolate
+// transactions from each other, so they run one at a time there.
+func (w *Workload) readWrite(ctx context.Context, f func(context.Context, *spanner.ReadWriteTransaction) error) error {
+	if w.Config.Backend == backend.Memory {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+	}
+
+	_, err := w.client.ReadWriteTransaction(ctx, f)
+	return err
+}
+
+// newBatch returns a batch writing to the database of the workload
+func (w *Workload) newBatch(ctx context.Context) *batch {
+	return &batch{
+		ctx:      ctx,
+		client:   w.client,
+		registry: w.MetricRegistry,
+		cfg:      w.Config.Histogram,
+		timer:    histogram.GetOrRegisterTimer("operations.write.time", w.MetricRegistry, w.Config.Histogram),
+		meter:    metrics.GetOrRegisterMeter("operations.write.rate", w.MetricRegistry),
+		tables:   make(map[string]*workload.TableMetrics),
+	}
+}
+
+// batch buffers inserts into a table and applies them in batches of loadBatchSize rows. Rows
+// of another table flush the batch first, so that parents are written before the rows
+// interleaved in them.
+type batch struct {
+	ctx      context.Context
+	client   backend.Client
+	registry metrics.Registry
+	cfg      config.Histogram
+	timer    metrics.Timer
+	meter    metrics.Meter
+	tables   map[string]*workload.TableMetrics
+	table    string
+	rows     []*spanner.Mutation
+}
+
+func (b *batch) insert(table string, columns []string, values ...interface{}) error {
+	if table != b.table {
+		err := b.flush()
+		if err != nil {
+			return err
+		}
+		b.table = table
+	}
+
+	b.rows = append(b.rows, spanner.Insert(table, columns, values))
+	if len(b.rows) >= loadBatchSize {
+		return b.flush()
+	}
+
+	return nil
+}
+
+func (b *batch) flush() error {
+	if len(b.rows) == 0 {
+		return nil
+	}
+
+	m, ok := b.tables[b.table]
+	if !ok {
+		m = workload.NewTableMetrics(b.registry, b.table, b.cfg)
+		b.tables[b.table] = m
+	}
+
+	start := time.Now()
+	_, err := b.client.Apply(b.ctx, b.rows)
+	if err != nil {
+		m.RecordError(workload.OperationWrite, len(b.rows), err)
+		return fmt.Errorf("loading %s: %s", b.table, err.Error())
+	}
+
+	d := time.Since(start)
+	b.timer.Update(d)
+	b.meter.Mark(int64(len(b.rows)))
+	m.RecordWrite(d, len(b.rows))
+	b.rows = b.rows[:0]
+
+	return nil
+}
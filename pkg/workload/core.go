@@ -20,17 +20,21 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"strings"
 	"sync"
 	"time"
 
-	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/data"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/operation"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/sample"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/seed"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/selector"
+	"github.com/cloudspannerecosystem/gcsb/pkg/histogram"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
 	"github.com/cloudspannerecosystem/gcsb/pkg/workload/pool"
 	"github.com/olekukonko/tablewriter"
@@ -40,6 +44,12 @@ import (
 var (
 	// Assert that WorkerPool implements Workload
 	_ Workload = (*CoreWorkload)(nil)
+
+	// Assert that CoreWorkload can describe its plan
+	_ PlanSummarizer = (*CoreWorkload)(nil)
+
+	// Assert that CoreWorkload records its execution
+	_ ExecutionRecorder = (*CoreWorkload)(nil)
 )
 
 const (
@@ -52,11 +62,12 @@ type (
 		Config          *config.Config
 		Schema          schema.Schema
 		MetricsRegistry metrics.Registry
+		Observer        observer.Observer // Notified about every operation (optional)
 
 		// Internals
 		pool   *pool.PipedPool
-		wg     sync.WaitGroup
-		client *spanner.Client
+		output chan pool.Job // Receives jobs once the pool executed them
+		client backend.Client
 
 		DataWriteGenerationTimer metrics.Timer // Used to time data generation
 		DataReadGenerationTimer  metrics.Timer // Used to time data geenration
@@ -64,9 +75,20 @@ type (
 		DataWriteMeter           metrics.Meter // Used to measure volume of writes
 		DataReadTimer            metrics.Timer // Used to time reads
 		DataReadMeter            metrics.Meter // Used to measure volume of reads
+		DataUpdateTimer          metrics.Timer // Used to time updates
+		DataUpdateMeter          metrics.Meter // Used to measure volume of updates
+		DataScanTimer            metrics.Timer // Used to time scans
+		DataScanMeter            metrics.Meter // Used to measure volume of scans
+		DataRMWTimer             metrics.Timer // Used to time read-modify-writes
+		DataRMWMeter             metrics.Meter // Used to measure volume of read-modify-writes
+		ErrorBudget              *ErrorBudget  // Counts failed operations and aborts the run when exceeded
 
 		// Plans and targets
 		plan []*Target // The entire run plan. 1 target per table
+
+		// Execution window of the plan
+		executeStart time.Time
+		executeEnd   time.Time
 	}
 )
 
@@ -77,6 +99,7 @@ func NewCoreWorkload(cfg WorkloadConfig) (Workload, error) {
 		Config:          cfg.Config,
 		Schema:          cfg.Schema,
 		MetricsRegistry: cfg.MetricRegistry,
+		Observer:        cfg.Observer,
 		plan:            make([]*Target, 0),
 		pool: pool.NewPipedPool(pool.PipedPoolConfig{
 			Workers:         cfg.Config.Threads,
@@ -119,23 +142,34 @@ func (c *CoreWorkload) Initialize() error {
 		return err
 	}
 
-	// Start the thread pool
+	// Start the thread pool and bind its output once. Every call to Execute drains it
 	c.pool.Start()
+	c.output = make(chan pool.Job, defaultBufferLen)
+	c.pool.BindPool(c.output)
 
 	// Create our job metrics
-	c.DataWriteGenerationTimer = metrics.GetOrRegisterTimer("operations.write.data", c.MetricsRegistry) // Used to time data generation
-	c.DataReadGenerationTimer = metrics.GetOrRegisterTimer("operations.read.data", c.MetricsRegistry)   // Used to time data geenration
-	c.DataWriteTimer = metrics.GetOrRegisterTimer("operations.write.time", c.MetricsRegistry)           // Used to time writes
-	c.DataWriteMeter = metrics.GetOrRegisterMeter("operations.write.rate", c.MetricsRegistry)           // Used to measure volume of writes
-	c.DataReadTimer = metrics.GetOrRegisterTimer("operations.read.time", c.MetricsRegistry)             // Used to time reads
-	c.DataReadMeter = metrics.GetOrRegisterMeter("operations.read.rate", c.MetricsRegistry)             // Used to measure volume of reads
+	c.DataWriteGenerationTimer = histogram.GetOrRegisterTimer("operations.write.data", c.MetricsRegistry, c.Config.Histogram) // Used to time data generation
+	c.DataReadGenerationTimer = histogram.GetOrRegisterTimer("operations.read.data", c.MetricsRegistry, c.Config.Histogram)   // Used to time data geenration
+	c.DataWriteTimer = histogram.GetOrRegisterTimer("operations.write.time", c.MetricsRegistry, c.Config.Histogram)           // Used to time writes
+	c.DataWriteMeter = metrics.GetOrRegisterMeter("operations.write.rate", c.MetricsRegistry)                                 // Used to measure volume of writes
+	c.DataReadTimer = histogram.GetOrRegisterTimer("operations.read.time", c.MetricsRegistry, c.Config.Histogram)             // Used to time reads
+	c.DataReadMeter = metrics.GetOrRegisterMeter("operations.read.rate", c.MetricsRegistry)                                   // Used to measure volume of reads
+	c.DataUpdateTimer = histogram.GetOrRegisterTimer("operations.update.time", c.MetricsRegistry, c.Config.Histogram)
+	c.DataUpdateMeter = metrics.GetOrRegisterMeter("operations.update.rate", c.MetricsRegistry)
+	c.DataScanTimer = histogram.GetOrRegisterTimer("operations.scan.time", c.MetricsRegistry, c.Config.Histogram)
+	c.DataScanMeter = metrics.GetOrRegisterMeter("operations.scan.rate", c.MetricsRegistry)
+	c.DataRMWTimer = histogram.GetOrRegisterTimer("operations.read_modify_write.time", c.MetricsRegistry, c.Config.Histogram)
+	c.DataRMWMeter = metrics.GetOrRegisterMeter("operations.read_modify_write.rate", c.MetricsRegistry)
+	c.ErrorBudget = NewErrorBudget(c.MetricsRegistry, c.Config.Operations.MaxErrors, c.Config.Operations.MaxErrorRate)
 
 	return nil
 }
 
-// Plan will create *Targets for each TargetName
+// Plan will create *Targets for each TargetName. It replaces any previous plan, so a workload may
+// load and then run in the same process
 func (c *CoreWorkload) Plan(pt JobType, targets []string) error {
 	var needOperationMultiplication bool
+	c.plan = make([]*Target, 0)
 	apexTables := make([]schema.Table, 0)
 
 	// search func for looking if targets contains the given string
@@ -185,6 +219,15 @@ func (c *CoreWorkload) Plan(pt JobType, targets []string) error {
 			apexTables = append(apexTables, st) // Collect a slice of apex tables
 		}
 
+		// When the workload is distributed, this process only inserts, reads and updates keys of its range
+		kr, err := generator.GetKeyRange(c.Config, st)
+		if err != nil {
+			return err
+		}
+		if kr != nil {
+			log.Printf("Partition %d of %d owns the keys of table '%s' with %s", c.Config.Partition.Index, c.Config.Partition.Count, t, kr)
+		}
+
 		// Create target
 		target := &Target{
 			Config:                   c.Config,
@@ -194,27 +237,37 @@ func (c *CoreWorkload) Plan(pt JobType, targets []string) error {
 			Table:                    st,
 			TableName:                t,
 			ColumnNames:              st.ColumnNames(),
+			KeyColumns:               st.PrimaryKeyNames(),
 			DataWriteGenerationTimer: c.DataWriteGenerationTimer,
 			DataReadGenerationTimer:  c.DataReadGenerationTimer,
 			DataWriteTimer:           c.DataWriteTimer,
 			DataWriteMeter:           c.DataWriteMeter,
 			DataReadTimer:            c.DataReadTimer,
 			DataReadMeter:            c.DataReadMeter,
+			DataUpdateTimer:          c.DataUpdateTimer,
+			DataUpdateMeter:          c.DataUpdateMeter,
+			DataScanTimer:            c.DataScanTimer,
+			DataScanMeter:            c.DataScanMeter,
+			DataRMWTimer:             c.DataRMWTimer,
+			DataRMWMeter:             c.DataRMWMeter,
+			ErrorBudget:              c.ErrorBudget,
+			TableMetrics:             NewTableMetrics(c.MetricsRegistry, t, c.Config.Histogram),
+			Observer:                 c.Observer,
 		}
 
 		// If we are in 'run' context
 		if pt == JobRun {
-			// Generate an operation selector
-			sel, err := c.GetOperationSelector()
+			// Generate an operation selector. Jobs fork their own instance from it
+			sel, err := c.GetOperationSelector(target.Table)
 			if err != nil {
 				return fmt.Errorf("creating operation selector: %s", err.Error())
 			}
 
 			target.OperationSelector = sel
 
-			// If our read fraction is > 0,
-			// We have faith that the operation selector will not return reads if read fraction is <= 0
-			if c.Config.Operations.Read > 0 {
+			// If any operation targets existing rows,
+			// We have faith that the operation selector will not return them if their weights are <= 0
+			if c.Config.Operations.ReadsKeys() {
 				// Sample the table and create a sample generator
 				sg, err := c.GetReadGeneratorMap(target.Table)
 				if err != nil {
@@ -222,11 +275,32 @@ func (c *CoreWorkload) Plan(pt JobType, targets []string) error {
 				}
 
 				target.ReadGenerator = sg
+
+				// Skew the requests towards a few hot keys of the sample
+				if c.Config.Operations.Distribution == config.DistributionZipfian {
+					zg, err := c.GetZipfianGenerator(target.Table, sg)
+					if err != nil {
+						return fmt.Errorf("creating zipfian key generator: %s", err.Error())
+					}
+
+					target.ReadGenerator = zg
+				}
+
+				// If a fraction of reads should target keys inserted during this run, remember them
+				if c.Config.Operations.Latest.Fraction > 0 {
+					lg, err := c.GetLatestGenerator(target.Table, target.ReadGenerator)
+					if err != nil {
+						return fmt.Errorf("creating latest key generator: %s", err.Error())
+					}
+
+					target.RecentKeys = lg.Recent()
+					target.ReadGenerator = lg
+				}
 			}
 		}
 
 		// Create a generator map for the table
-		gm, err := c.GetGeneratorMap(target.Table)
+		gm, err := c.GetGeneratorMap(target.Table, pt, 0)
 		if err != nil {
 			return fmt.Errorf("creating generator map: %s", err.Error())
 		}
@@ -290,19 +364,21 @@ func (c *CoreWorkload) Plan(pt JobType, targets []string) error {
 		}
 	}
 
+	// When the workload is distributed, only execute this processes share of every target
+	for _, t := range c.plan {
+		t.Operations = c.Config.Partition.Share(t.Operations)
+	}
+
 	return nil
 }
 
 func (c *CoreWorkload) Load(x []string) error {
 	// Plan our run
-	err := c.Plan(JobLoad, x)
+	err := c.Prepare(JobLoad, x)
 	if err != nil {
-		return fmt.Errorf("planning run: %s", err.Error())
+		return err
 	}
 
-	// Summarize plan
-	c.SummarizePlan()
-
 	// Execute our run
 	err = c.Execute()
 	if err != nil {
@@ -314,23 +390,48 @@ func (c *CoreWorkload) Load(x []string) error {
 
 // Run will execute a Run phase against the target table.
 func (c *CoreWorkload) Run(x string) error {
-	// Fetch table from schema
-	table := c.Schema.GetTable(x)
-	if table == nil {
-		return fmt.Errorf("table '%s' missing from schema", x)
+	// Plan our run
+	err := c.Prepare(JobRun, []string{x})
+	if err != nil {
+		return err
+	}
+
+	// Execute our run
+	err = c.Execute()
+	if err != nil {
+		return fmt.Errorf("executing run: %s", err.Error())
 	}
 
-	// Check if table is interleaved
-	if table.IsInterleaved() {
-		// Check if table is apex. If not, return error
-		if !table.IsApex() {
-			apex := table.GetApex()
-			return fmt.Errorf("can only execute run against apex table (try '%s')", apex.Name())
+	return nil
+}
+
+// Prepare plans a phase without executing it, so that the tables of a run are sampled before
+// several processes start executing at the same moment. Runs execute against a single apex
+// table. Execute executes the prepared plan.
+func (c *CoreWorkload) Prepare(pt JobType, x []string) error {
+	if pt == JobRun {
+		if len(x) != 1 {
+			return fmt.Errorf("can only execute run against a single table (got %d)", len(x))
+		}
+
+		// Fetch table from schema
+		table := c.Schema.GetTable(x[0])
+		if table == nil {
+			return fmt.Errorf("table '%s' missing from schema", x[0])
+		}
+
+		// Check if table is interleaved
+		if table.IsInterleaved() {
+			// Check if table is apex. If not, return error
+			if !table.IsApex() {
+				apex := table.GetApex()
+				return fmt.Errorf("can only execute run against apex table (try '%s')", apex.Name())
+			}
 		}
 	}
 
 	// Plan our run
-	err := c.Plan(JobRun, []string{x})
+	err := c.Plan(pt, x)
 	if err != nil {
 		return fmt.Errorf("planning run: %s", err.Error())
 	}
@@ -338,92 +439,104 @@ func (c *CoreWorkload) Run(x string) error {
 	// Summarize plan
 	c.SummarizePlan()
 
-	// Execute our run
-	err = c.Execute()
-	if err != nil {
-		return fmt.Errorf("executing run: %s", err.Error())
-	}
-
 	return nil
 }
 
+// Execute runs the current plan. If max execution time is set, jobs stop once it is reached and
+// Execute returns after the operations in flight complete
 func (c *CoreWorkload) Execute() error {
+	c.executeStart, c.executeEnd = time.Now(), time.Time{}
+	defer func() {
+		c.executeEnd = time.Now()
+	}()
+
 	////
 	// Setup transition threads
 	////
 
 	var abortErr error           // If we abort for some reason, we will assign the reason to this error and return it
 	var timeout <-chan time.Time // The timeout channel. It is never used if max execution time is not set
-	abort := make(chan struct{}) // Used to halt everything on fatal error
-	done := make(chan struct{})  // Signaled when the waitgroup is done (all jobs exit normally)
+	var wg sync.WaitGroup        // Counts the jobs of this execution
+	var stopOnce sync.Once       // Guards closing stop
+	done := make(chan struct{})  // Signaled when the waitgroup is done (all jobs have returned)
+	stop := make(chan struct{})  // Closed to stop the jobs on fatal error or when max execution time is reached
+	stopJobs := func() {
+		stopOnce.Do(func() { close(stop) })
+	}
 
 	// If max execution time is set and is > 0, setup a timer that will fire on the timeout chan
 	if c.Config.MaxExecutionTime > 0 {
 		to := time.NewTimer(c.Config.MaxExecutionTime)
+		defer to.Stop()
 		timeout = to.C
 	}
 
 	// Create a waitgroup thread. This thread listens to the output of c.pool and decrements
-	// the wait group when the job is complete
-	waitGroupChan := make(chan pool.Job, defaultBufferLen)
-	waitGroupEnd := make(chan bool)
+	// the wait group when the job is complete. It keeps draining after a fatal error, so that no
+	// job of this execution is left in the output of the pool for the next one
+	waitGroupEnd := make(chan struct{})
 	waitGroupFunc := func() {
 		for {
 			select {
 			case <-waitGroupEnd:
 				return
-			case j := <-waitGroupChan:
+			case j := <-c.output:
 				job, ok := j.(*Job)
 				if !ok {
 					panic("received job that was not *Job (BUG)")
 				}
 
-				// If the job has a fatal error, we will abort
-				if job.FatalErr != nil {
+				// If the job has a fatal error, we will abort and stop the other jobs
+				if job.FatalErr != nil && abortErr == nil {
 					abortErr = job.FatalErr
-					abort <- struct{}{}
-					close(abort)
-					return
+					stopJobs()
 				}
 
-				c.wg.Done() // Must release! Otherwise we will deadlock
+				wg.Done() // Must release! Otherwise we will deadlock
 			}
 		}
 	}
 
-	c.pool.BindPool(waitGroupChan)
 	go waitGroupFunc()
-
-	// Cleanup on return
-	// defer func() {
-	// 	c.pool.Stop()
-	// 	waitGroupEnd <- true
-	// }()
+	defer close(waitGroupEnd)
 
 	////
 	// Do work. Generate jobs and feed them to the pool
 	////
-	c.wg.Add(1)
+	wg.Add(1)
 	go func() {
-		defer c.wg.Done()
+		defer wg.Done()
 
 		for _, target := range c.plan {
+			// The observer may be set after the plan was prepared
+			target.Observer = c.Observer
+			target.Stop = stop
+
 			// Bucketize operations
 			buckets := c.bucketOps(target.Operations, c.Config.Threads)
 
-			// For each bucket of operations, make a job
-			for _, ops := range buckets {
+			// For each bucket of operations, make a job. The bucket index identifies the worker so
+			// that every job draws from its own seeded sources. Workers of other partitions use
+			// other indexes.
+			for worker, ops := range buckets {
+				// Do not submit more jobs once they were stopped
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
 				// Get a job from the target
-				job := target.NewJob()
+				job := target.NewJob(c.Config.Partition.Worker(worker))
 
 				// Set operations
 				job.Operations = ops
 
+				// Increment waitgroup before the job can complete
+				wg.Add(1)
+
 				// Submit job to pool
 				c.pool.Submit(job)
-
-				// Increment waitgorup
-				c.wg.Add(1)
 			}
 		}
 	}()
@@ -433,19 +546,21 @@ func (c *CoreWorkload) Execute() error {
 	////
 	go func() {
 		// Wait for all jobs to flow through the pipeline
-		c.wg.Wait()
-		done <- struct{}{}
+		wg.Wait()
 		close(done)
 	}()
 
+	// abortErr is set before the job that failed is released, so it can be read once all jobs
+	// have returned
 	select {
-	case <-done: // all jobs exited normally
-		return nil
-	case <-abort: // a job encountered a fatal error
+	case <-done: // all jobs returned, abortErr is set if one encountered a fatal error
 		return abortErr
-	case <-timeout: // max execution time reached
-		return errors.New("max execution time reached")
+	case <-timeout: // max execution time reached, stop the jobs and let them drain
+		stopJobs()
 	}
+
+	<-done
+	return abortErr
 }
 
 func (c *CoreWorkload) Stop() error {
@@ -463,21 +578,53 @@ func (c *CoreWorkload) GetReadGeneratorMap(t schema.Table) (*sample.SampleGenera
 		return nil, fmt.Errorf("sampling table: %s", err.Error())
 	}
 
-	return generator.GetReadGeneratorMap(samples, t.PrimaryKeyNames())
+	return generator.GetReadGeneratorMap(seed.Source(c.Config.Seed, "table", t.Name(), "reads"), samples, t.PrimaryKeyNames())
+}
+
+// GetZipfianGenerator will return a generator choosing keys of the sample with a zipfian distribution
+func (c *CoreWorkload) GetZipfianGenerator(t schema.Table, samples *sample.SampleGenerator) (*sample.ZipfianGenerator, error) {
+	return sample.NewZipfianGenerator(rand.New(seed.Source(c.Config.Seed, "table", t.Name(), "zipfian")), samples, sample.ZipfianConstant)
+}
+
+// GetLatestGenerator will wrap a read generator so that a fraction of reads target recently inserted keys
+func (c *CoreWorkload) GetLatestGenerator(t schema.Table, fallback sample.KeyGenerator) (*sample.LatestGenerator, error) {
+	recent, err := sample.NewRecentKeys(rand.New(seed.Source(c.Config.Seed, "table", t.Name(), "recent")), c.Config.Operations.Latest.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	return sample.NewLatestGenerator(
+		rand.New(seed.Source(c.Config.Seed, "table", t.Name(), "latest")),
+		recent,
+		fallback,
+		c.Config.Operations.Latest.Fraction,
+	)
 }
 
-// SampleTable will return a map[string]interface of values using the tables primary keys
+// SampleTable will return a map[string]interface of values using the tables primary keys.
+// If operations.key_file is set, keys are loaded from that file instead of querying the table.
 func (c *CoreWorkload) SampleTable(t schema.Table) (map[string]interface{}, error) {
+	if c.Config.Operations.KeyFile != "" {
+		kr, err := generator.GetKeyRange(c.Config, t)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("Loading keys for table '%s' from key file '%s'", t.Name(), c.Config.Operations.KeyFile)
+		return generator.LoadKeyFile(c.Config.Operations.KeyFile, t, c.Config.Operations.Sampling.MaxKeys, kr)
+	}
+
 	return generator.SampleTable(c.Config, c.Context, c.client, t)
 }
 
 // GetGeneratorMap will return a generator map suitable for creating insert operations against a table
-func (c *CoreWorkload) GetGeneratorMap(t schema.Table) (data.GeneratorMap, error) {
-	return generator.GetDataGeneratorMapForTable(*c.Config, t)
+func (c *CoreWorkload) GetGeneratorMap(t schema.Table, pt JobType, worker int) (data.GeneratorMap, error) {
+	return generator.GetDataGeneratorMapForTable(c.Config, t, pt.String(), worker)
 }
 
-func (c *CoreWorkload) GetOperationSelector() (selector.Selector, error) {
-	return operation.NewOperationSelector(c.Config)
+// GetOperationSelector will return an operation selector for the table
+func (c *CoreWorkload) GetOperationSelector(t schema.Table) (selector.Selector, error) {
+	return operation.NewOperationSelector(c.Config, seed.Source(c.Config.Seed, "table", t.Name(), "selector"))
 }
 
 // bucketOps will divide operations into buckets and grow each bucket to handle remainders
@@ -498,36 +645,81 @@ func (c *CoreWorkload) bucketOps(n int, k int) []int {
 	return r
 }
 
+// ExecutionWindow returns when the execution of the last plan started and ended. Both are zero
+// if no plan was executed.
+func (c *CoreWorkload) ExecutionWindow() (time.Time, time.Time) {
+	return c.executeStart, c.executeEnd
+}
+
+// PlanSummary describes the planned targets in the order they execute
+func (c *CoreWorkload) PlanSummary() []TargetSummary {
+	ret := make([]TargetSummary, 0, len(c.plan))
+	for _, target := range c.plan {
+		ts := TargetSummary{
+			Table:      target.TableName,
+			Phase:      strings.ToUpper(target.JobType.String()),
+			Operations: target.Operations,
+		}
+
+		if target.JobType == JobRun {
+			ts.Read = c.Config.Operations.Read
+			ts.Write = c.Config.Operations.Write
+			ts.Update = c.Config.Operations.Update
+			ts.Scan = c.Config.Operations.Scan
+			ts.ReadModifyWrite = c.Config.Operations.ReadModifyWrite
+			if target.ReadGenerator != nil {
+				ts.Distribution = c.Config.Operations.Distribution
+			}
+			if target.RecentKeys != nil {
+				ts.Latest = c.Config.Operations.Latest.Fraction
+			}
+		}
+
+		ret = append(ret, ts)
+	}
+
+	return ret
+}
+
 func (c *CoreWorkload) SummarizePlan() {
 	tableString := &strings.Builder{}
 	t := tablewriter.NewWriter(tableString)
 	t.SetHeader([]string{
-		"Table", "Operations", "Read", "Write", "Context",
+		"Table", "Operations", "Read", "Write", "Update", "Scan", "RMW", "Keys", "Latest", "Context",
 	})
 
-	for _, target := range c.plan {
+	for _, ts := range c.PlanSummary() {
 		l := []string{
-			target.TableName,
-			fmt.Sprintf("%d", target.Operations),
+			ts.Table,
+			fmt.Sprintf("%d", ts.Operations),
 		}
 
-		if target.JobType == JobRun {
+		if ts.Phase == "RUN" {
 			l = append(l,
-				fmt.Sprintf("%d", c.Config.Operations.Read),
-				fmt.Sprintf("%d", c.Config.Operations.Write),
+				fmt.Sprintf("%d", ts.Read),
+				fmt.Sprintf("%d", ts.Write),
+				fmt.Sprintf("%d", ts.Update),
+				fmt.Sprintf("%d", ts.Scan),
+				fmt.Sprintf("%d", ts.ReadModifyWrite),
 			)
-		} else {
-			l = append(l, "N/A", "N/A")
-		}
 
-		if target.JobType == JobLoad {
-			l = append(l, "LOAD")
-		}
+			if ts.Distribution != "" {
+				l = append(l, ts.Distribution)
+			} else {
+				l = append(l, "N/A")
+			}
 
-		if target.JobType == JobRun {
-			l = append(l, "RUN")
+			if ts.Latest > 0 {
+				l = append(l, fmt.Sprintf("%.2f", ts.Latest))
+			} else {
+				l = append(l, "N/A")
+			}
+		} else {
+			l = append(l, "N/A", "N/A", "N/A", "N/A", "N/A", "N/A", "N/A")
 		}
 
+		l = append(l, ts.Phase)
+
 		t.Append(l)
 	}
 
@@ -1,10 +1,18 @@
 package workload
 
 import (
+	"context"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/rcrowley/go-metrics"
+	"github.com/spf13/viper"
 )
 
 func TestBucketOps(t *testing.T) {
@@ -103,6 +111,339 @@ func TestPlan(t *testing.T) {
 	}
 }
 
+func TestPlanPartition(t *testing.T) {
+	testSchema := schema.NewSchema()
+	t1 := schema.NewTable()
+	t1.SetName("Singers")
+	c1 := schema.NewColumn()
+	c1.SetName("SingerId")
+	c1.SetSpannerType("INT64")
+	c1.SetPrimaryKey(true)
+	t1.AddColumn(c1)
+	testSchema.Tables().AddTable(t1)
+
+	// 10 operations split across 3 processes
+	want := []int{4, 3, 3}
+	ranges := make([]*generator.KeyRange, len(want))
+	keys := make([][]int64, len(want))
+	for i, w := range want {
+		cfg := &config.Config{Partition: config.Partition{Index: i, Count: len(want)}}
+		cfg.Operations.Total = 10
+
+		workload := CoreWorkload{
+			Schema: testSchema,
+			Config: cfg,
+		}
+
+		err := workload.Plan(JobLoad, []string{"Singers"})
+		if err != nil {
+			t.Fatalf("workload.Plan got error: %v", err)
+		}
+
+		if got := workload.plan[0].Operations; got != w {
+			t.Errorf("partition %d planned %d operations, but want = %d", i, got, w)
+		}
+
+		ranges[i], err = generator.GetKeyRange(cfg, t1)
+		if err != nil {
+			t.Fatalf("GetKeyRange got error: %v", err)
+		}
+		for j := 0; j < 100; j++ {
+			keys[i] = append(keys[i], workload.plan[0].WriteGenerator["SingerId"].Next().(int64))
+		}
+	}
+
+	// Every process inserts keys of its own range only
+	for i := range keys {
+		for j, r := range ranges {
+			for _, k := range keys[i] {
+				if r.Contains(spanner.Key{k}) != (i == j) {
+					t.Fatalf("key %d of partition %d: in range of partition %d (%s) = %t", k, i, j, r, i != j)
+				}
+			}
+		}
+	}
+}
+
+func TestCoreWorkloadMemory(t *testing.T) {
+	v := viper.New()
+	v.Set("backend", backend.Memory)
+	v.Set("create.ddl_file", "../../schemas/multi_table.sql")
+	v.Set("threads", 4)
+	v.Set("seed", 7)
+	v.Set("operations.total", 200)
+
+	cfg, err := config.NewConfig(v)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	ctx := context.Background()
+	s, err := schema.LoadSchema(ctx, cfg)
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+
+	execute := func(f func(Workload) error) metrics.Registry {
+		registry := metrics.NewRegistry()
+		wl, err := NewCoreWorkload(WorkloadConfig{Context: ctx, Config: cfg, Schema: s, MetricRegistry: registry})
+		if err != nil {
+			t.Fatalf("NewCoreWorkload: %v", err)
+		}
+		defer wl.Stop()
+
+		if err := f(wl); err != nil {
+			t.Fatalf("executing workload: %v", err)
+		}
+
+		registry.Each(func(name string, _ interface{}) {
+			if _, _, ok := ParseErrorMetricName(name); ok {
+				t.Errorf("unexpected error metric %s", name)
+			}
+		})
+
+		return registry
+	}
+
+	// Loading an interleaved table loads its whole hierarchy, multiplying the rows of each child table
+	load := execute(func(wl Workload) error { return wl.Load([]string{"Albums"}) })
+	want := map[string]int64{"Singers": 200, "Albums": 1000, "Songs": 5000}
+	for table, rows := range want {
+		if n := metrics.GetOrRegisterMeter(TableMetricName(table, "operations.write.rate"), load).Count(); n != rows {
+			t.Errorf("loaded %d rows into %s, want %d", n, table, rows)
+		}
+	}
+	if n := metrics.GetOrRegisterMeter("operations.write.rate", load).Count(); n != 6200 {
+		t.Errorf("loaded %d rows, want 6200", n)
+	}
+
+	run := execute(func(wl Workload) error { return wl.Run("Singers") })
+	reads := metrics.GetOrRegisterMeter("operations.read.rate", run).Count()
+	writes := metrics.GetOrRegisterMeter("operations.write.rate", run).Count()
+	if reads == 0 || writes == 0 || reads+writes < 200 {
+		t.Errorf("run executed %d reads and %d writes, want about 200 operations", reads, writes)
+	}
+}
+
+func TestCoreWorkloadMemoryOperations(t *testing.T) {
+	v := viper.New()
+	v.Set("backend", backend.Memory)
+	v.Set("create.ddl_file", "../../schemas/ycsb.sql")
+	v.Set("threads", 4)
+	v.Set("seed", 7)
+	v.Set("operations.total", 200)
+	v.Set("operations.sample_size", 100)
+	v.Set("operations.distribution", config.DistributionZipfian)
+
+	cfg, err := config.NewConfig(v)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	ctx := context.Background()
+	s, err := schema.LoadSchema(ctx, cfg)
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+
+	// The in memory backend lives as long as cfg, only the operation mix changes between phases
+	mix := func(read, write, update, scan, rmw int) {
+		cfg.Operations.Read = read
+		cfg.Operations.Write = write
+		cfg.Operations.Update = update
+		cfg.Operations.Scan = scan
+		cfg.Operations.ReadModifyWrite = rmw
+	}
+	execute := func(f func(Workload) error) metrics.Registry {
+		registry := metrics.NewRegistry()
+		wl, err := NewCoreWorkload(WorkloadConfig{Context: ctx, Config: cfg, Schema: s, MetricRegistry: registry})
+		if err != nil {
+			t.Fatalf("NewCoreWorkload: %v", err)
+		}
+		defer wl.Stop()
+
+		if err := f(wl); err != nil {
+			t.Fatalf("executing workload: %v", err)
+		}
+
+		registry.Each(func(name string, _ interface{}) {
+			if _, _, ok := ParseErrorMetricName(name); ok {
+				t.Errorf("unexpected error metric %s", name)
+			}
+		})
+
+		return registry
+	}
+	// run returns how many operations of type op the run executed. Every type has its own
+	// metrics, so nothing may be recorded as a read or write unless op is one
+	run := func(op string) int64 {
+		registry := execute(func(wl Workload) error { return wl.Run("usertable") })
+		for _, other := range []string{OperationRead, OperationWrite} {
+			if other == op {
+				continue
+			}
+			if n := metrics.GetOrRegisterMeter("operations."+other+".rate", registry).Count(); n != 0 {
+				t.Errorf("%s run recorded %d operations as %s", op, n, other)
+			}
+		}
+		if n := metrics.GetOrRegisterMeter(TableMetricName("usertable", "operations."+op+".rate"), registry).Count(); n == 0 {
+			t.Errorf("%s run recorded no operations of the table", op)
+		}
+
+		return metrics.GetOrRegisterMeter("operations."+op+".rate", registry).Count()
+	}
+
+	execute(func(wl Workload) error { return wl.Load([]string{"usertable"}) })
+
+	// Updates and read-modify-writes only touch existing rows, a missing row would fail them
+	mix(0, 0, 1, 0, 0)
+	if n := run(OperationUpdate); n < 200 {
+		t.Errorf("run executed %d updates, want at least 200", n)
+	}
+
+	mix(0, 0, 0, 0, 1)
+	if n := run(OperationReadModifyWrite); n < 200 {
+		t.Errorf("run executed %d read-modify-writes, want at least 200", n)
+	}
+
+	// Scans are counted once each, whatever number of rows they read
+	mix(0, 0, 0, 1, 0)
+	if n := run(OperationScan); n < 200 {
+		t.Errorf("run executed %d scans, want at least 200", n)
+	}
+}
+
+func TestCoreWorkloadMemoryPhases(t *testing.T) {
+	v := viper.New()
+	v.Set("backend", backend.Memory)
+	v.Set("create.ddl_file", "../../schemas/single_table.sql")
+	v.Set("threads", 4)
+	v.Set("seed", 7)
+	v.Set("operations.total", 100)
+
+	cfg, err := config.NewConfig(v)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	ctx := context.Background()
+	s, err := schema.LoadSchema(ctx, cfg)
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+
+	registry := metrics.NewRegistry()
+	wl, err := NewCoreWorkload(WorkloadConfig{Context: ctx, Config: cfg, Schema: s, MetricRegistry: registry})
+	if err != nil {
+		t.Fatalf("NewCoreWorkload: %v", err)
+	}
+	defer wl.Stop()
+
+	// A prepared load executes with the observer set after preparing it, like agents do
+	cw := wl.(*CoreWorkload)
+	if err := cw.Prepare(JobLoad, []string{"SingleSingers"}); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	obs := &countingObserver{}
+	cw.Observer = obs
+	if err := cw.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if obs.done == 0 {
+		t.Error("observer attached after Prepare was not notified")
+	}
+	cw.Observer = nil
+
+	// Running after loading with the same workload executes each plan once
+	before := time.Now()
+	if err := wl.Run("SingleSingers"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	after := time.Now()
+
+	// Reports measure the execution of the plan, which starts after the table was sampled
+	start, end := ExecutionWindow(wl, before, after)
+	if start == before || end == after || start.Before(before) || end.After(after) || end.Before(start) {
+		t.Errorf("execution window %s - %s, want a window within %s - %s", start, end, before, after)
+	}
+
+	if plan := wl.(PlanSummarizer).PlanSummary(); len(plan) != 1 || plan[0].Phase != "RUN" {
+		t.Errorf("plan after run = %+v, want a single RUN target", plan)
+	}
+
+	// With a time limit the run ends long before its operations are exhausted
+	cfg.Operations.Total = 100000000
+	cfg.MaxExecutionTime = 200 * time.Millisecond
+	start = time.Now()
+	if err := wl.Run("SingleSingers"); err != nil {
+		t.Fatalf("Run with max execution time: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("run with a 200ms limit took %s", elapsed)
+	}
+}
+
+func TestCoreWorkloadMemoryAbort(t *testing.T) {
+	v := viper.New()
+	v.Set("backend", backend.Memory)
+	v.Set("create.ddl_file", "../../schemas/single_table.sql")
+	v.Set("threads", 16)
+	v.Set("seed", 7)
+	v.Set("operations.total", 2000)
+	v.Set("operations.max_errors", 10)
+
+	cfg, err := config.NewConfig(v)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	ctx := context.Background()
+	s, err := schema.LoadSchema(ctx, cfg)
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+
+	wl, err := NewCoreWorkload(WorkloadConfig{Context: ctx, Config: cfg, Schema: s, MetricRegistry: metrics.NewRegistry()})
+	if err != nil {
+		t.Fatalf("NewCoreWorkload: %v", err)
+	}
+	defer wl.Stop()
+
+	if err := wl.Load([]string{"SingleSingers"}); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Loading the same rows again exceeds the error budget, which aborts the load
+	obs := &countingObserver{}
+	wl.(*CoreWorkload).Observer = obs
+	if err := wl.Load([]string{"SingleSingers"}); err == nil {
+		t.Fatal("Load of existing rows did not abort")
+	}
+
+	// Every job returned before the aborted load did
+	done := atomic.LoadInt64(&obs.done)
+	if started := atomic.LoadInt64(&obs.started); started != done {
+		t.Errorf("%d operations started, but only %d finished when the load aborted", started, done)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt64(&obs.done); n != done {
+		t.Errorf("%d operations finished after the load aborted", n-done)
+	}
+
+	// Jobs of the aborted load do not leak into the next execution
+	wl.(*CoreWorkload).Observer = nil
+	cfg.Operations.Read, cfg.Operations.Write = 1, 0
+	if err := wl.Run("SingleSingers"); err != nil {
+		t.Fatalf("Run after aborted load: %v", err)
+	}
+}
+
 func isSameSlice(a, b []int) bool {
 	if len(a) != len(b) {
 		return false
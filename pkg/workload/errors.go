@@ -0,0 +1,150 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rcrowley/go-metrics"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// Operation names used in metric names
+	OperationRead            = "read"
+	OperationWrite           = "write"
+	OperationUpdate          = "update"
+	OperationScan            = "scan"
+	OperationReadModifyWrite = "read_modify_write"
+
+	// The error rate is not enforced until this many operations have been attempted,
+	// so that a single early failure does not abort the run
+	errorRateMinOperations = 100
+)
+
+type (
+	// ErrorBudget counts attempted and failed operations across all jobs. Failures are recorded
+	// in the metrics registry as counters named operations.<op>.errors.<code>. Record returns an
+	// error once max_errors or max_error_rate is exceeded. It is safe for concurrent use.
+	//
+	// An operation may take several attempts when it is retried. Attempts are recorded separately
+	// with RecordAttempt and do not count against the budget, only the final outcome does.
+	ErrorBudget struct {
+		registry   metrics.Registry
+		maxErrors  int64
+		maxRate    float64
+		operations int64
+		errors     int64
+	}
+)
+
+func NewErrorBudget(registry metrics.Registry, maxErrors int, maxRate float64) *ErrorBudget {
+	return &ErrorBudget{
+		registry:  registry,
+		maxErrors: int64(maxErrors),
+		maxRate:   maxRate,
+	}
+}
+
+// Record records n attempted operations of type op. If err is not nil, all n operations failed.
+// A nil *ErrorBudget records nothing.
+func (b *ErrorBudget) Record(op string, n int, err error) error {
+	if b == nil {
+		return nil
+	}
+
+	ops := atomic.AddInt64(&b.operations, int64(n))
+	if err == nil {
+		return nil
+	}
+
+	metrics.GetOrRegisterCounter(ErrorMetricName(op, ErrorCode(err)), b.registry).Inc(int64(n))
+	errs := atomic.AddInt64(&b.errors, int64(n))
+
+	if b.maxErrors > 0 && errs > b.maxErrors {
+		return fmt.Errorf("error budget exceeded: %d failed operations (max_errors %d)", errs, b.maxErrors)
+	}
+
+	if b.maxRate > 0 && ops >= errorRateMinOperations {
+		rate := float64(errs) / float64(ops)
+		if rate > b.maxRate {
+			return fmt.Errorf("error budget exceeded: %d of %d operations failed (%.4f > max_error_rate %.4f)", errs, ops, rate, b.maxRate)
+		}
+	}
+
+	return nil
+}
+
+// RecordAttempt records one attempt at n operations of type op in the counter operations.<op>.attempts.
+// If err is not nil, the attempt failed and is also counted in operations.<op>.attempt_errors.<code>.
+// A nil *ErrorBudget records nothing.
+func (b *ErrorBudget) RecordAttempt(op string, n int, err error) {
+	if b == nil {
+		return
+	}
+
+	metrics.GetOrRegisterCounter(AttemptMetricName(op), b.registry).Inc(int64(n))
+	if err != nil {
+		metrics.GetOrRegisterCounter(AttemptErrorMetricName(op, ErrorCode(err)), b.registry).Inc(int64(n))
+	}
+}
+
+// Errors returns the number of failed operations
+func (b *ErrorBudget) Errors() int64 {
+	return atomic.LoadInt64(&b.errors)
+}
+
+// Operations returns the number of attempted operations
+func (b *ErrorBudget) Operations() int64 {
+	return atomic.LoadInt64(&b.operations)
+}
+
+// ErrorMetricName returns the name of the counter for failed operations of type op with code
+func ErrorMetricName(op string, code codes.Code) string {
+	return fmt.Sprintf("operations.%s.errors.%s", op, code.String())
+}
+
+// AttemptMetricName returns the name of the counter for attempts at operations of type op
+func AttemptMetricName(op string) string {
+	return fmt.Sprintf("operations.%s.attempts", op)
+}
+
+// AttemptErrorMetricName returns the name of the counter for failed attempts at operations of type op with code
+func AttemptErrorMetricName(op string, code codes.Code) string {
+	return fmt.Sprintf("operations.%s.attempt_errors.%s", op, code.String())
+}
+
+// ParseErrorMetricName splits a counter name created by ErrorMetricName into operation and code.
+// ok is false if name is not an error counter.
+func ParseErrorMetricName(name string) (op string, code string, ok bool) {
+	return parseCodeMetricName(name, "errors")
+}
+
+// ParseAttemptErrorMetricName splits a counter name created by AttemptErrorMetricName into operation and code.
+// ok is false if name is not a failed attempt counter.
+func ParseAttemptErrorMetricName(name string) (op string, code string, ok bool) {
+	return parseCodeMetricName(name, "attempt_errors")
+}
+
+func parseCodeMetricName(name string, kind string) (op string, code string, ok bool) {
+	parts := strings.Split(name, ".")
+	if len(parts) != 4 || parts[0] != "operations" || parts[2] != kind {
+		return "", "", false
+	}
+
+	return parts[1], parts[3], true
+}
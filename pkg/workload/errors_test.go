@@ -0,0 +1,162 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/rcrowley/go-metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorBudget(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	t.Run("counts errors by operation and code", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		b := NewErrorBudget(registry, 0, 0)
+
+		for i := 0; i < 10; i++ {
+			if err := b.Record(OperationRead, 1, nil); err != nil {
+				t.Fatalf("Record got error: %v", err)
+			}
+		}
+		if err := b.Record(OperationWrite, 5, unavailable); err != nil {
+			t.Fatalf("Record got error: %v", err)
+		}
+
+		if got := b.Operations(); got != 15 {
+			t.Errorf("Operations() = %d, want 15", got)
+		}
+		if got := b.Errors(); got != 5 {
+			t.Errorf("Errors() = %d, want 5", got)
+		}
+
+		c, ok := registry.Get("operations.write.errors.Unavailable").(metrics.Counter)
+		if !ok {
+			t.Fatal("missing operations.write.errors.Unavailable counter")
+		}
+		if c.Count() != 5 {
+			t.Errorf("operations.write.errors.Unavailable = %d, want 5", c.Count())
+		}
+	})
+
+	t.Run("max errors", func(t *testing.T) {
+		b := NewErrorBudget(metrics.NewRegistry(), 2, 0)
+		for i := 0; i < 2; i++ {
+			if err := b.Record(OperationRead, 1, unavailable); err != nil {
+				t.Fatalf("Record got error before budget was exceeded: %v", err)
+			}
+		}
+		if err := b.Record(OperationRead, 1, unavailable); err == nil {
+			t.Error("Record did not return an error after max_errors was exceeded")
+		}
+	})
+
+	t.Run("max error rate", func(t *testing.T) {
+		b := NewErrorBudget(metrics.NewRegistry(), 0, 0.1)
+
+		// The rate is not enforced until enough operations were attempted
+		if err := b.Record(OperationRead, 1, unavailable); err != nil {
+			t.Fatalf("Record got error before min operations: %v", err)
+		}
+
+		for i := 0; i < errorRateMinOperations; i++ {
+			if err := b.Record(OperationRead, 1, nil); err != nil {
+				t.Fatalf("Record got error for a successful operation: %v", err)
+			}
+		}
+
+		var err error
+		for i := 0; i < 20 && err == nil; i++ {
+			err = b.Record(OperationRead, 1, unavailable)
+		}
+		if err == nil {
+			t.Error("Record did not return an error after max_error_rate was exceeded")
+		}
+	})
+
+	t.Run("nil budget", func(t *testing.T) {
+		var b *ErrorBudget
+		if err := b.Record(OperationRead, 1, unavailable); err != nil {
+			t.Errorf("nil budget returned error: %v", err)
+		}
+	})
+
+	t.Run("parse metric name", func(t *testing.T) {
+		op, code, ok := ParseErrorMetricName(ErrorMetricName(OperationRead, codes.NotFound))
+		if !ok || op != OperationRead || code != "NotFound" {
+			t.Errorf("ParseErrorMetricName = (%q, %q, %v)", op, code, ok)
+		}
+
+		if _, _, ok := ParseErrorMetricName("operations.read.time"); ok {
+			t.Error("ParseErrorMetricName accepted operations.read.time")
+		}
+	})
+}
+
+func TestJobFailedOperations(t *testing.T) {
+	client := newTestClient(t)
+	target := newTestTarget(client, JobRun)
+	target.ErrorBudget = NewErrorBudget(metrics.NewRegistry(), 0, 0)
+
+	j := target.NewJob(0)
+
+	// Reading a missing row fails with NotFound. It must be counted, not timed, and not be fatal.
+	err := j.checkSpannerError(OperationRead, 1, j.readRow(spanner.Key{"missing"}))
+	if err != nil {
+		t.Fatalf("NotFound was treated as fatal: %v", err)
+	}
+
+	if got := target.ErrorBudget.Errors(); got != 1 {
+		t.Errorf("Errors() = %d, want 1", got)
+	}
+	if got := target.DataReadTimer.Count(); got != 0 {
+		t.Errorf("failed read was timed (count %d)", got)
+	}
+
+	// A write with the max_errors budget exhausted is fatal
+	target.ErrorBudget = NewErrorBudget(metrics.NewRegistry(), 1, 0)
+	j = target.NewJob(0)
+	dup := []*spanner.Mutation{
+		spanner.InsertMap("Singers", map[string]interface{}{"SingerId": "a"}),
+		spanner.InsertMap("Singers", map[string]interface{}{"SingerId": "a"}),
+	}
+	for i := 0; i < 2; i++ {
+		_, _ = client.Apply(context.Background(), dup[:1])
+		err = j.checkSpannerError(OperationWrite, 1, j.applyMutations(dup[1:]))
+	}
+	if err == nil || j.FatalErr == nil {
+		t.Fatal("exceeding max_errors did not set FatalErr")
+	}
+	if got := target.DataWriteTimer.Count(); got != 0 {
+		t.Errorf("failed write was timed (count %d)", got)
+	}
+}
+
+func TestJobCanceled(t *testing.T) {
+	client := newTestClient(t)
+	target := newTestTarget(client, JobRun)
+
+	// Retries return the context error as is, it must halt the workload like a canceled RPC
+	j := target.NewJob(0)
+	err := j.checkSpannerError(OperationRead, 1, context.Canceled)
+	if err == nil || j.FatalErr == nil {
+		t.Fatal("context.Canceled was not treated as fatal")
+	}
+}
@@ -17,14 +17,17 @@ package workload
 import (
 	"context"
 	"log"
+	"math/rand"
 	"time"
 
 	"cloud.google.com/go/spanner"
-	"github.com/rcrowley/go-metrics"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/data"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/operation"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/sample"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/selector"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
+	"github.com/rcrowley/go-metrics"
 	"google.golang.org/grpc/codes"
 )
 
@@ -32,27 +35,44 @@ type (
 	Job struct {
 		JobType           JobType           // Job Type (load or run)
 		Context           context.Context   // Context
-		Client            *spanner.Client   // Spanner Client
+		Client            backend.Client    // Spanner Client
 		Table             string            // Table name to execute against
 		Operations        int               // How many operations in this job
 		Batched           bool              // When true, batch $operations mostly used for load
 		BatchSize         int               // Write batch size
 		Columns           []string          // Tables column names to ask for during reads
+		KeyColumns        []string          // Tables primary key column names
 		StaleReads        bool              // Perform stale reads if true
 		Staleness         time.Duration     // If performing stale reads, use this exact staleness
-		OperationSelector selector.Selector // Weghted choice selector (read or write)
+		OperationSelector selector.Selector // Weghted choice selector (see package operation)
+		MaxScanLength     int               // Scans read between 1 and this many rows
+		ScanSource        *rand.Rand        // Source of scan lengths
+		Timeout           time.Duration     // Deadline for each operation including retries (0 means none)
+		ReadRetry         *RetryPolicy      // Retry policy for reads (optional)
+		WriteRetry        *RetryPolicy      // Retry policy for writes (optional)
+		Stop              <-chan struct{}   // Closed when the job must stop before completing its operations (optional)
 
 		// Generators
-		WriteGenerator data.GeneratorMap       // Generator for making row data
-		ReadGenerator  *sample.SampleGenerator // Generator for point reads
+		WriteGenerator data.GeneratorMap   // Generator for making row data
+		ReadGenerator  sample.KeyGenerator // Generator for point reads
+		RecentKeys     *sample.RecentKeys  // Ring of keys inserted during this run (optional)
 
 		// Metrics
-		DataWriteGenerationTimer metrics.Timer // Used to time data generation
-		DataReadGenerationTimer  metrics.Timer // Used to time data geenration
-		DataWriteTimer           metrics.Timer // Used to time writes
-		DataWriteMeter           metrics.Meter // Used to measure volume of writes
-		DataReadTimer            metrics.Timer // Used to time reads
-		DataReadMeter            metrics.Meter // Used to measure volume of reads
+		DataWriteGenerationTimer metrics.Timer     // Used to time data generation
+		DataReadGenerationTimer  metrics.Timer     // Used to time data geenration
+		DataWriteTimer           metrics.Timer     // Used to time writes
+		DataWriteMeter           metrics.Meter     // Used to measure volume of writes
+		DataReadTimer            metrics.Timer     // Used to time reads
+		DataReadMeter            metrics.Meter     // Used to measure volume of reads
+		DataUpdateTimer          metrics.Timer     // Used to time updates
+		DataUpdateMeter          metrics.Meter     // Used to measure volume of updates
+		DataScanTimer            metrics.Timer     // Used to time scans
+		DataScanMeter            metrics.Meter     // Used to measure volume of scans
+		DataRMWTimer             metrics.Timer     // Used to time read-modify-writes
+		DataRMWMeter             metrics.Meter     // Used to measure volume of read-modify-writes
+		ErrorBudget              *ErrorBudget      // Counts failed operations (optional)
+		TableMetrics             *TableMetrics     // Metrics of this jobs table (optional)
+		Observer                 observer.Observer // Notified about every operation (optional)
 
 		FatalErr error
 	}
@@ -60,6 +80,7 @@ type (
 	// A simplified transaction interface to consolidate stale vs strong reads
 	transaction interface {
 		ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error)
+		ReadWithOptions(ctx context.Context, table string, keys spanner.KeySet, columns []string, opts *spanner.ReadOptions) *spanner.RowIterator
 		Close()
 	}
 )
@@ -80,7 +101,7 @@ func (j *Job) Execute() {
 			}
 		} else {
 			// Insert $operations individually
-			for i := 0; i <= j.Operations; i++ {
+			for i := 0; i <= j.Operations && !j.stopped(); i++ {
 				err := j.InsertOne()
 				if err != nil { // If err is returned, it is fatal
 					return
@@ -89,7 +110,7 @@ func (j *Job) Execute() {
 		}
 	case JobRun: // Run against table
 		// Generate $operations reads/writes
-		for i := 0; i <= j.Operations; i++ {
+		for i := 0; i <= j.Operations && !j.stopped(); i++ {
 			// Select an operation to perform
 			op := j.OperationSelector.Select().Item().(operation.Operation)
 			switch op {
@@ -103,6 +124,21 @@ func (j *Job) Execute() {
 				if err != nil { // if err is returned, it is fatal
 					return
 				}
+			case operation.UPDATE:
+				err := j.UpdateOne()
+				if err != nil { // if err is returned, it is fatal
+					return
+				}
+			case operation.SCAN:
+				err := j.ScanOne()
+				if err != nil { // if err is returned, it is fatal
+					return
+				}
+			case operation.READ_MODIFY_WRITE:
+				err := j.ReadModifyWriteOne()
+				if err != nil { // if err is returned, it is fatal
+					return
+				}
 			}
 		}
 	default:
@@ -115,18 +151,58 @@ func (j *Job) ReadOne() error {
 	// Generate read predicate
 	r := j.generateReadKey()
 
-	// Get a read transaction
-	tx := j.getReadTransaction()
-
 	// perform read
-	err := j.readRow(tx, r)
+	err := j.readRow(r)
 
 	// Check for fatal errors
-	err = j.checkSpannerError(err)
+	err = j.checkSpannerError(OperationRead, 1, err)
 
 	return err
 }
 
+/*
+ * ScanOne will read between 1 and MaxScanLength rows starting at an existing row
+ */
+func (j *Job) ScanOne() error {
+	// Generate the start of the range and its length
+	r := j.generateReadKey()
+	limit := 1 + j.ScanSource.Intn(j.MaxScanLength)
+
+	// perform scan
+	err := j.scanRows(r, limit)
+
+	// Check for fatal errors
+	return j.checkSpannerError(OperationScan, 1, err)
+}
+
+/*
+ * UpdateOne will overwrite the columns of an existing row with generated data
+ */
+func (j *Job) UpdateOne() error {
+	// Create a map of row data for an existing key
+	_, m := j.generateUpdate()
+
+	// Update the row using the mutation API
+	err := j.updateRow(m)
+
+	// Collect the error and only return it if it is fatal
+	return j.checkSpannerError(OperationUpdate, 1, err)
+}
+
+/*
+ * ReadModifyWriteOne will read an existing row and update it in one read-write transaction
+ */
+func (j *Job) ReadModifyWriteOne() error {
+	// Create a map of row data for an existing key
+	k, m := j.generateUpdate()
+
+	// Read and update the row
+	err := j.readModifyWrite(k, m)
+
+	// Collect the error and only return it if it is fatal
+	return j.checkSpannerError(OperationReadModifyWrite, 1, err)
+}
+
 /*
  * InsertOne will insert one row into the jobs table
  */
@@ -141,12 +217,13 @@ func (j *Job) InsertOne() error {
 		},
 	)
 
-	// Check if error is fatal. Since we're only performing 1 op,
-	// This is called mostly for it's side effect of collecting
-	// non-fatal errors to be used elsewhere
-	_ = j.checkSpannerError(err)
+	// Remember the key so that reads can target recently inserted rows
+	if err == nil && j.RecentKeys != nil {
+		j.recordKey(m)
+	}
 
-	return err
+	// Collect the error and only return it if it is fatal
+	return j.checkSpannerError(OperationWrite, 1, err)
 }
 
 /*
@@ -162,7 +239,7 @@ func (j *Job) InsertBatch() error {
 	// Create a buffer for storing mutations
 	buffer := make([]*spanner.Mutation, 0, bsize)
 
-	for i := 1; i <= j.Operations; i++ {
+	for i := 1; i <= j.Operations && !j.stopped(); i++ {
 		// Generate a map for the row data
 		m := j.generateRow()
 
@@ -175,7 +252,7 @@ func (j *Job) InsertBatch() error {
 			err := j.applyMutations(buffer)
 
 			// Check to see if error is fatal, and halt if it is
-			err = j.checkSpannerError(err)
+			err = j.checkSpannerError(OperationWrite, len(buffer), err)
 			if err != nil {
 				return err
 			}
@@ -192,7 +269,7 @@ func (j *Job) InsertBatch() error {
 		err := j.applyMutations(buffer)
 
 		// Check to see if error is fatal, and halt if it is
-		err = j.checkSpannerError(err)
+		err = j.checkSpannerError(OperationWrite, len(buffer), err)
 		if err != nil {
 			return err
 		}
@@ -204,11 +281,30 @@ func (j *Job) InsertBatch() error {
 	return nil
 }
 
-// checkSpannerError will return the error if it is fatal,
-// if not, it will collect the error and return nil
-func (j *Job) checkSpannerError(err error) error {
+// stopped reports whether the job was asked to stop. Operations in flight always complete
+func (j *Job) stopped() bool {
+	select {
+	case <-j.Stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkSpannerError records n operations of type op in the error budget. It will return the error
+// if it is fatal, if not, it will collect the error and return nil
+func (j *Job) checkSpannerError(op string, n int, err error) error {
+	j.TableMetrics.RecordError(op, n, err)
+
+	// Exceeding the error budget halts the entire workload
+	budgetErr := j.ErrorBudget.Record(op, n, err)
+	if budgetErr != nil {
+		j.FatalErr = budgetErr
+		return budgetErr
+	}
+
 	if err != nil {
-		spannerErr := spanner.ErrCode(err)
+		spannerErr := ErrorCode(err)
 
 		// If error is codes.Unauthenticated, return. We can not proceed
 		if spannerErr == codes.Unauthenticated {
@@ -221,8 +317,6 @@ func (j *Job) checkSpannerError(err error) error {
 			j.FatalErr = err // Set the FatalError so we halt the entire workload
 			return err
 		}
-
-		// TODO: Collect errors
 	}
 
 	return nil
@@ -232,25 +326,59 @@ func (j *Job) checkSpannerError(err error) error {
 func (j *Job) generateRow() map[string]interface{} {
 	// Generate a map for the row data
 	m := make(map[string]interface{}, len(j.WriteGenerator))
-	j.DataWriteGenerationTimer.Time(func() {
-		for k, v := range j.WriteGenerator {
-			m[k] = v.Next()
-		}
-	})
+	start := time.Now()
+	for k, v := range j.WriteGenerator {
+		m[k] = v.Next()
+	}
+
+	d := time.Since(start)
+	j.DataWriteGenerationTimer.Update(d)
+	j.TableMetrics.RecordWriteGeneration(d)
 
 	return m
 }
 
 // generateReadKey will return a spanner.Key suitable for executing a point read
 func (j *Job) generateReadKey() spanner.Key {
-	var r spanner.Key
-	j.DataReadGenerationTimer.Time(func() {
-		r = j.ReadGenerator.Next().(spanner.Key)
-	})
+	start := time.Now()
+	r := j.ReadGenerator.Next().(spanner.Key)
+
+	d := time.Since(start)
+	j.DataReadGenerationTimer.Update(d)
+	j.TableMetrics.RecordReadGeneration(d)
 
 	return r
 }
 
+// generateUpdate will return a key of the jobs ReadGenerator and a map of row data for it
+func (j *Job) generateUpdate() (spanner.Key, map[string]interface{}) {
+	k := j.generateReadKey()
+	m := j.generateRow()
+	for i, col := range j.KeyColumns {
+		if i < len(k) {
+			m[col] = k[i]
+		}
+	}
+
+	return k, m
+}
+
+// recordKey will add the primary key of a row to the jobs RecentKeys ring
+func (j *Job) recordKey(m map[string]interface{}) {
+	k := make(spanner.Key, 0, len(j.KeyColumns))
+	for _, col := range j.KeyColumns {
+		v, ok := m[col]
+		// Commit timestamps are not known until after the write, so the key can't be read back
+		if !ok || v == spanner.CommitTimestamp {
+			return
+		}
+
+		k = append(k, v)
+	}
+
+	j.RecentKeys.Add(k)
+}
+
 // getTransactions will return a read transactions based on jobs config
 func (j *Job) getReadTransaction() transaction {
 	if j.StaleReads {
@@ -260,28 +388,180 @@ func (j *Job) getReadTransaction() transaction {
 	return j.Client.Single()
 }
 
-// applyMutations will call apply on a slice of spanner mutations and return any errors
+// applyMutations will call apply on a slice of spanner mutations and return any errors.
+// Only successful writes are timed and measured, including the time spent on retries.
 func (j *Job) applyMutations(muts []*spanner.Mutation) error {
-	var err error
-	j.DataWriteTimer.Time(func() {
-		_, err = j.Client.Apply(j.Context, muts)
+	ctx := j.observeStart(OperationWrite)
+	start := time.Now()
+	err := j.withRetry(ctx, OperationWrite, len(muts), j.WriteRetry, func(ctx context.Context) error {
+		_, err := j.Client.Apply(ctx, muts)
+		return err
+	})
+	d := time.Since(start)
+	j.observeDone(ctx, OperationWrite, len(muts), d, err)
+	if err == nil {
+		j.DataWriteTimer.Update(d)
 		j.DataWriteMeter.Mark(int64(len(muts))) // Mark how many write mutations were proccessed
+		j.TableMetrics.RecordWrite(d, len(muts))
+	}
+
+	return err
+}
+
+// updateRow will apply an update of the row m.
+// Only successful updates are timed and measured, including the time spent on retries.
+func (j *Job) updateRow(m map[string]interface{}) error {
+	ctx := j.observeStart(OperationUpdate)
+	start := time.Now()
+	err := j.withRetry(ctx, OperationUpdate, 1, j.WriteRetry, func(ctx context.Context) error {
+		_, err := j.Client.Apply(ctx, []*spanner.Mutation{spanner.UpdateMap(j.Table, m)})
+		return err
 	})
+	d := time.Since(start)
+	j.observeDone(ctx, OperationUpdate, 1, d, err)
+	if err == nil {
+		j.DataUpdateTimer.Update(d)
+		j.DataUpdateMeter.Mark(1)
+		j.TableMetrics.RecordUpdate(d)
+	}
 
 	return err
 }
 
-// readRow will query the table for the provided spanner.Key using the passed transaction
-func (j *Job) readRow(tx transaction, r spanner.Key) error {
-	var err error
-	j.DataReadTimer.Time(func() {
+// readRow will query the table for the provided spanner.Key using a read transaction per attempt.
+// Only successful reads are timed and measured, including the time spent on retries.
+func (j *Job) readRow(r spanner.Key) error {
+	ctx := j.observeStart(OperationRead)
+	start := time.Now()
+	err := j.withRetry(ctx, OperationRead, 1, j.ReadRetry, func(ctx context.Context) error {
+		// Get a read transaction
+		tx := j.getReadTransaction()
+		defer tx.Close()
+
 		// Perform read, discard row
-		_, err = tx.ReadRow(j.Context, j.Table, r, j.Columns)
+		_, err := tx.ReadRow(ctx, j.Table, r, j.Columns)
+		return err
+	})
+	d := time.Since(start)
+	j.observeDone(ctx, OperationRead, 1, d, err)
+	if err == nil {
+		j.DataReadTimer.Update(d)
 		j.DataReadMeter.Mark(1) // measure read rate
+		j.TableMetrics.RecordRead(d, 1)
+	}
+
+	return err
+}
+
+// scanRows will read at most limit rows starting at r using a read transaction per attempt.
+// Only successful scans are timed and measured, including the time spent on retries.
+func (j *Job) scanRows(r spanner.Key, limit int) error {
+	ctx := j.observeStart(OperationScan)
+	start := time.Now()
+	err := j.withRetry(ctx, OperationScan, 1, j.ReadRetry, func(ctx context.Context) error {
+		// Get a read transaction
+		tx := j.getReadTransaction()
+		defer tx.Close()
+
+		// An empty end key with a closed bound includes every key after r
+		keys := spanner.KeyRange{Start: r, End: spanner.Key{}, Kind: spanner.ClosedClosed}
+
+		// Perform read and discard rows
+		return tx.ReadWithOptions(ctx, j.Table, keys, j.Columns, &spanner.ReadOptions{Limit: limit}).Do(func(*spanner.Row) error {
+			return nil
+		})
 	})
+	d := time.Since(start)
+	j.observeDone(ctx, OperationScan, 1, d, err) // observe one scan, not the rows returned
+	if err == nil {
+		j.DataScanTimer.Update(d)
+		j.DataScanMeter.Mark(1)
+		j.TableMetrics.RecordScan(d)
+	}
+
+	return err
+}
+
+// readModifyWrite will read the row k and update it with m in a read-write transaction.
+// Only successful transactions are timed and measured, including the time spent on retries.
+func (j *Job) readModifyWrite(k spanner.Key, m map[string]interface{}) error {
+	ctx := j.observeStart(OperationReadModifyWrite)
+	start := time.Now()
+	err := j.withRetry(ctx, OperationReadModifyWrite, 1, j.WriteRetry, func(ctx context.Context) error {
+		_, err := j.Client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+			_, err := tx.ReadRow(ctx, j.Table, k, j.Columns)
+			if err != nil {
+				return err
+			}
 
-	// Close the transaction
-	tx.Close()
+			return tx.BufferWrite([]*spanner.Mutation{spanner.UpdateMap(j.Table, m)})
+		})
+		return err
+	})
+	d := time.Since(start)
+	j.observeDone(ctx, OperationReadModifyWrite, 1, d, err)
+	if err == nil {
+		j.DataRMWTimer.Update(d)
+		j.DataRMWMeter.Mark(1)
+		j.TableMetrics.RecordReadModifyWrite(d)
+	}
 
 	return err
 }
+
+// withRetry calls f until it succeeds, fails with an error the policy does not retry, or the
+// operation deadline expires. Every attempt is recorded in the error budget. The returned error
+// is the final outcome of the operation.
+func (j *Job) withRetry(ctx context.Context, op string, n int, policy *RetryPolicy, f func(ctx context.Context) error) error {
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := f(ctx)
+		j.ErrorBudget.RecordAttempt(op, n, err)
+
+		if !policy.Retryable(attempt, err) {
+			return err
+		}
+
+		// There is no point in retrying once the deadline has expired
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		t := time.NewTimer(policy.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// observeStart notifies the jobs observer that an operation is about to be sent and returns
+// the context to send it with
+func (j *Job) observeStart(op string) context.Context {
+	if j.Observer == nil {
+		return j.Context
+	}
+
+	return j.Observer.Start(j.Context, j.Table, op)
+}
+
+// observeDone notifies the jobs observer that an operation finished
+func (j *Job) observeDone(ctx context.Context, op string, n int, d time.Duration, err error) {
+	if j.Observer == nil {
+		return
+	}
+
+	code := codes.OK
+	if err != nil {
+		code = ErrorCode(err)
+	}
+
+	j.Observer.Done(ctx, j.Table, op, n, d, code)
+}
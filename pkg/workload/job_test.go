@@ -0,0 +1,322 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spannertest"
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/operation"
+	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/rcrowley/go-metrics"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	testDB      = "projects/p/instances/i/databases/d"
+	testThreads = 32
+	testOps     = 50
+)
+
+// countingObserver counts operations that started and finished
+type countingObserver struct {
+	started int64
+	done    int64
+}
+
+func (o *countingObserver) Start(ctx context.Context, table string, op string) context.Context {
+	atomic.AddInt64(&o.started, 1)
+	return ctx
+}
+
+func (o *countingObserver) Done(ctx context.Context, table string, op string, n int, d time.Duration, code codes.Code) {
+	atomic.AddInt64(&o.done, 1)
+}
+
+// recordingObserver records the row count and code of finished operations
+type recordingObserver struct {
+	mu    sync.Mutex
+	n     []int
+	codes []codes.Code
+}
+
+func (o *recordingObserver) Start(ctx context.Context, table string, op string) context.Context {
+	return ctx
+}
+
+func (o *recordingObserver) Done(ctx context.Context, table string, op string, n int, d time.Duration, code codes.Code) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.n = append(o.n, n)
+	o.codes = append(o.codes, code)
+}
+
+// newTestClient starts an in-memory spanner server with the Singers table and returns a client for it
+func newTestClient(t *testing.T) *spanner.Client {
+	t.Helper()
+
+	srv, err := spannertest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatalf("starting spannertest server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	srv.SetLogger(func(format string, args ...interface{}) {})
+
+	ddl, err := spansql.ParseDDL("", `CREATE TABLE Singers (
+		SingerId STRING(36) NOT NULL,
+		Name STRING(64),
+		Score INT64,
+	) PRIMARY KEY (SingerId)`)
+	if err != nil {
+		t.Fatalf("parsing DDL: %v", err)
+	}
+
+	if err := srv.UpdateDDL(ddl); err != nil {
+		t.Fatalf("applying DDL: %v", err)
+	}
+
+	client, err := spanner.NewClient(context.Background(), testDB,
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithInsecure()),
+	)
+	if err != nil {
+		t.Fatalf("creating spanner client: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	return client
+}
+
+func singersTable() schema.Table {
+	t := schema.NewTable()
+	t.SetName("Singers")
+
+	for _, c := range []struct {
+		name string
+		typ  string
+		pk   bool
+	}{
+		{"SingerId", "STRING(36)", true},
+		{"Name", "STRING(64)", false},
+		{"Score", "INT64", false},
+	} {
+		col := schema.NewColumn()
+		col.SetName(c.name)
+		col.SetSpannerType(c.typ)
+		col.SetPrimaryKey(c.pk)
+		t.AddColumn(col)
+	}
+
+	return t
+}
+
+func newTestTarget(client *spanner.Client, jt JobType) *Target {
+	table := singersTable()
+	cfg := &config.Config{
+		Seed:      42,
+		Batch:     true,
+		BatchSize: 10,
+	}
+	cfg.Operations.Read = 50
+	cfg.Operations.Write = 50
+
+	return &Target{
+		Config:                   cfg,
+		Context:                  context.Background(),
+		Client:                   client,
+		JobType:                  jt,
+		Table:                    table,
+		TableName:                table.Name(),
+		ColumnNames:              table.ColumnNames(),
+		KeyColumns:               table.PrimaryKeyNames(),
+		DataWriteGenerationTimer: metrics.NewTimer(),
+		DataReadGenerationTimer:  metrics.NewTimer(),
+		DataWriteTimer:           metrics.NewTimer(),
+		DataWriteMeter:           metrics.NewMeter(),
+		DataReadTimer:            metrics.NewTimer(),
+		DataReadMeter:            metrics.NewMeter(),
+		ErrorBudget:              NewErrorBudget(metrics.NewRegistry(), 0, 0),
+	}
+}
+
+// executeJobs runs one job per worker concurrently and fails the test on fatal errors
+func executeJobs(t *testing.T, target *Target) {
+	t.Helper()
+
+	jobs := make([]*Job, testThreads)
+	for i := range jobs {
+		jobs[i] = target.NewJob(i)
+		jobs[i].Operations = testOps
+	}
+
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j *Job) {
+			defer wg.Done()
+			j.Execute()
+		}(j)
+	}
+	wg.Wait()
+
+	for i, j := range jobs {
+		if j.FatalErr != nil {
+			t.Fatalf("job %d: %v", i, j.FatalErr)
+		}
+	}
+}
+
+func readKeys(t *testing.T, client *spanner.Client) []string {
+	t.Helper()
+
+	keys := make([]string, 0)
+	iter := client.Single().Read(context.Background(), "Singers", spanner.AllKeys(), []string{"SingerId"})
+	defer iter.Stop()
+
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading keys: %v", err)
+		}
+
+		var k string
+		if err := row.Columns(&k); err != nil {
+			t.Fatalf("decoding key: %v", err)
+		}
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func TestJobExecuteConcurrent(t *testing.T) {
+	client := newTestClient(t)
+
+	// Load the table from many workers at once. Workers must not generate the same keys,
+	// otherwise batches fail with AlreadyExists and rows go missing.
+	executeJobs(t, newTestTarget(client, JobLoad))
+
+	keys := readKeys(t, client)
+	if want := testThreads * testOps; len(keys) != want {
+		t.Fatalf("loaded %d rows, want %d", len(keys), want)
+	}
+
+	// Run a mixed read/write workload against the loaded keys. Every job forks the shared
+	// selector and read generator, which the race detector verifies.
+	target := newTestTarget(client, JobRun)
+
+	sel, err := operation.NewOperationSelector(target.Config, rand.NewSource(1))
+	if err != nil {
+		t.Fatalf("creating operation selector: %v", err)
+	}
+	target.OperationSelector = sel
+
+	sg, err := generator.GetReadGeneratorMap(rand.NewSource(1), map[string]interface{}{"SingerId": keys}, target.KeyColumns)
+	if err != nil {
+		t.Fatalf("creating read generator: %v", err)
+	}
+	target.ReadGenerator = sg
+
+	obs := &countingObserver{}
+	target.Observer = obs
+
+	executeJobs(t, target)
+
+	if target.DataReadMeter.Count() == 0 || target.DataWriteMeter.Count() == 0 {
+		t.Fatalf("expected reads and writes, got %d reads and %d writes", target.DataReadMeter.Count(), target.DataWriteMeter.Count())
+	}
+
+	if errs := target.ErrorBudget.Errors(); errs != 0 {
+		t.Fatalf("run had %d failed operations", errs)
+	}
+
+	// Every operation is reported to the observer once it is done. Run writes are not batched.
+	if want := target.DataReadMeter.Count() + target.DataWriteMeter.Count(); obs.started != want || obs.done != want {
+		t.Fatalf("observer saw %d started and %d done operations, want %d", obs.started, obs.done, want)
+	}
+}
+
+func TestTargetNewJobDeterministic(t *testing.T) {
+	target := newTestTarget(nil, JobRun)
+
+	sel, err := operation.NewOperationSelector(target.Config, rand.NewSource(1))
+	if err != nil {
+		t.Fatalf("creating operation selector: %v", err)
+	}
+	target.OperationSelector = sel
+
+	ops := func(worker int) []interface{} {
+		j := target.NewJob(worker)
+		ret := make([]interface{}, 0, 100)
+		for i := 0; i < 100; i++ {
+			ret = append(ret, j.OperationSelector.Select().Item())
+		}
+		return ret
+	}
+
+	a, b := ops(3), ops(3)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("worker 3 selected %v then %v at operation %d", a[i], b[i], i)
+		}
+	}
+}
+
+func TestJobScanObservesOneOperation(t *testing.T) {
+	client := newTestClient(t)
+	executeJobs(t, newTestTarget(client, JobLoad))
+
+	scan := func(table string) *recordingObserver {
+		target := newTestTarget(client, JobRun)
+		target.TableName = table
+		target.DataScanTimer = metrics.NewTimer()
+		target.DataScanMeter = metrics.NewMeter()
+
+		obs := &recordingObserver{}
+		target.Observer = obs
+
+		j := target.NewJob(0)
+		_ = j.scanRows(spanner.Key{""}, 10)
+
+		return obs
+	}
+
+	// A scan of several rows counts once, as it does in the report
+	if obs := scan("Singers"); len(obs.n) != 1 || obs.n[0] != 1 || obs.codes[0] != codes.OK {
+		t.Fatalf("observer saw scan counts %v with codes %v, want [1] with [OK]", obs.n, obs.codes)
+	}
+
+	// A failed scan returns no rows, but is still one failed operation
+	if obs := scan("Missing"); len(obs.n) != 1 || obs.n[0] != 1 || obs.codes[0] == codes.OK {
+		t.Fatalf("observer saw scan counts %v with codes %v, want [1] with an error", obs.n, obs.codes)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/histogram"
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	// Prefix of metrics scoped to a table
+	tableMetricPrefix = "tables."
+)
+
+type (
+	// TableMetrics records the operations of a single table in metrics named
+	// tables.<table>.operations.<op>.<metric>, next to the totals of all tables. All methods
+	// are safe to call on a nil *TableMetrics.
+	TableMetrics struct {
+		DataWriteGenerationTimer metrics.Timer // Used to time data generation
+		DataReadGenerationTimer  metrics.Timer // Used to time data generation
+		DataWriteTimer           metrics.Timer // Used to time writes
+		DataWriteMeter           metrics.Meter // Used to measure volume of writes
+		DataReadTimer            metrics.Timer // Used to time reads
+		DataReadMeter            metrics.Meter // Used to measure volume of reads
+		DataUpdateTimer          metrics.Timer // Used to time updates
+		DataUpdateMeter          metrics.Meter // Used to measure volume of updates
+		DataScanTimer            metrics.Timer // Used to time scans
+		DataScanMeter            metrics.Meter // Used to measure volume of scans
+		DataRMWTimer             metrics.Timer // Used to time read-modify-writes
+		DataRMWMeter             metrics.Meter // Used to measure volume of read-modify-writes
+
+		table    string
+		registry metrics.Registry
+	}
+)
+
+// NewTableMetrics registers the metrics of table
+func NewTableMetrics(registry metrics.Registry, table string, cfg config.Histogram) *TableMetrics {
+	name := func(n string) string { return TableMetricName(table, n) }
+
+	return &TableMetrics{
+		DataWriteGenerationTimer: histogram.GetOrRegisterTimer(name("operations.write.data"), registry, cfg),
+		DataReadGenerationTimer:  histogram.GetOrRegisterTimer(name("operations.read.data"), registry, cfg),
+		DataWriteTimer:           histogram.GetOrRegisterTimer(name("operations.write.time"), registry, cfg),
+		DataWriteMeter:           metrics.GetOrRegisterMeter(name("operations.write.rate"), registry),
+		DataReadTimer:            histogram.GetOrRegisterTimer(name("operations.read.time"), registry, cfg),
+		DataReadMeter:            metrics.GetOrRegisterMeter(name("operations.read.rate"), registry),
+		DataUpdateTimer:          histogram.GetOrRegisterTimer(name("operations.update.time"), registry, cfg),
+		DataUpdateMeter:          metrics.GetOrRegisterMeter(name("operations.update.rate"), registry),
+		DataScanTimer:            histogram.GetOrRegisterTimer(name("operations.scan.time"), registry, cfg),
+		DataScanMeter:            metrics.GetOrRegisterMeter(name("operations.scan.rate"), registry),
+		DataRMWTimer:             histogram.GetOrRegisterTimer(name("operations.read_modify_write.time"), registry, cfg),
+		DataRMWMeter:             metrics.GetOrRegisterMeter(name("operations.read_modify_write.rate"), registry),
+		table:                    table,
+		registry:                 registry,
+	}
+}
+
+// RecordWriteGeneration records the time it took to generate a row
+func (m *TableMetrics) RecordWriteGeneration(d time.Duration) {
+	if m != nil {
+		m.DataWriteGenerationTimer.Update(d)
+	}
+}
+
+// RecordReadGeneration records the time it took to generate a read key
+func (m *TableMetrics) RecordReadGeneration(d time.Duration) {
+	if m != nil {
+		m.DataReadGenerationTimer.Update(d)
+	}
+}
+
+// RecordWrite records a successful write of n rows
+func (m *TableMetrics) RecordWrite(d time.Duration, n int) {
+	if m != nil {
+		m.DataWriteTimer.Update(d)
+		m.DataWriteMeter.Mark(int64(n))
+	}
+}
+
+// RecordRead records a successful read of n rows
+func (m *TableMetrics) RecordRead(d time.Duration, n int) {
+	if m != nil {
+		m.DataReadTimer.Update(d)
+		m.DataReadMeter.Mark(int64(n))
+	}
+}
+
+// RecordUpdate records a successful update of a row
+func (m *TableMetrics) RecordUpdate(d time.Duration) {
+	if m != nil {
+		m.DataUpdateTimer.Update(d)
+		m.DataUpdateMeter.Mark(1)
+	}
+}
+
+// RecordScan records a successful scan
+func (m *TableMetrics) RecordScan(d time.Duration) {
+	if m != nil {
+		m.DataScanTimer.Update(d)
+		m.DataScanMeter.Mark(1)
+	}
+}
+
+// RecordReadModifyWrite records a successful read-modify-write of a row
+func (m *TableMetrics) RecordReadModifyWrite(d time.Duration) {
+	if m != nil {
+		m.DataRMWTimer.Update(d)
+		m.DataRMWMeter.Mark(1)
+	}
+}
+
+// RecordError records n failed operations of type op. Nothing is recorded if err is nil.
+func (m *TableMetrics) RecordError(op string, n int, err error) {
+	if m == nil || err == nil {
+		return
+	}
+
+	metrics.GetOrRegisterCounter(TableMetricName(m.table, ErrorMetricName(op, ErrorCode(err))), m.registry).Inc(int64(n))
+}
+
+// TableMetricName returns the name of metric name scoped to table
+func TableMetricName(table string, name string) string {
+	return tableMetricPrefix + table + "." + name
+}
+
+// ParseTableMetricName splits a name created by TableMetricName into table and metric name.
+// ok is false if name is not scoped to a table.
+func ParseTableMetricName(name string) (table string, metric string, ok bool) {
+	if !strings.HasPrefix(name, tableMetricPrefix) {
+		return "", "", false
+	}
+
+	// Spanner table names can not contain dots
+	parts := strings.SplitN(strings.TrimPrefix(name, tableMetricPrefix), ".", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
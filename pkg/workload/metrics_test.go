@@ -0,0 +1,91 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/rcrowley/go-metrics"
+	"google.golang.org/grpc/codes"
+)
+
+func TestTableMetricName(t *testing.T) {
+	name := TableMetricName("Singers", ErrorMetricName(OperationWrite, codes.Aborted))
+	if name != "tables.Singers.operations.write.errors.Aborted" {
+		t.Fatalf("TableMetricName = %q", name)
+	}
+
+	table, metric, ok := ParseTableMetricName(name)
+	if !ok || table != "Singers" || metric != "operations.write.errors.Aborted" {
+		t.Errorf("ParseTableMetricName = (%q, %q, %v)", table, metric, ok)
+	}
+
+	// Table errors must not be counted twice in the totals
+	if _, _, ok := ParseErrorMetricName(name); ok {
+		t.Error("ParseErrorMetricName accepted a table metric")
+	}
+
+	for _, n := range []string{"operations.read.time", "tables.", "tables.Singers"} {
+		if _, _, ok := ParseTableMetricName(n); ok {
+			t.Errorf("ParseTableMetricName accepted %q", n)
+		}
+	}
+
+	// A nil TableMetrics records nothing
+	var m *TableMetrics
+	m.RecordWrite(time.Millisecond, 1)
+	m.RecordError(OperationRead, 1, errors.New("boom"))
+}
+
+func TestJobTableMetrics(t *testing.T) {
+	client := newTestClient(t)
+	registry := metrics.NewRegistry()
+
+	target := newTestTarget(client, JobLoad)
+	target.TableMetrics = NewTableMetrics(registry, target.TableName, config.Histogram{})
+
+	executeJobs(t, target)
+
+	tm := target.TableMetrics
+	if got, want := tm.DataWriteMeter.Count(), target.DataWriteMeter.Count(); got != want || got == 0 {
+		t.Errorf("table write meter = %d, want %d", got, want)
+	}
+	if got, want := tm.DataWriteTimer.Count(), target.DataWriteTimer.Count(); got != want {
+		t.Errorf("table write timer count = %d, want %d", got, want)
+	}
+	if got, want := tm.DataWriteGenerationTimer.Count(), target.DataWriteGenerationTimer.Count(); got != want {
+		t.Errorf("table generation timer count = %d, want %d", got, want)
+	}
+
+	// Failed operations are counted per table next to the totals
+	j := target.NewJob(0)
+	err := j.checkSpannerError(OperationRead, 1, j.readRow(spanner.Key{"missing"}))
+	if err != nil {
+		t.Fatalf("NotFound was treated as fatal: %v", err)
+	}
+
+	name := TableMetricName("Singers", ErrorMetricName(OperationRead, codes.NotFound))
+	c, ok := registry.Get(name).(metrics.Counter)
+	if !ok || c.Count() != 1 {
+		t.Errorf("%s was not incremented", name)
+	}
+	if tm.DataReadTimer.Count() != 0 {
+		t.Error("failed read was timed")
+	}
+}
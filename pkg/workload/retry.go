@@ -0,0 +1,91 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"google.golang.org/grpc/codes"
+)
+
+type (
+	// RetryPolicy decides if and when a failed attempt of an operation is retried.
+	// A nil *RetryPolicy never retries.
+	RetryPolicy struct {
+		MaxAttempts    int
+		InitialBackoff time.Duration
+		MaxBackoff     time.Duration
+		Multiplier     float64
+		Codes          map[codes.Code]bool
+	}
+)
+
+// NewRetryPolicy returns a RetryPolicy for a validated retry configuration
+func NewRetryPolicy(cfg config.Retry) *RetryPolicy {
+	p := &RetryPolicy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		Multiplier:     cfg.Multiplier,
+		Codes:          make(map[codes.Code]bool, len(cfg.Codes)),
+	}
+
+	// Unknown codes are rejected by config validation
+	cs, _ := cfg.RetryableCodes()
+	for _, c := range cs {
+		p.Codes[c] = true
+	}
+
+	return p
+}
+
+// Retryable returns true if an operation that failed its attempt'th attempt with err should be retried
+func (p *RetryPolicy) Retryable(attempt int, err error) bool {
+	if p == nil || err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+
+	return p.Codes[ErrorCode(err)]
+}
+
+// Backoff returns how long to wait after the attempt'th attempt failed
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+		if d >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+
+	return time.Duration(d)
+}
+
+// ErrorCode returns the gRPC code of err. Context errors, which can be returned before a request
+// is sent, map to their gRPC equivalents.
+func ErrorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	}
+
+	return spanner.ErrCode(err)
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/rcrowley/go-metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testRetryPolicy(attempts int) *RetryPolicy {
+	return NewRetryPolicy(config.Retry{
+		MaxAttempts:    attempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     4 * time.Millisecond,
+		Multiplier:     2,
+		Codes:          []string{"UNAVAILABLE", "Aborted"},
+	})
+}
+
+func counterValue(registry metrics.Registry, name string) int64 {
+	c, ok := registry.Get(name).(metrics.Counter)
+	if !ok {
+		return 0
+	}
+
+	return c.Count()
+}
+
+func TestRetryPolicy(t *testing.T) {
+	p := testRetryPolicy(3)
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	for _, tc := range []struct {
+		attempt int
+		err     error
+		want    bool
+	}{
+		{1, unavailable, true},
+		{2, status.Error(codes.Aborted, "aborted"), true},
+		{3, unavailable, false},
+		{1, status.Error(codes.NotFound, "not found"), false},
+		{1, nil, false},
+	} {
+		if got := p.Retryable(tc.attempt, tc.err); got != tc.want {
+			t.Errorf("Retryable(%d, %v) = %v, want %v", tc.attempt, tc.err, got, tc.want)
+		}
+	}
+
+	for attempt, want := range []time.Duration{1: time.Millisecond, 2: 2 * time.Millisecond, 3: 4 * time.Millisecond, 4: 4 * time.Millisecond} {
+		if attempt == 0 {
+			continue
+		}
+		if got := p.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+
+	var nilPolicy *RetryPolicy
+	if nilPolicy.Retryable(1, unavailable) {
+		t.Error("nil policy retried")
+	}
+}
+
+func TestJobWithRetry(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	newJob := func(policy *RetryPolicy) (*Job, metrics.Registry) {
+		registry := metrics.NewRegistry()
+		return &Job{
+			Context:     context.Background(),
+			ReadRetry:   policy,
+			ErrorBudget: NewErrorBudget(registry, 0, 0),
+		}, registry
+	}
+
+	t.Run("retries until success", func(t *testing.T) {
+		j, registry := newJob(testRetryPolicy(3))
+
+		calls := 0
+		err := j.withRetry(j.Context, OperationRead, 1, j.ReadRetry, func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return unavailable
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("withRetry returned %v", err)
+		}
+
+		if got := counterValue(registry, AttemptMetricName(OperationRead)); got != 3 {
+			t.Errorf("attempts = %d, want 3", got)
+		}
+		if got := counterValue(registry, AttemptErrorMetricName(OperationRead, codes.Unavailable)); got != 2 {
+			t.Errorf("failed attempts = %d, want 2", got)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		j, _ := newJob(testRetryPolicy(2))
+
+		calls := 0
+		err := j.withRetry(j.Context, OperationRead, 1, j.ReadRetry, func(ctx context.Context) error {
+			calls++
+			return unavailable
+		})
+		if spanner.ErrCode(err) != codes.Unavailable || calls != 2 {
+			t.Errorf("withRetry returned %v after %d calls, want Unavailable after 2", err, calls)
+		}
+	})
+
+	t.Run("deadline covers all attempts", func(t *testing.T) {
+		j, _ := newJob(testRetryPolicy(1000))
+		j.Timeout = 20 * time.Millisecond
+
+		err := j.withRetry(j.Context, OperationRead, 1, j.ReadRetry, func(ctx context.Context) error {
+			return unavailable
+		})
+		if ErrorCode(err) != codes.DeadlineExceeded {
+			t.Errorf("withRetry returned %v, want DeadlineExceeded", err)
+		}
+	})
+}
+
+func TestJobTimeout(t *testing.T) {
+	client := newTestClient(t)
+	registry := metrics.NewRegistry()
+	target := newTestTarget(client, JobRun)
+	target.ErrorBudget = NewErrorBudget(registry, 0, 0)
+	target.Config.Operations.Timeout = time.Nanosecond
+
+	// Every read misses the deadline. It is recorded as a failed operation, not a fatal error.
+	j := target.NewJob(0)
+	if err := j.checkSpannerError(OperationRead, 1, j.readRow(spanner.Key{"a"})); err != nil {
+		t.Fatalf("ReadOne returned a fatal error: %v", err)
+	}
+
+	if got := counterValue(registry, ErrorMetricName(OperationRead, codes.DeadlineExceeded)); got != 1 {
+		t.Errorf("%s = %d, want 1", ErrorMetricName(OperationRead, codes.DeadlineExceeded), got)
+	}
+}
@@ -16,67 +16,111 @@ package workload
 
 import (
 	"context"
+	"math/rand"
 
-	"cloud.google.com/go/spanner"
-	"github.com/rcrowley/go-metrics"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/data"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/sample"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/seed"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/selector"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
+	"github.com/rcrowley/go-metrics"
 )
 
 type Target struct {
 	Config                   *config.Config
 	Context                  context.Context
-	Client                   *spanner.Client
-	JobType                  JobType                 // Determines if we are in a 'run' phase or a 'load' phase
-	Table                    schema.Table            // Which table this target points at
-	TableName                string                  // string name of the table
-	Operations               int                     // Total number of operations to execute against this target
-	ColumnNames              []string                // Col names for reads
-	OperationSelector        selector.Selector       // If JobType == JobRun this is used to determine if it should be a read op or a write op
-	WriteGenerator           data.GeneratorMap       // Map used for generating row data on inserts
-	ReadGenerator            *sample.SampleGenerator // Sample generator for generating point reads
-	DataWriteGenerationTimer metrics.Timer           // Used to time data generation
-	DataReadGenerationTimer  metrics.Timer           // Used to time data geenration
-	DataWriteTimer           metrics.Timer           // Used to time writes
-	DataWriteMeter           metrics.Meter           // Used to measure volume of writes
-	DataReadTimer            metrics.Timer           // Used to time reads
-	DataReadMeter            metrics.Meter           // Used to measure volume of reads
+	Client                   backend.Client
+	JobType                  JobType             // Determines if we are in a 'run' phase or a 'load' phase
+	Table                    schema.Table        // Which table this target points at
+	TableName                string              // string name of the table
+	Operations               int                 // Total number of operations to execute against this target
+	ColumnNames              []string            // Col names for reads
+	KeyColumns               []string            // Primary key col names
+	OperationSelector        selector.Selector   // If JobType == JobRun this is used to determine if it should be a read op or a write op
+	WriteGenerator           data.GeneratorMap   // Map used for generating row data on inserts
+	ReadGenerator            sample.KeyGenerator // Sample generator for generating point reads
+	RecentKeys               *sample.RecentKeys  // Keys inserted during the run. nil unless operations.latest.fraction > 0
+	DataWriteGenerationTimer metrics.Timer       // Used to time data generation
+	DataReadGenerationTimer  metrics.Timer       // Used to time data geenration
+	DataWriteTimer           metrics.Timer       // Used to time writes
+	DataWriteMeter           metrics.Meter       // Used to measure volume of writes
+	DataReadTimer            metrics.Timer       // Used to time reads
+	DataReadMeter            metrics.Meter       // Used to measure volume of reads
+	DataUpdateTimer          metrics.Timer       // Used to time updates
+	DataUpdateMeter          metrics.Meter       // Used to measure volume of updates
+	DataScanTimer            metrics.Timer       // Used to time scans
+	DataScanMeter            metrics.Meter       // Used to measure volume of scans
+	DataRMWTimer             metrics.Timer       // Used to time read-modify-writes
+	DataRMWMeter             metrics.Meter       // Used to measure volume of read-modify-writes
+	ErrorBudget              *ErrorBudget        // Counts failed operations
+	TableMetrics             *TableMetrics       // Metrics of this table
+	Observer                 observer.Observer   // Notified about every operation (optional)
+	Stop                     <-chan struct{}     // Closed when the jobs of this target must stop early (optional)
 }
 
-func (t *Target) NewJob() *Job {
+// NewJob returns a job for the given worker. Each job owns its generators and selector, which draw
+// from independent sources derived from the run seed, so jobs never share a *rand.Rand.
+func (t *Target) NewJob(worker int) *Job {
 	j := &Job{
 		JobType:                  t.JobType,
 		Context:                  t.Context,
 		Client:                   t.Client,
 		Table:                    t.TableName,
 		Columns:                  t.ColumnNames,
+		KeyColumns:               t.KeyColumns,
 		StaleReads:               t.Config.Operations.ReadStale,
 		Staleness:                t.Config.Operations.Staleness,
+		Timeout:                  t.Config.Operations.Timeout,
+		ReadRetry:                NewRetryPolicy(t.Config.Operations.Retry.Read),
+		WriteRetry:               NewRetryPolicy(t.Config.Operations.Retry.Write),
 		Batched:                  t.Config.Batch,
 		BatchSize:                t.Config.BatchSize,
-		OperationSelector:        t.OperationSelector,
 		WriteGenerator:           t.WriteGenerator,
-		ReadGenerator:            t.ReadGenerator,
+		RecentKeys:               t.RecentKeys,
 		DataWriteGenerationTimer: t.DataWriteGenerationTimer,
 		DataReadGenerationTimer:  t.DataReadGenerationTimer,
 		DataWriteTimer:           t.DataWriteTimer,
 		DataWriteMeter:           t.DataWriteMeter,
 		DataReadTimer:            t.DataReadTimer,
 		DataReadMeter:            t.DataReadMeter,
+		DataUpdateTimer:          t.DataUpdateTimer,
+		DataUpdateMeter:          t.DataUpdateMeter,
+		DataScanTimer:            t.DataScanTimer,
+		DataScanMeter:            t.DataScanMeter,
+		DataRMWTimer:             t.DataRMWTimer,
+		DataRMWMeter:             t.DataRMWMeter,
+		ErrorBudget:              t.ErrorBudget,
+		TableMetrics:             t.TableMetrics,
+		Observer:                 t.Observer,
+		Stop:                     t.Stop,
 	}
 
-	t.CreateMaps(j)
+	t.CreateMaps(j, worker)
 
 	return j
 }
 
-func (t *Target) CreateMaps(j *Job) {
+func (t *Target) CreateMaps(j *Job, worker int) {
+	// Fork the operation selector and read generator for the worker
+	if t.OperationSelector != nil {
+		j.OperationSelector = t.OperationSelector.Fork(t.workerRand(worker, "selector"))
+	}
+
+	if t.ReadGenerator != nil {
+		j.ReadGenerator = t.ReadGenerator.Fork(t.workerRand(worker, "reads"))
+	}
+
+	if t.Config.Operations.Scan > 0 {
+		j.MaxScanLength = t.Config.Operations.MaxScanLength
+		j.ScanSource = t.workerRand(worker, "scans")
+	}
+
 	// Create a generator map for the table
-	gm, err := t.GetGeneratorMap()
+	gm, err := t.GetGeneratorMap(worker)
 	if err != nil {
 		return
 	}
@@ -85,8 +129,13 @@ func (t *Target) CreateMaps(j *Job) {
 }
 
 // GetGeneratorMap will return a generator map suitable for creating insert operations against a table
-func (t *Target) GetGeneratorMap() (data.GeneratorMap, error) {
-	return generator.GetDataGeneratorMapForTable(*t.Config, t.Table)
+func (t *Target) GetGeneratorMap(worker int) (data.GeneratorMap, error) {
+	return generator.GetDataGeneratorMapForTable(t.Config, t.Table, t.JobType.String(), worker)
+}
+
+// workerRand returns the random source for one of a workers streams
+func (t *Target) workerRand(worker int, stream string) *rand.Rand {
+	return rand.New(seed.Source(t.Config.Seed, "table", t.TableName, "worker", seed.Worker(worker), stream))
 }
 
 func FindTargetByName(plan []*Target, name string) *Target {
@@ -22,3 +22,14 @@ const (
 	JobLoad JobType = 1 + iota
 	JobRun
 )
+
+func (j JobType) String() string {
+	switch j {
+	case JobLoad:
+		return "load"
+	case JobRun:
+		return "run"
+	}
+
+	return "unknown"
+}
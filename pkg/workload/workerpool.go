@@ -20,13 +20,14 @@ import (
 	"fmt"
 	"sync"
 
-	"cloud.google.com/go/spanner"
-	"github.com/rcrowley/go-metrics"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/operation"
+	"github.com/cloudspannerecosystem/gcsb/pkg/generator/seed"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
 	"github.com/cloudspannerecosystem/gcsb/pkg/workload/pool"
+	"github.com/rcrowley/go-metrics"
 )
 
 var (
@@ -43,7 +44,7 @@ type (
 		Pool            *pool.Pool
 		Jobs            []pool.Job
 		wg              sync.WaitGroup
-		client          *spanner.Client
+		client          backend.Client
 		MetricsRegistry metrics.Registry
 	}
 )
@@ -117,7 +118,7 @@ func (w *WorkerPool) Load(tables []string) error {
 	opsPerJob := w.Config.Operations.Total / w.Config.Threads
 	for i := 1; i <= w.Config.Threads; i++ {
 		// Create a unique generator map instance for each job
-		genMap, err := generator.GetDataGeneratorMapForTable(*w.Config, table)
+		genMap, err := generator.GetDataGeneratorMapForTable(w.Config, table, JobLoad.String(), i)
 		if err != nil {
 			return fmt.Errorf("getting generator map: %s", err.Error())
 		}
@@ -184,19 +185,19 @@ func (w *WorkerPool) Run(tableName string) error {
 	// Create 1 job per thread
 	for i := 1; i <= w.Config.Threads; i++ {
 		// Create operation selector
-		sel, err := operation.NewOperationSelector(w.Config)
+		sel, err := operation.NewOperationSelector(w.Config, seed.Source(w.Config.Seed, "table", tableName, "worker", seed.Worker(i), "selector"))
 		if err != nil {
 			return fmt.Errorf("getting operation selector: %s", err.Error())
 		}
 
 		// Construct generator map for table inserts
-		insertMap, err := generator.GetDataGeneratorMapForTable(*w.Config, table)
+		insertMap, err := generator.GetDataGeneratorMapForTable(w.Config, table, JobRun.String(), i)
 		if err != nil {
 			return fmt.Errorf("getting insert generator map: %s", err.Error())
 		}
 
 		// initialize a static value generator for READ ops (readMap)
-		gen, err := generator.GetReadGeneratorMap(samples, table.PrimaryKeyNames())
+		gen, err := generator.GetReadGeneratorMap(seed.Source(w.Config.Seed, "table", tableName, "worker", seed.Worker(i), "reads"), samples, table.PrimaryKeyNames())
 		if err != nil {
 			return fmt.Errorf("error getting read generator: %s", err.Error())
 		}
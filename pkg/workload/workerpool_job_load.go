@@ -21,6 +21,7 @@ import (
 
 	"cloud.google.com/go/spanner"
 	"github.com/rcrowley/go-metrics"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/data"
 	"github.com/cloudspannerecosystem/gcsb/pkg/workload/pool"
 	"google.golang.org/grpc/codes"
@@ -35,7 +36,7 @@ type (
 	// WorkerPoolLoadJob is responsible for inserting data into a table
 	WorkerPoolLoadJob struct {
 		Context         context.Context
-		Client          *spanner.Client
+		Client          backend.Client
 		TableName       string
 		RowCount        int
 		Statement       string
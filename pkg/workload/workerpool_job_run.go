@@ -22,6 +22,7 @@ import (
 
 	"cloud.google.com/go/spanner"
 	"github.com/rcrowley/go-metrics"
+	"github.com/cloudspannerecosystem/gcsb/pkg/backend"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/data"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/operation"
 	"github.com/cloudspannerecosystem/gcsb/pkg/generator/sample"
@@ -39,7 +40,7 @@ var (
 type (
 	WorkerPoolRunJob struct {
 		Context           context.Context
-		Client            *spanner.Client
+		Client            backend.Client
 		TableName         string
 		ReadMap           data.GeneratorMap // Generate data for point reads
 		WriteMap          data.GeneratorMap // Generate data for writes
@@ -19,8 +19,10 @@ package workload
 
 import (
 	"context"
+	"time"
 
 	"github.com/cloudspannerecosystem/gcsb/pkg/config"
+	"github.com/cloudspannerecosystem/gcsb/pkg/observer"
 	"github.com/cloudspannerecosystem/gcsb/pkg/schema"
 
 	"github.com/rcrowley/go-metrics"
@@ -35,11 +37,36 @@ type (
 		Stop() error
 	}
 
+	// PlanSummarizer is implemented by workloads that can describe the targets they planned
+	PlanSummarizer interface {
+		PlanSummary() []TargetSummary
+	}
+
+	// ExecutionRecorder is implemented by workloads that record when they executed their last plan
+	ExecutionRecorder interface {
+		ExecutionWindow() (time.Time, time.Time)
+	}
+
+	// TargetSummary describes a planned target
+	TargetSummary struct {
+		Table           string  `json:"table"`
+		Phase           string  `json:"phase"` // LOAD or RUN
+		Operations      int     `json:"operations"`
+		Read            int     `json:"read"`              // Read weight. 0 for load targets
+		Write           int     `json:"write"`             // Write weight. 0 for load targets
+		Update          int     `json:"update"`            // Update weight. 0 for load targets
+		Scan            int     `json:"scan"`              // Scan weight. 0 for load targets
+		ReadModifyWrite int     `json:"read_modify_write"` // Read-modify-write weight. 0 for load targets
+		Distribution    string  `json:"distribution"`      // Distribution of keys chosen from the sample. Empty for load targets
+		Latest          float64 `json:"latest"`            // Fraction of reads targeting keys inserted during the run
+	}
+
 	WorkloadConfig struct {
 		Context        context.Context
 		Config         *config.Config
 		Schema         schema.Schema
 		MetricRegistry metrics.Registry
+		Observer       observer.Observer // Notified about every operation (optional)
 	}
 )
 
@@ -51,3 +78,20 @@ func GetWorkloadConstructor(workloadType string) (Constructor, error) {
 		return NewCoreWorkload, nil
 	}
 }
+
+// ExecutionWindow returns when wl started and finished executing its plan, leaving out planning
+// and sampling, so that rates are computed over the time operations were executed. It returns
+// start and end if wl does not record its execution or did not execute.
+func ExecutionWindow(wl Workload, start time.Time, end time.Time) (time.Time, time.Time) {
+	r, ok := wl.(ExecutionRecorder)
+	if !ok {
+		return start, end
+	}
+
+	s, e := r.ExecutionWindow()
+	if s.IsZero() || e.IsZero() {
+		return start, end
+	}
+
+	return s, e
+}
@@ -0,0 +1,24 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schemas embeds the benchmark schemas of this directory and the generator configs of
+// the presets built on them (see package preset), so that they ship with the binary
+package schemas
+
+import "embed"
+
+// FS holds the DDL files and presets/*.yaml
+//
+//go:embed *.sql presets/*.yaml
+var FS embed.FS
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+ * TPC-C. Every table but item is interleaved in warehouse, so that the rows of a warehouse are
+ * stored together. Amounts are FLOAT64.
+ */
+CREATE TABLE warehouse (
+  w_id       INT64 NOT NULL,
+  w_name     STRING(10),
+  w_street_1 STRING(20),
+  w_street_2 STRING(20),
+  w_city     STRING(20),
+  w_state    STRING(2),
+  w_zip      STRING(9),
+  w_tax      FLOAT64,
+  w_ytd      FLOAT64,
+) PRIMARY KEY (w_id);
+
+CREATE TABLE district (
+  w_id        INT64 NOT NULL,
+  d_id        INT64 NOT NULL,
+  d_name      STRING(10),
+  d_street_1  STRING(20),
+  d_street_2  STRING(20),
+  d_city      STRING(20),
+  d_state     STRING(2),
+  d_zip       STRING(9),
+  d_tax       FLOAT64,
+  d_ytd       FLOAT64,
+  d_next_o_id INT64,
+) PRIMARY KEY (w_id, d_id),
+  INTERLEAVE IN PARENT warehouse ON DELETE CASCADE;
+
+CREATE TABLE customer (
+  w_id           INT64 NOT NULL,
+  d_id           INT64 NOT NULL,
+  c_id           INT64 NOT NULL,
+  c_first        STRING(16),
+  c_middle       STRING(2),
+  c_last         STRING(16),
+  c_street_1     STRING(20),
+  c_street_2     STRING(20),
+  c_city         STRING(20),
+  c_state        STRING(2),
+  c_zip          STRING(9),
+  c_phone        STRING(16),
+  c_since        TIMESTAMP,
+  c_credit       STRING(2),
+  c_credit_lim   FLOAT64,
+  c_discount     FLOAT64,
+  c_balance      FLOAT64,
+  c_ytd_payment  FLOAT64,
+  c_payment_cnt  INT64,
+  c_delivery_cnt INT64,
+  c_data         STRING(500),
+) PRIMARY KEY (w_id, d_id, c_id),
+  INTERLEAVE IN PARENT district ON DELETE CASCADE;
+
+CREATE INDEX customer_by_last ON customer(w_id, d_id, c_last, c_first), INTERLEAVE IN district;
+
+CREATE TABLE history (
+  w_id     INT64 NOT NULL,
+  d_id     INT64 NOT NULL,
+  c_id     INT64 NOT NULL,
+  h_id     STRING(36) NOT NULL,
+  h_d_id   INT64,
+  h_w_id   INT64,
+  h_date   TIMESTAMP,
+  h_amount FLOAT64,
+  h_data   STRING(24),
+) PRIMARY KEY (w_id, d_id, c_id, h_id),
+  INTERLEAVE IN PARENT customer ON DELETE CASCADE;
+
+CREATE TABLE orders (
+  w_id         INT64 NOT NULL,
+  d_id         INT64 NOT NULL,
+  o_id         INT64 NOT NULL,
+  o_c_id       INT64,
+  o_entry_d    TIMESTAMP,
+  o_carrier_id INT64,
+  o_ol_cnt     INT64,
+  o_all_local  INT64,
+) PRIMARY KEY (w_id, d_id, o_id),
+  INTERLEAVE IN PARENT district ON DELETE CASCADE;
+
+CREATE INDEX orders_by_customer ON orders(w_id, d_id, o_c_id, o_id DESC) STORING (o_entry_d, o_carrier_id), INTERLEAVE IN district;
+
+CREATE TABLE new_order (
+  w_id INT64 NOT NULL,
+  d_id INT64 NOT NULL,
+  o_id INT64 NOT NULL,
+) PRIMARY KEY (w_id, d_id, o_id),
+  INTERLEAVE IN PARENT district ON DELETE CASCADE;
+
+CREATE TABLE order_line (
+  w_id           INT64 NOT NULL,
+  d_id           INT64 NOT NULL,
+  o_id           INT64 NOT NULL,
+  ol_number      INT64 NOT NULL,
+  ol_i_id        INT64,
+  ol_supply_w_id INT64,
+  ol_delivery_d  TIMESTAMP,
+  ol_quantity    INT64,
+  ol_amount      FLOAT64,
+  ol_dist_info   STRING(24),
+) PRIMARY KEY (w_id, d_id, o_id, ol_number),
+  INTERLEAVE IN PARENT orders ON DELETE CASCADE;
+
+CREATE TABLE item (
+  i_id    INT64 NOT NULL,
+  i_im_id INT64,
+  i_name  STRING(24),
+  i_price FLOAT64,
+  i_data  STRING(50),
+) PRIMARY KEY (i_id);
+
+CREATE TABLE stock (
+  w_id         INT64 NOT NULL,
+  s_i_id       INT64 NOT NULL,
+  s_quantity   INT64,
+  s_dist_01    STRING(24),
+  s_dist_02    STRING(24),
+  s_dist_03    STRING(24),
+  s_dist_04    STRING(24),
+  s_dist_05    STRING(24),
+  s_dist_06    STRING(24),
+  s_dist_07    STRING(24),
+  s_dist_08    STRING(24),
+  s_dist_09    STRING(24),
+  s_dist_10    STRING(24),
+  s_ytd        INT64,
+  s_order_cnt  INT64,
+  s_remote_cnt INT64,
+  s_data       STRING(50),
+) PRIMARY KEY (w_id, s_i_id),
+  INTERLEAVE IN PARENT warehouse ON DELETE CASCADE;
@@ -0,0 +1,32 @@
+/*
+Copyright 2022 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+ * The record of the YCSB core workloads: a string key and ten 100 character fields
+ */
+CREATE TABLE usertable (
+  YCSB_KEY STRING(MAX) NOT NULL,
+  FIELD0   STRING(100),
+  FIELD1   STRING(100),
+  FIELD2   STRING(100),
+  FIELD3   STRING(100),
+  FIELD4   STRING(100),
+  FIELD5   STRING(100),
+  FIELD6   STRING(100),
+  FIELD7   STRING(100),
+  FIELD8   STRING(100),
+  FIELD9   STRING(100),
+) PRIMARY KEY (YCSB_KEY);